@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/certs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// connFlags are the connection flags shared by all commands.
+type connFlags struct {
+	addr       string
+	useTLS     bool
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string
+	insecure   bool
+	timeout    time.Duration
+	output     string
+}
+
+func addConnFlags(fs *flag.FlagSet) *connFlags {
+	c := &connFlags{}
+	fs.StringVar(&c.addr, "addr", envOr("SHORTENER_ADDR", "localhost:8080"), "address of the gRPC server")
+	fs.BoolVar(&c.useTLS, "tls", false, "connect over TLS")
+	fs.StringVar(&c.caFile, "ca", "", "CA certificates to verify the server with, implies -tls")
+	fs.StringVar(&c.certFile, "cert", "", "client certificate for mutual TLS, implies -tls")
+	fs.StringVar(&c.keyFile, "key", "", "key of the client certificate")
+	fs.StringVar(&c.serverName, "server-name", "", "expected name of the server certificate")
+	fs.BoolVar(&c.insecure, "insecure", false, "skip verification of the server certificate")
+	fs.DurationVar(&c.timeout, "timeout", 10*time.Second, "timeout of each call")
+	fs.StringVar(&c.output, "o", "table", "output format: table or json")
+	return c
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (c *connFlags) transportCredentials() (credentials.TransportCredentials, error) {
+	if !c.useTLS && c.caFile == "" && c.certFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.serverName,
+		InsecureSkipVerify: c.insecure,
+	}
+	if c.caFile != "" {
+		roots, err := certs.LoadCAs(c.caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = roots
+	}
+	if c.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// client is a connection to the service.
+type client struct {
+	url.ShortenerServiceClient
+	conn  io.Closer
+	flags *connFlags
+}
+
+func (c *connFlags) dial() (*client, error) {
+	if c.output != "table" && c.output != "json" {
+		return nil, fmt.Errorf("unknown output format %q", c.output)
+	}
+	service, conn, err := connect(c)
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		ShortenerServiceClient: service,
+		conn:                   conn,
+		flags:                  c,
+	}, nil
+}
+
+// connect opens a connection to the service. Tests replace it to talk to a
+// fake service.
+var connect = func(c *connFlags) (url.ShortenerServiceClient, io.Closer, error) {
+	creds, err := c.transportCredentials()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := grpc.NewClient(c.addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", c.addr, err)
+	}
+	return url.NewShortenerServiceClient(conn), conn, nil
+}
+
+// context returns the context of one call.
+func (c *client) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.flags.timeout)
+}
+
+func (c *client) printer() printer {
+	return printer{format: c.flags.output, w: stdout}
+}
+
+func (c *client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// passwordMetadataKey carries the password of a protected link.
+const passwordMetadataKey = "x-link-password"
+
+// link is a short link as the commands print and the files hold it.
+type link struct {
+	Alias       string `json:"alias,omitempty"`
+	ShortURL    string `json:"short_url,omitempty"`
+	OriginalURL string `json:"original_url"`
+	Domain      string `json:"domain,omitempty"`
+	Status      string `json:"status,omitempty"`
+	// TTL is only read by import.
+	TTL        string `json:"ttl,omitempty"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	MaxClicks  int64  `json:"max_clicks,omitempty"`
+	ClicksLeft int64  `json:"clicks_left,omitempty"`
+	// Version is only written by export.
+	Version int64 `json:"version,omitempty"`
+}
+
+var linkHeaders = []string{"ALIAS", "SHORT URL", "ORIGINAL URL", "STATUS", "EXPIRES AT", "CLICKS LEFT", "VERSION"}
+
+func (l link) row() []string {
+	clicksLeft := "unlimited"
+	if l.MaxClicks > 0 {
+		clicksLeft = fmt.Sprintf("%d/%d", l.ClicksLeft, l.MaxClicks)
+	}
+	expiresAt := l.ExpiresAt
+	if expiresAt == "" {
+		expiresAt = "never"
+	}
+	return []string{l.Alias, l.ShortURL, l.OriginalURL, l.Status, expiresAt, clicksLeft, strconv.FormatInt(l.Version, 10)}
+}
+
+func linkFromURL(u *url.URL) link {
+	return link{Alias: u.Code, ShortURL: u.ShortUrl, OriginalURL: u.OriginalUrl, Domain: u.Domain, Status: "active"}
+}
+
+func linkFromStats(stats *url.URLStats) link {
+	l := link{
+		Alias:       stats.Code,
+		ShortURL:    stats.ShortUrl,
+		OriginalURL: stats.OriginalUrl,
+		Domain:      stats.Domain,
+		Status:      statusName(stats.Status),
+		MaxClicks:   stats.MaxClicks,
+		ClicksLeft:  stats.ClicksLeft,
+		Version:     stats.Version,
+	}
+	if stats.ExpiresAt != nil {
+		l.ExpiresAt = stats.ExpiresAt.AsTime().Format(time.RFC3339)
+	}
+	return l
+}
+
+func statusName(status url.LinkStatus) string {
+	switch status {
+	case url.LinkStatus_LINK_STATUS_ACTIVE:
+		return "active"
+	case url.LinkStatus_LINK_STATUS_DISABLED:
+		return "disabled"
+	case url.LinkStatus_LINK_STATUS_DELETED:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// request turns l into a request creating the same link.
+func (l link) request() (*url.GenerateShortURLRequest, error) {
+	req := &url.GenerateShortURLRequest{
+		OriginalUrl: l.OriginalURL,
+		Alias:       l.Alias,
+		Domain:      l.Domain,
+		MaxClicks:   l.MaxClicks,
+	}
+	if l.OriginalURL == "" {
+		return nil, errors.New("original_url is required")
+	}
+	if l.TTL != "" {
+		ttl, err := time.ParseDuration(l.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ttl: %w", err)
+		}
+		req.Ttl = durationpb.New(ttl)
+	}
+	if l.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, l.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expires_at: %w", err)
+		}
+		req.ExpiresAt = timestamppb.New(expiresAt)
+	}
+	return req, nil
+}
+
+func runCreate(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	conn := addConnFlags(fs)
+	var l link
+	fs.StringVar(&l.TTL, "ttl", "", "lifetime of the link, e.g. 24h")
+	fs.StringVar(&l.Alias, "alias", "", "custom code instead of a generated one")
+	fs.StringVar(&l.Domain, "domain", "", "branded domain to create the link on")
+	fs.Int64Var(&l.MaxClicks, "max-clicks", 0, "delete the link after this many visits")
+	idempotencyKey := fs.String("idempotency-key", "", "key that makes retries return the same link")
+	fs.Usage = commandUsage(fs, "create [flags] <original-url>")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one original url")
+	}
+	l.OriginalURL = fs.Arg(0)
+
+	req, err := l.request()
+	if err != nil {
+		return err
+	}
+	req.IdempotencyKey = *idempotencyKey
+	c, err := conn.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ctx, cancel := c.context()
+	defer cancel()
+	created, err := c.GenerateShortURL(ctx, req)
+	if err != nil {
+		return err
+	}
+	result := linkFromURL(created)
+	return c.printer().print(result, []string{"ALIAS", "SHORT URL", "ORIGINAL URL"},
+		[][]string{{result.Alias, result.ShortURL, result.OriginalURL}})
+}
+
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	conn := addConnFlags(fs)
+	password := fs.String("password", "", "password of a protected link")
+	fs.Usage = commandUsage(fs, "get [flags] <code-or-link>\n\nResolving counts as a visit; use stats to look at a link without one.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one short link")
+	}
+
+	c, err := conn.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ctx, cancel := c.context()
+	defer cancel()
+	if *password != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, passwordMetadataKey, *password)
+	}
+	resolved, err := c.GetOriginalURL(ctx, &url.ShortURL{Url: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	result := struct {
+		URL     string `json:"url"`
+		Variant string `json:"variant,omitempty"`
+	}{resolved.Url, resolved.Variant}
+	return c.printer().print(result, []string{"URL", "VARIANT"}, [][]string{{result.URL, result.Variant}})
+}
+
+func runDelete(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	conn := addConnFlags(fs)
+	ifURL := fs.String("if-url", "", "only delete links that still point to this url")
+	ifVersion := fs.Int64("if-version", 0, "only delete links still at this version, as shown by stats")
+	fs.Usage = commandUsage(fs, "delete [flags] <code-or-link>...")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected at least one short link")
+	}
+
+	c, err := conn.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	type deleted struct {
+		URL   string `json:"url"`
+		Error string `json:"error,omitempty"`
+	}
+	var results []deleted
+	var rows [][]string
+	failed := 0
+	for _, shortURL := range fs.Args() {
+		ctx, cancel := c.context()
+		_, err := c.DeleteShortURL(ctx, &url.DeleteShortURLRequest{
+			Url:                 shortURL,
+			ExpectedOriginalUrl: *ifURL,
+			ExpectedVersion:     *ifVersion,
+		})
+		cancel()
+
+		result := deleted{URL: shortURL}
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+		results = append(results, result)
+		rows = append(rows, []string{result.URL, resultText(result.Error)})
+	}
+	if err := c.printer().print(results, []string{"URL", "RESULT"}, rows); err != nil {
+		return err
+	}
+	return failures(failed, len(results))
+}
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	conn := addConnFlags(fs)
+	fs.Usage = commandUsage(fs, "stats [flags] <code-or-link>...")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected at least one short link")
+	}
+
+	c, err := conn.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	links, err := c.stats(fs.Args())
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(links))
+	for _, l := range links {
+		rows = append(rows, l.row())
+	}
+	return c.printer().print(links, linkHeaders, rows)
+}
+
+// stats describes the links, stopping at the first failure.
+func (c *client) stats(shortURLs []string) ([]link, error) {
+	links := make([]link, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		ctx, cancel := c.context()
+		stats, err := c.GetURLStats(ctx, &url.ShortURL{Url: shortURL})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", shortURL, err)
+		}
+		links = append(links, linkFromStats(stats))
+	}
+	return links, nil
+}
+
+func resultText(err string) string {
+	if err == "" {
+		return "ok"
+	}
+	return err
+}
+
+// failures reports how many of total items failed, if any did.
+func failures(failed, total int) error {
+	if failed == 0 {
+		return nil
+	}
+	return errors.New(strconv.Itoa(failed) + " of " + strconv.Itoa(total) + " failed")
+}
+
+func commandUsage(fs *flag.FlagSet, synopsis string) func() {
+	return func() {
+		fmt.Fprintf(fs.Output(), "Usage: shortener-cli %s\n\nFlags:\n", synopsis)
+		fs.PrintDefaults()
+	}
+}
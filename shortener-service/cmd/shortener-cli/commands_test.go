@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeService records the requests it gets and answers from stats.
+type fakeService struct {
+	url.ShortenerServiceClient
+	created []*url.GenerateShortURLRequest
+	deleted []*url.DeleteShortURLRequest
+	md      metadata.MD
+	stats   map[string]*url.URLStats
+}
+
+func (s *fakeService) GenerateShortURL(ctx context.Context, in *url.GenerateShortURLRequest, _ ...grpc.CallOption) (*url.URL, error) {
+	s.md, _ = metadata.FromOutgoingContext(ctx)
+	s.created = append(s.created, in)
+	if in.OriginalUrl == "https://blocked.example" {
+		return nil, status.Error(codes.InvalidArgument, "destination is blocked")
+	}
+	code := in.Alias
+	if code == "" {
+		code = "gen1"
+	}
+	return &url.URL{ShortUrl: "https://sho.rt/" + code, OriginalUrl: in.OriginalUrl, Code: code, Domain: in.Domain}, nil
+}
+
+func (s *fakeService) GetOriginalURL(ctx context.Context, in *url.ShortURL, _ ...grpc.CallOption) (*url.OriginalURL, error) {
+	s.md, _ = metadata.FromOutgoingContext(ctx)
+	stats, ok := s.stats[in.Url]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "URL not found")
+	}
+	return &url.OriginalURL{Url: stats.OriginalUrl, Variant: "b"}, nil
+}
+
+func (s *fakeService) DeleteShortURL(ctx context.Context, in *url.DeleteShortURLRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	s.md, _ = metadata.FromOutgoingContext(ctx)
+	s.deleted = append(s.deleted, in)
+	if _, ok := s.stats[in.Url]; !ok {
+		return nil, status.Error(codes.NotFound, "URL not found")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *fakeService) GetURLStats(ctx context.Context, in *url.ShortURL, _ ...grpc.CallOption) (*url.URLStats, error) {
+	s.md, _ = metadata.FromOutgoingContext(ctx)
+	stats, ok := s.stats[in.Url]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "URL not found")
+	}
+	return stats, nil
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{stats: map[string]*url.URLStats{
+		"abc123": {
+			ShortUrl:    "https://sho.rt/abc123",
+			Code:        "abc123",
+			OriginalUrl: "https://example.com",
+			Status:      url.LinkStatus_LINK_STATUS_ACTIVE,
+			Version:     1,
+		},
+		"promo": {
+			ShortUrl:    "https://sho.rt/promo",
+			Code:        "promo",
+			OriginalUrl: "https://example.com/sale",
+			Status:      url.LinkStatus_LINK_STATUS_DISABLED,
+			MaxClicks:   5,
+			ClicksLeft:  2,
+			ExpiresAt:   timestamppb.New(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)),
+			Version:     3,
+		},
+	}}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// run runs the command with args against service and returns its output.
+func run(t *testing.T, service *fakeService, cmd string, args ...string) (string, error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	oldStdout, oldStderr, oldConnect := stdout, stderr, connect
+	t.Cleanup(func() { stdout, stderr, connect = oldStdout, oldStderr, oldConnect })
+	stdout, stderr = &out, &errOut
+	connect = func(*connFlags) (url.ShortenerServiceClient, io.Closer, error) {
+		return service, nopCloser{}, nil
+	}
+
+	err := commands[cmd](args)
+	return out.String() + errOut.String(), err
+}
+
+func TestRunCreate(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		check          func(t *testing.T, req *url.GenerateShortURLRequest)
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name: "generated code",
+			args: []string{"https://example.com"},
+			check: func(t *testing.T, req *url.GenerateShortURLRequest) {
+				assert.Equal(t, "https://example.com", req.OriginalUrl)
+				assert.Nil(t, req.Ttl)
+			},
+			expectedOutput: "ALIAS  SHORT URL            ORIGINAL URL\n" +
+				"gen1   https://sho.rt/gen1  https://example.com\n",
+		},
+		{
+			name: "all flags",
+			args: []string{
+				"-alias", "promo", "-ttl", "24h", "-domain", "go.example", "-max-clicks", "3",
+				"-idempotency-key", "req-1", "https://example.com",
+			},
+			check: func(t *testing.T, req *url.GenerateShortURLRequest) {
+				assert.Equal(t, "promo", req.Alias)
+				assert.Equal(t, 24*time.Hour, req.Ttl.AsDuration())
+				assert.Equal(t, "go.example", req.Domain)
+				assert.Equal(t, int64(3), req.MaxClicks)
+				assert.Equal(t, "req-1", req.IdempotencyKey)
+			},
+			expectedOutput: "ALIAS  SHORT URL             ORIGINAL URL\n" +
+				"promo  https://sho.rt/promo  https://example.com\n",
+		},
+		{
+			name: "json output",
+			args: []string{"-o", "json", "-alias", "promo", "https://example.com"},
+			expectedOutput: "{\n" +
+				"  \"alias\": \"promo\",\n" +
+				"  \"short_url\": \"https://sho.rt/promo\",\n" +
+				"  \"original_url\": \"https://example.com\",\n" +
+				"  \"status\": \"active\"\n" +
+				"}\n",
+		},
+		{name: "missing url", args: []string{}, expectedError: "expected one original url"},
+		{name: "too many urls", args: []string{"https://a.example", "https://b.example"}, expectedError: "expected one original url"},
+		{name: "invalid ttl", args: []string{"-ttl", "soon", "https://example.com"}, expectedError: "invalid ttl"},
+		{name: "unknown output format", args: []string{"-o", "yaml", "https://example.com"}, expectedError: `unknown output format "yaml"`},
+		{name: "unknown flag", args: []string{"-color", "https://example.com"}, expectedError: "flag provided but not defined"},
+		{name: "service error", args: []string{"https://blocked.example"}, expectedError: "destination is blocked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeService()
+
+			output, err := run(t, service, "create", tt.args...)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutput, output)
+			require.Len(t, service.created, 1)
+			if tt.check != nil {
+				tt.check(t, service.created[0])
+			}
+		})
+	}
+}
+
+func TestRunGet(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		expectedOutput   string
+		expectedPassword []string
+		expectedError    string
+	}{
+		{
+			name:           "resolves",
+			args:           []string{"abc123"},
+			expectedOutput: "URL                  VARIANT\nhttps://example.com  b\n",
+		},
+		{
+			name:             "password",
+			args:             []string{"-password", "s3cret", "-o", "json", "abc123"},
+			expectedOutput:   "{\n  \"url\": \"https://example.com\",\n  \"variant\": \"b\"\n}\n",
+			expectedPassword: []string{"s3cret"},
+		},
+		{name: "missing link", args: []string{}, expectedError: "expected one short link"},
+		{name: "not found", args: []string{"missing"}, expectedError: "URL not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeService()
+
+			output, err := run(t, service, "get", tt.args...)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutput, output)
+			assert.Equal(t, tt.expectedPassword, service.md.Get(passwordMetadataKey))
+		})
+	}
+}
+
+func TestRunDelete(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name:           "deletes every link",
+			args:           []string{"abc123", "promo"},
+			expectedOutput: "URL     RESULT\nabc123  ok\npromo   ok\n",
+		},
+		{
+			name: "reports failures per link",
+			args: []string{"abc123", "missing"},
+			expectedOutput: "URL      RESULT\nabc123   ok\n" +
+				"missing  rpc error: code = NotFound desc = URL not found\n",
+			expectedError: "1 of 2 failed",
+		},
+		{
+			name:           "json output",
+			args:           []string{"-o", "json", "missing"},
+			expectedOutput: "[\n  {\n    \"url\": \"missing\",\n    \"error\": \"rpc error: code = NotFound desc = URL not found\"\n  }\n]\n",
+			expectedError:  "1 of 1 failed",
+		},
+		{name: "missing link", args: []string{"-if-version", "2"}, expectedError: "expected at least one short link"},
+		{name: "invalid version", args: []string{"-if-version", "two", "abc123"}, expectedError: "invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := run(t, newFakeService(), "delete", tt.args...)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
+
+func TestRunDeletePreconditions(t *testing.T) {
+	service := newFakeService()
+
+	_, err := run(t, service, "delete", "-if-url", "https://example.com", "-if-version", "2", "abc123")
+	require.NoError(t, err)
+
+	require.Len(t, service.deleted, 1)
+	assert.Equal(t, "abc123", service.deleted[0].Url)
+	assert.Equal(t, "https://example.com", service.deleted[0].ExpectedOriginalUrl)
+	assert.Equal(t, int64(2), service.deleted[0].ExpectedVersion)
+}
+
+func TestRunStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name: "table",
+			args: []string{"abc123", "promo"},
+			expectedOutput: "" +
+				"ALIAS   SHORT URL              ORIGINAL URL              STATUS    EXPIRES AT            CLICKS LEFT  VERSION\n" +
+				"abc123  https://sho.rt/abc123  https://example.com       active    never                 unlimited    1\n" +
+				"promo   https://sho.rt/promo   https://example.com/sale  disabled  2030-01-02T03:04:05Z  2/5          3\n",
+		},
+		{
+			name: "json",
+			args: []string{"-o", "json", "promo"},
+			expectedOutput: "[\n" +
+				"  {\n" +
+				"    \"alias\": \"promo\",\n" +
+				"    \"short_url\": \"https://sho.rt/promo\",\n" +
+				"    \"original_url\": \"https://example.com/sale\",\n" +
+				"    \"status\": \"disabled\",\n" +
+				"    \"expires_at\": \"2030-01-02T03:04:05Z\",\n" +
+				"    \"max_clicks\": 5,\n" +
+				"    \"clicks_left\": 2,\n" +
+				"    \"version\": 3\n" +
+				"  }\n" +
+				"]\n",
+		},
+		{name: "missing link", args: []string{}, expectedError: "expected at least one short link"},
+		{name: "stops at the first failure", args: []string{"abc123", "missing"}, expectedError: "missing: rpc error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := run(t, newFakeService(), "stats", tt.args...)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, output)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the columns export writes and import understands.
+var csvColumns = []string{
+	"alias", "short_url", "original_url", "domain", "status",
+	"ttl", "expires_at", "max_clicks", "clicks_left", "version",
+}
+
+// fileFormat picks csv or jsonl from the flag, or else from the extension.
+func fileFormat(format, path string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".jsonl", ".ndjson":
+			format = "jsonl"
+		default:
+			format = "csv"
+		}
+	}
+	if format != "csv" && format != "jsonl" {
+		return "", fmt.Errorf("unknown file format %q, expected csv or jsonl", format)
+	}
+	return format, nil
+}
+
+// readLinks reads links from CSV with a header row or from JSON lines.
+// Unknown CSV columns and JSON fields are ignored.
+func readLinks(r io.Reader, format string) ([]link, error) {
+	if format == "jsonl" {
+		var links []link
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var l link
+			if err := json.Unmarshal([]byte(text), &l); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			links = append(links, l)
+		}
+		return links, scanner.Err()
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	column := make(map[string]int, len(header))
+	for i, name := range header {
+		column[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := column["original_url"]; !ok {
+		return nil, errors.New("header has no original_url column")
+	}
+
+	var links []link
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return links, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(name string) string {
+			if i, ok := column[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		l := link{
+			Alias:       get("alias"),
+			ShortURL:    get("short_url"),
+			OriginalURL: get("original_url"),
+			Domain:      get("domain"),
+			Status:      get("status"),
+			TTL:         get("ttl"),
+			ExpiresAt:   get("expires_at"),
+		}
+		if l.MaxClicks, err = parseCount(get("max_clicks")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid max_clicks: %w", line, err)
+		}
+		if l.ClicksLeft, err = parseCount(get("clicks_left")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid clicks_left: %w", line, err)
+		}
+		links = append(links, l)
+	}
+}
+
+func parseCount(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func writeLinks(w io.Writer, links []link, format string) error {
+	if format == "jsonl" {
+		encoder := json.NewEncoder(w)
+		for _, l := range links {
+			if err := encoder.Encode(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, l := range links {
+		record := []string{
+			l.Alias, l.ShortURL, l.OriginalURL, l.Domain, l.Status,
+			l.TTL, l.ExpiresAt, countText(l.MaxClicks), countText(l.ClicksLeft), countText(l.Version),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func countText(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	conn := addConnFlags(fs)
+	format := fs.String("format", "", "file format: csv or jsonl, by default from the extension")
+	fs.Usage = commandUsage(fs, "import [flags] <file>\n\nCreates a link for every record; the alias column keeps existing codes.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one file")
+	}
+
+	path := fs.Arg(0)
+	fileFmt, err := fileFormat(*format, path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	links, err := readLinks(file, fileFmt)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	c, err := conn.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var created []link
+	var rows [][]string
+	failed := 0
+	for i, l := range links {
+		result, err := c.importLink(l)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stderr, "record %d (%s): %v\n", i+1, l.OriginalURL, err)
+			continue
+		}
+		created = append(created, result)
+		rows = append(rows, []string{result.Alias, result.ShortURL, result.OriginalURL})
+	}
+	if err := c.printer().print(created, []string{"ALIAS", "SHORT URL", "ORIGINAL URL"}, rows); err != nil {
+		return err
+	}
+	return failures(failed, len(links))
+}
+
+func (c *client) importLink(l link) (link, error) {
+	req, err := l.request()
+	if err != nil {
+		return link{}, err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	result, err := c.GenerateShortURL(ctx, req)
+	if err != nil {
+		return link{}, err
+	}
+	return linkFromURL(result), nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	conn := addConnFlags(fs)
+	format := fs.String("format", "", "file format: csv or jsonl, by default from the extension")
+	out := fs.String("out", "", "file to write, standard output by default")
+	codesFile := fs.String("codes", "", "file with one code or link per line to export")
+	fs.Usage = commandUsage(fs, "export [flags] [<code-or-link>...]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	shortURLs := fs.Args()
+	if *codesFile != "" {
+		codes, err := readCodes(*codesFile)
+		if err != nil {
+			return err
+		}
+		shortURLs = append(shortURLs, codes...)
+	}
+	if len(shortURLs) == 0 {
+		fs.Usage()
+		return errors.New("expected codes as arguments or with -codes")
+	}
+	fileFmt, err := fileFormat(*format, *out)
+	if err != nil {
+		return err
+	}
+
+	c, err := conn.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	links, err := c.stats(shortURLs)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return writeLinks(stdout, links, fileFmt)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeLinks(file, links, fileFmt); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func readCodes(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var codes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if code := strings.TrimSpace(scanner.Text()); code != "" && !strings.HasPrefix(code, "#") {
+			codes = append(codes, code)
+		}
+	}
+	return codes, scanner.Err()
+}
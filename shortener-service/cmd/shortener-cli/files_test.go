@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		path          string
+		expected      string
+		expectedError bool
+	}{
+		{name: "csv extension", path: "links.csv", expected: "csv"},
+		{name: "jsonl extension", path: "links.jsonl", expected: "jsonl"},
+		{name: "ndjson extension", path: "links.NDJSON", expected: "jsonl"},
+		{name: "json extension", path: "links.json", expected: "jsonl"},
+		{name: "unknown extension", path: "links.txt", expected: "csv"},
+		{name: "standard output", path: "", expected: "csv"},
+		{name: "flag wins", format: "jsonl", path: "links.csv", expected: "jsonl"},
+		{name: "unknown flag value", format: "xml", path: "links.csv", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := fileFormat(tt.format, tt.path)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, format)
+		})
+	}
+}
+
+func TestReadLinks(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		input         string
+		expected      []link
+		expectedError string
+	}{
+		{
+			name:   "csv in any column order",
+			format: "csv",
+			input: "Original_URL,alias,comment,max_clicks,ttl\n" +
+				"https://example.com, promo ,ignored,5,24h\n" +
+				"https://example.org\n",
+			expected: []link{
+				{OriginalURL: "https://example.com", Alias: "promo", MaxClicks: 5, TTL: "24h"},
+				{OriginalURL: "https://example.org"},
+			},
+		},
+		{
+			name:          "csv without original_url",
+			format:        "csv",
+			input:         "alias,short_url\npromo,https://sho.rt/promo\n",
+			expectedError: "no original_url column",
+		},
+		{
+			name:          "csv with invalid count",
+			format:        "csv",
+			input:         "original_url,max_clicks\nhttps://example.com,many\n",
+			expectedError: "line 2: invalid max_clicks",
+		},
+		{
+			name:          "empty csv",
+			format:        "csv",
+			input:         "",
+			expectedError: "failed to read header",
+		},
+		{
+			name:   "json lines",
+			format: "jsonl",
+			input: `{"original_url":"https://example.com","alias":"promo","extra":true}` + "\n\n" +
+				`{"original_url":"https://example.org","expires_at":"2030-01-02T03:04:05Z"}` + "\n",
+			expected: []link{
+				{OriginalURL: "https://example.com", Alias: "promo"},
+				{OriginalURL: "https://example.org", ExpiresAt: "2030-01-02T03:04:05Z"},
+			},
+		},
+		{
+			name:          "invalid json line",
+			format:        "jsonl",
+			input:         `{"original_url":"https://example.com"}` + "\n{\n",
+			expectedError: "line 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, err := readLinks(strings.NewReader(tt.input), tt.format)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, links)
+		})
+	}
+}
+
+func TestWriteLinks(t *testing.T) {
+	links := []link{
+		{Alias: "promo", ShortURL: "https://sho.rt/promo", OriginalURL: "https://example.com/a,b", Status: "active", MaxClicks: 5, ClicksLeft: 2, Version: 3},
+		{Alias: "abc123", OriginalURL: "https://example.org", ExpiresAt: "2030-01-02T03:04:05Z"},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "csv",
+			expected: "alias,short_url,original_url,domain,status,ttl,expires_at,max_clicks,clicks_left,version\n" +
+				"promo,https://sho.rt/promo,\"https://example.com/a,b\",,active,,,5,2,3\n" +
+				"abc123,,https://example.org,,,,2030-01-02T03:04:05Z,,,\n",
+		},
+		{
+			format: "jsonl",
+			expected: `{"alias":"promo","short_url":"https://sho.rt/promo","original_url":"https://example.com/a,b","status":"active","max_clicks":5,"clicks_left":2,"version":3}` + "\n" +
+				`{"alias":"abc123","original_url":"https://example.org","expires_at":"2030-01-02T03:04:05Z"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeLinks(&buf, links, tt.format))
+			assert.Equal(t, tt.expected, buf.String())
+
+			read, err := readLinks(&buf, tt.format)
+			require.NoError(t, err)
+			require.Len(t, read, len(links))
+			assert.Equal(t, links[0].OriginalURL, read[0].OriginalURL, "export can be imported again")
+		})
+	}
+}
+
+func TestRunImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.csv")
+	require.NoError(t, os.WriteFile(path, []byte("original_url,alias,ttl\n"+
+		"https://example.com,promo,1h\n"+
+		"https://blocked.example,,\n"+
+		"https://example.org,,later\n"), 0o600))
+	service := newFakeService()
+
+	output, err := run(t, service, "import", path)
+
+	assert.EqualError(t, err, "2 of 3 failed")
+	assert.Equal(t, ""+
+		"ALIAS  SHORT URL             ORIGINAL URL\n"+
+		"promo  https://sho.rt/promo  https://example.com\n"+
+		"record 2 (https://blocked.example): rpc error: code = InvalidArgument desc = destination is blocked\n"+
+		"record 3 (https://example.org): invalid ttl: time: invalid duration \"later\"\n",
+		output)
+	assert.Len(t, service.created, 2, "records that fail to parse are not sent")
+}
+
+func TestRunImportArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedError string
+	}{
+		{name: "missing file argument", args: []string{}, expectedError: "expected one file"},
+		{name: "unknown format", args: []string{"-format", "xml", "links.xml"}, expectedError: `unknown file format "xml"`},
+		{name: "missing file", args: []string{filepath.Join(t.TempDir(), "missing.csv")}, expectedError: "no such file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, newFakeService(), "import", tt.args...)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestRunExport(t *testing.T) {
+	dir := t.TempDir()
+	codes := filepath.Join(dir, "codes.txt")
+	require.NoError(t, os.WriteFile(codes, []byte("# links to back up\npromo\n\n"), 0o600))
+
+	tests := []struct {
+		name          string
+		args          []string
+		out           string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "standard output",
+			args:     []string{"-format", "jsonl", "abc123"},
+			expected: `{"alias":"abc123","short_url":"https://sho.rt/abc123","original_url":"https://example.com","status":"active","version":1}` + "\n",
+		},
+		{
+			name: "file from extension with codes file",
+			args: []string{"-out", filepath.Join(dir, "links.csv"), "-codes", codes, "abc123"},
+			out:  filepath.Join(dir, "links.csv"),
+			expected: "alias,short_url,original_url,domain,status,ttl,expires_at,max_clicks,clicks_left,version\n" +
+				"abc123,https://sho.rt/abc123,https://example.com,,active,,,,,1\n" +
+				"promo,https://sho.rt/promo,https://example.com/sale,,disabled,,2030-01-02T03:04:05Z,5,2,3\n",
+		},
+		{name: "no codes", args: []string{}, expectedError: "expected codes"},
+		{name: "unknown link", args: []string{"missing"}, expectedError: "missing: rpc error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := run(t, newFakeService(), "export", tt.args...)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			if tt.out != "" {
+				assert.Empty(t, output)
+				written, err := os.ReadFile(tt.out)
+				require.NoError(t, err)
+				output = string(written)
+			}
+			assert.Equal(t, tt.expected, output)
+		})
+	}
+}
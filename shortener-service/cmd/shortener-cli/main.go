@@ -0,0 +1,62 @@
+// Command shortener-cli manages short links through the ShortenerService
+// gRPC API.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: shortener-cli <command> [flags] [args]
+
+Commands:
+  create   create a short link for a URL
+  get      resolve a short link like a visitor would
+  delete   delete short links
+  stats    describe short links without resolving them
+  import   create short links from a CSV or JSON lines file
+  export   write short links to a CSV or JSON lines file
+
+Run shortener-cli <command> -h for the flags of a command. The server
+address defaults to the SHORTENER_ADDR environment variable.
+`
+
+type command func(args []string) error
+
+// stdout and stderr are where commands write; tests capture them.
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+var commands = map[string]command{
+	"create": runCreate,
+	"get":    runGet,
+	"delete": runDelete,
+	"stats":  runStats,
+	"import": runImport,
+	"export": runExport,
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err := run(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrinter(t *testing.T) {
+	value := []map[string]string{{"code": "abc123", "url": "https://example.com"}}
+	headers := []string{"CODE", "URL"}
+	rows := [][]string{{"abc123", "https://example.com"}, {"a", ""}}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   "table",
+			expected: "CODE    URL\nabc123  https://example.com\na       \n",
+		},
+		{
+			format:   "json",
+			expected: "[\n  {\n    \"code\": \"abc123\",\n    \"url\": \"https://example.com\"\n  }\n]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, printer{format: tt.format, w: &buf}.print(value, headers, rows))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestLinkRow(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     link
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			link:     link{Alias: "abc123", OriginalURL: "https://example.com", Status: "active", Version: 1},
+			expected: []string{"abc123", "", "https://example.com", "active", "never", "unlimited", "1"},
+		},
+		{
+			name:     "limits",
+			link:     link{Alias: "promo", ExpiresAt: "2030-01-02T03:04:05Z", MaxClicks: 5, ClicksLeft: 0, Version: 2},
+			expected: []string{"promo", "", "", "", "2030-01-02T03:04:05Z", "0/5", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.link.row())
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/codegen"
+	"github.com/redis/go-redis/v9"
+)
+
+func newCodeGenerator(cfg config.CodeGenConfig, client redis.UniversalClient) (codegen.CodeGenerator, error) {
+	switch cfg.Strategy {
+	case "", "random":
+		return codegen.NewRandomGenerator(cfg.Length)
+	case "counter":
+		return codegen.NewCounterGenerator(client, cfg.CounterKey, cfg.Length, string(cfg.Salt))
+	case "hash":
+		return codegen.NewHashGenerator(cfg.Length)
+	default:
+		return nil, fmt.Errorf("unknown code generation strategy %q", cfg.Strategy)
+	}
+}
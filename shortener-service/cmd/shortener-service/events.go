@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// newEventPublisher returns a publisher delivering to every configured sink
+// and a function releasing its resources.
+func newEventPublisher(
+	cfg *config.Config,
+	client redis.UniversalClient,
+	kafkaTransport *kafka.Transport,
+	logger *zap.Logger,
+) (events.Publisher, func() error, error) {
+	var publishers []events.Publisher
+	closeFn := func() error { return nil }
+
+	for _, sink := range cfg.Events.Sinks {
+		switch sink {
+		case "none":
+		case "kafka":
+			// Writes are synchronous so failures can be spooled; the
+			// controller publishes in the background anyway.
+			writer := &kafka.Writer{
+				Addr:                   kafka.TCP(cfg.Kafka.Brokers...),
+				Topic:                  cfg.Kafka.Topic,
+				Balancer:               &kafka.LeastBytes{},
+				WriteTimeout:           cfg.Kafka.WriteTimeout,
+				RequiredAcks:           kafka.RequiredAcks(cfg.Kafka.RequiredAcks),
+				BatchSize:              cfg.Kafka.BatchSize,
+				BatchBytes:             cfg.Kafka.BatchBytes,
+				BatchTimeout:           cfg.Kafka.BatchTimeout,
+				MaxAttempts:            cfg.Kafka.MaxAttempts,
+				AllowAutoTopicCreation: true,
+				Transport:              kafkaTransport,
+			}
+			closeFn = writer.Close
+
+			var publisher events.Publisher = events.NewKafkaPublisher(writer)
+			if cfg.Events.Spool.Dir != "" {
+				spool, err := events.NewSpool(
+					cfg.Events.Spool.Dir,
+					publisher,
+					cfg.Events.Spool.MaxBytes,
+					logger.Named("spool"),
+					events.WithSegmentSize(cfg.Events.Spool.SegmentBytes),
+					events.WithReplayInterval(cfg.Events.Spool.ReplayInterval),
+				)
+				if err != nil {
+					return nil, nil, err
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				go spool.Run(ctx)
+				closeFn = func() error {
+					cancel()
+					return errors.Join(spool.Close(), writer.Close())
+				}
+				publisher = spool
+			}
+			publishers = append(publishers, publisher)
+		case "redis":
+			publishers = append(publishers,
+				events.NewRedisStreamPublisher(client, cfg.Events.Stream, cfg.Events.StreamMaxLen))
+		case "log":
+			publishers = append(publishers, events.NewLogPublisher(logger))
+		default:
+			return nil, nil, fmt.Errorf("unknown event sink %q", sink)
+		}
+	}
+	return events.FanOut(publishers...), closeFn, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const kafkaDialTimeout = 10 * time.Second
+
+// newSASLMechanism returns the configured SASL mechanism, or nil when SASL is
+// disabled.
+func newSASLMechanism(cfg config.SASLConfig) (sasl.Mechanism, error) {
+	switch cfg.Mechanism {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: string(cfg.Password)}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, string(cfg.Password))
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, string(cfg.Password))
+	default:
+		return nil, fmt.Errorf("unknown sasl mechanism %q", cfg.Mechanism)
+	}
+}
+
+// newKafkaConnectors returns the transport used by writers and the dialer
+// used by readers and admin connections, both secured as configured.
+func newKafkaConnectors(cfg config.KafkaConfig) (*kafka.Transport, *kafka.Dialer, error) {
+	tlsConfig, err := newClientTLS(cfg.TLS)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kafka tls: %w", err)
+	}
+	mechanism, err := newSASLMechanism(cfg.SASL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kafka sasl: %w", err)
+	}
+
+	transport := &kafka.Transport{
+		DialTimeout: kafkaDialTimeout,
+		TLS:         tlsConfig,
+		SASL:        mechanism,
+	}
+	dialer := &kafka.Dialer{
+		Timeout:       kafkaDialTimeout,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}
+	return transport, dialer, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newLogger builds the service logger. The returned level changes the level
+// of the logger at runtime.
+func newLogger(cfg config.LogConfig, env string) (*zap.Logger, zap.AtomicLevel, error) {
+	level, err := zap.ParseAtomicLevel(cfg.Level)
+	if err != nil {
+		return nil, level, err
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if cfg.Encoding == "console" {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+
+	zapConfig := zap.Config{
+		Level:            level,
+		Development:      env == "development",
+		Encoding:         cfg.Encoding,
+		EncoderConfig:    encoderConfig,
+		OutputPaths:      cfg.OutputPaths,
+		ErrorOutputPaths: cfg.ErrorOutputPaths,
+	}
+	if cfg.Sampling.Initial > 0 {
+		zapConfig.Sampling = &zap.SamplingConfig{
+			Initial:    cfg.Sampling.Initial,
+			Thereafter: cfg.Sampling.Thereafter,
+		}
+	}
+
+	logger, err := zapConfig.Build()
+	if err != nil {
+		return nil, level, err
+	}
+	return logger, level, nil
+}
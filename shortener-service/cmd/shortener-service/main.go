@@ -2,89 +2,284 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/OrtemRepos/ShortURL/shortener-service/config"
 	"github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/blocklist"
 	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
 	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	httpHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/http"
+	kafkaHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/kafka"
 	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
-	"github.com/redis/go-redis/v9"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/webhook"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
 
+const defaultConfigPath = "config/config.yaml"
+
+const usage = `Usage: shortener-service [command] [--config path]
+
+Commands:
+  serve          run the service (default)
+  check-config   validate the config file and exit
+`
+
 func main() {
-	logger, err := zap.NewDevelopment()
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command named by the first argument, serve by default.
+func run(args []string) error {
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	if command != "serve" && command != "check-config" {
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+	configPath := flags.String("config", defaultConfigPath, "path of the config file")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if command == "check-config" {
+		fmt.Printf("%s: ok\n", *configPath)
+		return nil
+	}
+
+	serve(cfg)
+	return nil
+}
+
+func serve(cfg *config.Config) {
+	logger, logLevel, err := newLogger(cfg.Log, cfg.App.Env)
 	if err != nil {
 		panic(err)
 	}
 	defer func() { _ = logger.Sync() }()
 
-	cfg, err := config.LoadConfig("config/config.yaml")
+	client, err := newRedisClient(cfg.Redis)
 	if err != nil {
-		logger.Fatal("config reading error", zap.Error(err))
+		logger.Fatal("failed to create redis client", zap.Error(err))
 	}
 
-	client := redis.NewClient(
-		&redis.Options{
-			Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
-			Password: cfg.Redis.Password,
-			DB:       cfg.Redis.DB,
-			PoolSize: cfg.Redis.PoolSize,
-		},
-	)
-
-	ping := client.Ping(context.Background())
-	if ping.Err() != nil {
+	if err := client.Ping(context.Background()).Err(); err != nil {
 		logger.Error("redis ping error", zap.Error(err))
 	}
 
-	repo := repository.NewRedisURLRepo(client, logger.Named("repo_redis"))
+	kafkaTransport, kafkaDialer, err := newKafkaConnectors(cfg.Kafka)
+	if err != nil {
+		logger.Fatal("failed to set up kafka connections", zap.Error(err))
+	}
+
+	redisRepo := repository.NewRedisURLRepo(client, logger.Named("repo_redis"))
+	var repo controller.URLRepository = redisRepo
+
+	if cfg.Cache.Enabled {
+		cached, err := repository.NewCachedURLRepo(
+			redisRepo,
+			cfg.Cache.Size,
+			cfg.Cache.MaxTTL,
+			logger.Named("repo_cache"),
+		)
+		if err != nil {
+			logger.Fatal("failed to create url cache", zap.Error(err))
+		}
+		repo = cached
+
+		// Cache invalidations travel with the events on Kafka.
+		if cfg.Events.Uses("kafka") {
+			reader := kafka.NewReader(kafka.ReaderConfig{
+				Brokers:     cfg.Kafka.Brokers,
+				Topic:       cfg.Kafka.Topic,
+				GroupID:     cacheGroupID(cfg),
+				StartOffset: kafka.LastOffset,
+				Dialer:      kafkaDialer,
+			})
+			defer func() {
+				if err := reader.Close(); err != nil {
+					logger.Error("failed to close reader", zap.Error(err))
+				}
+			}()
 
-	writer := &kafka.Writer{
-		Addr: kafka.TCP(cfg.Kafka.Brokers...),
-		Topic: cfg.Kafka.Topic,
-		Balancer: &kafka.LeastBytes{},
-		WriteTimeout: cfg.Kafka.WriteTimeout,
-		RequiredAcks: kafka.RequiredAcks(cfg.Kafka.RequiredAcks),
-		BatchSize: cfg.Kafka.BatchSize,
-		BatchBytes: cfg.Kafka.BatchBytes,
-		BatchTimeout: cfg.Kafka.BatchTimeout,
-		MaxAttempts: cfg.Kafka.MaxAttempts,
-		AllowAutoTopicCreation: true,
-		Async: true,
+			invalidator := kafkaHandler.NewCacheInvalidator(reader, cached, logger.Named("cache_invalidator"))
+			go invalidator.Run(context.Background())
+		} else {
+			logger.Warn("cache invalidation needs the kafka event sink; changes reach other replicas after cache.max_ttl")
+		}
 	}
 
+	publisher, closePublisher, err := newEventPublisher(cfg, client, kafkaTransport, logger.Named("events"))
+	if err != nil {
+		logger.Fatal("failed to set up event publishing", zap.Error(err))
+	}
 	defer func() {
-		if err := writer.Close(); err != nil {
-			logger.Fatal("failed to close writer", zap.Error(err))
+		if err := closePublisher(); err != nil {
+			logger.Error("failed to close event publisher", zap.Error(err))
 		}
 	}()
-	
-	
-	ctrl := controller.NewController(repo, writer, logger.Named("controller"))
+
+	generator, err := newCodeGenerator(cfg.CodeGen, client)
+	if err != nil {
+		logger.Fatal("failed to create code generator", zap.Error(err))
+	}
+
+	limiter := repository.NewRedisFailureLimiter(
+		client,
+		cfg.App.PasswordMaxAttempts,
+		cfg.App.PasswordAttemptWindow,
+		logger.Named("failure_limiter"),
+	)
+
+	domains, err := domain.NewDomains(cfg.App.BaseURL, cfg.App.Domains)
+	if err != nil {
+		logger.Fatal("invalid short link domains", zap.Error(err))
+	}
+
+	opts := []controller.Option{
+		controller.WithFailureLimiter(limiter),
+		controller.WithDeleteRetention(cfg.App.DeleteRetention),
+		controller.WithDomains(domains),
+	}
+	var blocked *blocklist.Blocklist
+	if cfg.Blocklist.Path != "" {
+		blocked, err = blocklist.New(cfg.Blocklist.Path, logger.Named("blocklist"))
+		if err != nil {
+			logger.Fatal("failed to load blocklist", zap.Error(err))
+		}
+		go func() {
+			if err := blocked.Watch(context.Background()); err != nil {
+				logger.Error("blocklist will not be reloaded", zap.Error(err))
+			}
+		}()
+		opts = append(opts, controller.WithDestinationChecker(blocked))
+	}
+
+	idempotency := repository.NewRedisIdempotencyStore(
+		client,
+		cfg.App.IdempotencyTTL,
+		logger.Named("idempotency"),
+	)
+	handlerOpts := []grpcHandler.Option{
+		grpcHandler.WithIdempotency(idempotency),
+		grpcHandler.WithTrustedProxies(cfg.App.TrustedProxyPrefixes()...),
+	}
+	if cfg.Webhooks.Enabled {
+		store := repository.NewRedisWebhookStore(
+			client,
+			cfg.Webhooks.HistoryLimit,
+			cfg.Webhooks.HistoryTTL,
+			logger.Named("webhook_store"),
+		)
+		hookOpts := []webhook.Option{
+			webhook.WithRetries(cfg.Webhooks.MaxAttempts, cfg.Webhooks.InitialBackoff, cfg.Webhooks.MaxBackoff),
+			webhook.WithTimeout(cfg.Webhooks.Timeout),
+			webhook.WithPollInterval(cfg.Webhooks.PollInterval),
+		}
+		if cfg.Webhooks.AllowPrivateNetworks {
+			hookOpts = append(hookOpts, webhook.WithAllowPrivateNetworks())
+		}
+		if blocked != nil {
+			hookOpts = append(hookOpts, webhook.WithURLChecker(blocked))
+		}
+		hooks := webhook.NewService(store, logger.Named("webhooks"), hookOpts...)
+		hooksCtx, stopHooks := context.WithCancel(context.Background())
+		defer stopHooks()
+		go hooks.Run(hooksCtx)
+
+		publisher = events.FanOut(publisher, hooks)
+		handlerOpts = append(handlerOpts, grpcHandler.WithWebhooks(hooks))
+	}
+
+	ctrl := controller.NewController(
+		repo,
+		publisher,
+		generator,
+		logger.Named("controller"),
+		opts...,
+	)
 	handler := grpcHandler.New(
 		ctrl,
 		logger.Named("grpc_handler"),
+		handlerOpts...,
 	)
-	
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.App.Port))
 	if err != nil {
 		panic(err)
 	}
-	
-	srv := grpc.NewServer()
+
+	serverOpts, err := grpcServerOptions(cfg.App.TLS, logger)
+	if err != nil {
+		logger.Fatal("failed to set up grpc tls", zap.Error(err))
+	}
+	srv := grpc.NewServer(serverOpts...)
 
 	url.RegisterShortenerServiceServer(srv, handler)
-	
+
+	httpSrv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.App.HTTPPort),
+		Handler:           httpHandler.New(ctrl, logger.Named("http_handler")),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("http server failed", zap.Error(err))
+		}
+	}()
+
+	adminMux := http.NewServeMux()
+	adminMux.Handle("GET /metrics", promhttp.Handler())
+	// GET reports the log level, PUT {"level":"debug"} changes it.
+	adminMux.Handle("/log/level", logLevel)
+	adminSrv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.App.AdminPort),
+		Handler:           adminMux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("admin server failed", zap.Error(err))
+		}
+	}()
+
 	logger.Info("service started", zap.Any("config", cfg))
-	
-	if err := ensureTopicExists(context.Background(), writer, cfg.Kafka.Topic, logger); err != nil {
-		logger.Fatal("failed to ensure topics exists", zap.Error(err))
+
+	if cfg.Events.Uses("kafka") {
+		if err := ensureTopicExists(context.Background(), kafkaDialer, cfg.Kafka.Brokers, cfg.Kafka.Topic, logger); err != nil {
+			logger.Fatal("failed to ensure topics exists", zap.Error(err))
+		}
 	}
 
 	if err := srv.Serve(lis); err != nil {
@@ -93,8 +288,8 @@ func main() {
 
 }
 
-func ensureTopicExists(ctx context.Context, writer *kafka.Writer, topic string, logger *zap.Logger) error {
-	conn, err := kafka.DialContext(ctx, "tcp", strings.Split(writer.Addr.String(), ",")[0])
+func ensureTopicExists(ctx context.Context, dialer *kafka.Dialer, brokers []string, topic string, logger *zap.Logger) error {
+	conn, err := dialer.DialContext(ctx, "tcp", brokers[0])
 	if err != nil {
 		return fmt.Errorf("failed to dial kafka: %w", err)
 	}
@@ -103,7 +298,7 @@ func ensureTopicExists(ctx context.Context, writer *kafka.Writer, topic string,
 	partitions, err := conn.ReadPartitions(topic)
 	if err != nil || len(partitions) == 0 {
 		logger.Info("attempting to create topic", zap.String("topic", topic))
-		controllerConn, err := kafka.Dial("tcp", strings.Split(writer.Addr.String(), ",")[0])
+		controllerConn, err := dialer.DialContext(ctx, "tcp", brokers[0])
 		if err != nil {
 			return fmt.Errorf("failed to dial controller: %w", err)
 		}
@@ -119,4 +314,18 @@ func ensureTopicExists(ctx context.Context, writer *kafka.Writer, topic string,
 		return controllerConn.CreateTopics(topicConfigs...)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// cacheGroupID returns the consumer group used for cache invalidation. Every
+// replica needs its own group to see all events, so it defaults to one derived
+// from the hostname.
+func cacheGroupID(cfg *config.Config) string {
+	if cfg.Cache.GroupID != "" {
+		return cfg.Cache.GroupID
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-cache-%s", cfg.App.Name, hostname)
+}
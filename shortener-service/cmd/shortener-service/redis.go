@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisModeSentinel = "sentinel"
+	redisModeCluster  = "cluster"
+)
+
+func newRedisClient(cfg config.RedisConfig) (redis.UniversalClient, error) {
+	tlsConfig, err := newClientTLS(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("redis tls: %w", err)
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.Addrs,
+		Username:         cfg.Username,
+		Password:         string(cfg.Password),
+		DB:               cfg.DB,
+		PoolSize:         cfg.PoolSize,
+		MasterName:       cfg.MasterName,
+		SentinelPassword: string(cfg.SentinelPassword),
+		TLSConfig:        tlsConfig,
+	}
+
+	// The mode and its addresses were checked by config.Validate.
+	switch cfg.Mode {
+	case redisModeSentinel:
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case redisModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		opts.Addrs = []string{fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRedisClient(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.RedisConfig
+		check func(t *testing.T, client redis.UniversalClient)
+	}{
+		{
+			name: "standalone",
+			cfg:  config.RedisConfig{Mode: "standalone", Host: "redis", Port: 6380, DB: 2, PoolSize: 7, Password: "secret"},
+			check: func(t *testing.T, client redis.UniversalClient) {
+				require.IsType(t, &redis.Client{}, client)
+				opts := client.(*redis.Client).Options()
+				assert.Equal(t, "redis:6380", opts.Addr)
+				assert.Equal(t, 2, opts.DB)
+				assert.Equal(t, 7, opts.PoolSize)
+				assert.Equal(t, "secret", opts.Password)
+			},
+		},
+		{
+			name: "empty mode is standalone",
+			cfg:  config.RedisConfig{Host: "localhost", Port: 6379},
+			check: func(t *testing.T, client redis.UniversalClient) {
+				require.IsType(t, &redis.Client{}, client)
+				assert.Equal(t, "localhost:6379", client.(*redis.Client).Options().Addr)
+			},
+		},
+		{
+			name: "sentinel",
+			cfg: config.RedisConfig{
+				Mode:             "sentinel",
+				Addrs:            []string{"sentinel-0:26379", "sentinel-1:26379"},
+				MasterName:       "mymaster",
+				SentinelPassword: "sentinel-secret",
+				DB:               1,
+			},
+			check: func(t *testing.T, client redis.UniversalClient) {
+				require.IsType(t, &redis.Client{}, client)
+				opts := client.(*redis.Client).Options()
+				assert.Equal(t, "FailoverClient", opts.Addr, "sentinel clients resolve the master themselves")
+				assert.Equal(t, 1, opts.DB)
+			},
+		},
+		{
+			name: "cluster",
+			cfg:  config.RedisConfig{Mode: "cluster", Addrs: []string{"node-0:6379", "node-1:6379"}, PoolSize: 5},
+			check: func(t *testing.T, client redis.UniversalClient) {
+				require.IsType(t, &redis.ClusterClient{}, client)
+				opts := client.(*redis.ClusterClient).Options()
+				assert.Equal(t, []string{"node-0:6379", "node-1:6379"}, opts.Addrs)
+				assert.Equal(t, 5, opts.PoolSize)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newRedisClient(tt.cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = client.Close() })
+
+			tt.check(t, client)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/certs"
+	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// grpcServerOptions enables TLS on the gRPC server when a certificate is
+// configured, and authenticates callers by client certificate when a CA is.
+func grpcServerOptions(cfg config.ServerTLSConfig, logger *zap.Logger) ([]grpc.ServerOption, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	reloader, err := certs.NewReloader(cfg.CertFile, cfg.KeyFile, cfg.CAFile, logger.Named("certs"))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := reloader.Watch(context.Background()); err != nil {
+			logger.Error("certificates will not be reloaded", zap.Error(err))
+		}
+	}()
+
+	clientAuth := tls.NoClientCert
+	switch {
+	case cfg.RequireClientCert:
+		clientAuth = tls.RequireAndVerifyClientCert
+	case cfg.CAFile != "":
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	opts := []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(reloader.ServerConfig(clientAuth))),
+	}
+
+	if cfg.CAFile != "" {
+		identities := make(map[string]string, len(cfg.Identities))
+		for _, identity := range cfg.Identities {
+			identities[identity.ID] = identity.Name
+		}
+		opts = append(opts, grpc.UnaryInterceptor(
+			grpcHandler.IdentityInterceptor(identities, logger.Named("identity")),
+		))
+	}
+	return opts, nil
+}
+
+// newClientTLS builds the TLS config for connections to Redis or Kafka, or
+// returns nil when TLS is disabled.
+func newClientTLS(cfg config.ClientTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		roots, err := certs.LoadCAs(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = roots
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
+	"slices"
 	"strings"
 	"time"
 
@@ -9,82 +11,350 @@ import (
 )
 
 type Config struct {
-	App   AppConfig
-	Redis RedisConfig
-	Kafka KafkaConfig
+	App       AppConfig
+	Redis     RedisConfig
+	Kafka     KafkaConfig
+	Events    EventsConfig
+	Cache     CacheConfig
+	CodeGen   CodeGenConfig
+	Blocklist BlocklistConfig
+	Webhooks  WebhooksConfig
+	Log       LogConfig
 }
 
 type AppConfig struct {
+	Name      string `mapstructure:"name"`
+	Env       string `mapstructure:"env"`
+	Port      int    `mapstructure:"port"`
+	HTTPPort  int    `mapstructure:"http_port"`
+	AdminPort int    `mapstructure:"admin_port"`
+	// PasswordMaxAttempts failed password attempts are allowed per short URL
+	// and client within PasswordAttemptWindow.
+	PasswordMaxAttempts   int64         `mapstructure:"password_max_attempts"`
+	PasswordAttemptWindow time.Duration `mapstructure:"password_attempt_window"`
+	// DeleteRetention is how long deleted URLs can still be restored.
+	// Zero deletes them right away.
+	DeleteRetention time.Duration `mapstructure:"delete_retention"`
+	// IdempotencyTTL is how long GenerateShortURL replays responses to
+	// retries with the same idempotency key.
+	IdempotencyTTL time.Duration `mapstructure:"idempotency_ttl"`
+	// BaseURL is the public URL short links are built from, as seen by
+	// visitors. Domains are further branded hosts links can be minted on.
+	BaseURL string   `mapstructure:"base_url"`
+	Domains []string `mapstructure:"domains"`
+	// TrustedProxies are the addresses or CIDR ranges of proxies, such as
+	// grpc-gateway, whose x-forwarded-for tells the client address. The
+	// header is ignored from anyone else.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// TLS of the gRPC server.
+	TLS ServerTLSConfig `mapstructure:"tls"`
+}
+
+type TLSConfig struct {
+	// CertFile and KeyFile hold the PEM certificate and key; setting them
+	// enables TLS.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile holds the PEM certificates peers are verified against.
+	CAFile string `mapstructure:"ca_file"`
+}
+
+type ClientTLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// The certificate is presented to servers requiring client certificates.
+	// Without CAFile the server is verified against the system roots.
+	TLSConfig          `mapstructure:",squash"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+type ServerTLSConfig struct {
+	TLSConfig `mapstructure:",squash"`
+	// RequireClientCert rejects clients without a certificate signed by
+	// CAFile. Otherwise client certificates are verified when presented.
+	RequireClientCert bool `mapstructure:"require_client_cert"`
+	// Identities map client certificate identities to caller names. When
+	// set, only the listed identities may call the server.
+	Identities []IdentityConfig `mapstructure:"identities"`
+}
+
+// TrustedProxyPrefixes returns TrustedProxies as prefixes; a single address
+// is a prefix of its own. Entries that do not parse, which Validate reports,
+// are skipped.
+func (c AppConfig) TrustedProxyPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, proxy := range c.TrustedProxies {
+		if prefix, err := parseProxy(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (c ServerTLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+type IdentityConfig struct {
+	// ID is a SPIFFE ID, DNS name or common name from the certificate.
+	ID   string `mapstructure:"id"`
 	Name string `mapstructure:"name"`
-	Env  string `mapstructure:"env"`
-	Port int    `mapstructure:"port"`
 }
 
 type RedisConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
-	PoolSize int    `mapstructure:"pool_size"`
+	// Mode is one of "standalone", "sentinel" or "cluster". Standalone uses
+	// Host and Port, the other modes use Addrs.
+	Mode             string   `mapstructure:"mode"`
+	Host             string   `mapstructure:"host"`
+	Port             int      `mapstructure:"port"`
+	Addrs            []string `mapstructure:"addrs"`
+	MasterName       string   `mapstructure:"master_name"`
+	SentinelPassword Secret   `mapstructure:"sentinel_password"`
+	// Username selects a Redis ACL user; empty means the default user.
+	Username string          `mapstructure:"username"`
+	Password Secret          `mapstructure:"password"`
+	DB       int             `mapstructure:"db"`
+	PoolSize int             `mapstructure:"pool_size"`
+	TLS      ClientTLSConfig `mapstructure:"tls"`
 }
 
 type KafkaConfig struct {
-	Brokers        []string      `mapstructure:"brokers"`
-	Topic          string        `mapstructure:"topic"`
-	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
-	RequiredAcks   int           `mapstructure:"required_acks"`
-	BatchSize      int           `mapstructure:"batch_size"`
-	BatchBytes     int64         `mapstructure:"batch_bytes"`
-	BatchTimeout   time.Duration `mapstructure:"batch_timeout"`
+	Brokers        []string        `mapstructure:"brokers"`
+	Topic          string          `mapstructure:"topic"`
+	WriteTimeout   time.Duration   `mapstructure:"write_timeout"`
+	RequiredAcks   int             `mapstructure:"required_acks"`
+	BatchSize      int             `mapstructure:"batch_size"`
+	BatchBytes     int64           `mapstructure:"batch_bytes"`
+	BatchTimeout   time.Duration   `mapstructure:"batch_timeout"`
+	MaxAttempts    int             `mapstructure:"max_attempts"`
+	CommitInterval time.Duration   `mapstructure:"commit_interval"`
+	TLS            ClientTLSConfig `mapstructure:"tls"`
+	SASL           SASLConfig      `mapstructure:"sasl"`
+}
+
+type SASLConfig struct {
+	// Mechanism is one of "plain", "scram-sha-256" or "scram-sha-512".
+	// Empty disables SASL.
+	Mechanism string `mapstructure:"mechanism"`
+	Username  string `mapstructure:"username"`
+	Password  Secret `mapstructure:"password"`
+}
+
+type EventsConfig struct {
+	// Sinks lists where link events are published: "kafka", "redis" (a
+	// Redis stream) and "log", or just "none". Every event goes to all sinks.
+	Sinks []string `mapstructure:"sinks"`
+	// Stream is the Redis stream of the redis sink, trimmed to about
+	// StreamMaxLen entries. Zero keeps every entry.
+	Stream       string      `mapstructure:"stream"`
+	StreamMaxLen int64       `mapstructure:"stream_max_len"`
+	Spool        SpoolConfig `mapstructure:"spool"`
+}
+
+// SpoolConfig keeps events the kafka sink fails to deliver on disk until
+// Kafka is back. Empty Dir disables the spool.
+type SpoolConfig struct {
+	Dir string `mapstructure:"dir"`
+	// MaxBytes bounds the spool; when it is full the oldest segments of
+	// SegmentBytes each are dropped.
+	MaxBytes       int64         `mapstructure:"max_bytes"`
+	SegmentBytes   int64         `mapstructure:"segment_bytes"`
+	ReplayInterval time.Duration `mapstructure:"replay_interval"`
+}
+
+// Uses reports whether sink is one of the configured sinks.
+func (c EventsConfig) Uses(sink string) bool {
+	return slices.Contains(c.Sinks, sink)
+}
+
+type CacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Size    int           `mapstructure:"size"`
+	MaxTTL  time.Duration `mapstructure:"max_ttl"`
+	GroupID string        `mapstructure:"group_id"`
+}
+
+type CodeGenConfig struct {
+	// Strategy is one of "random", "counter" or "hash".
+	Strategy   string `mapstructure:"strategy"`
+	Length     int    `mapstructure:"length"`
+	CounterKey string `mapstructure:"counter_key"`
+	Salt       Secret `mapstructure:"salt"`
+}
+
+type LogConfig struct {
+	// Level is the initial level; it can be changed at runtime on the admin
+	// port.
+	Level string `mapstructure:"level"`
+	// Encoding is "json" or "console".
+	Encoding         string            `mapstructure:"encoding"`
+	Sampling         LogSamplingConfig `mapstructure:"sampling"`
+	OutputPaths      []string          `mapstructure:"output_paths"`
+	ErrorOutputPaths []string          `mapstructure:"error_output_paths"`
+}
+
+// LogSamplingConfig caps repeated messages: each second the first Initial
+// entries with the same level and message are logged, then every
+// Thereafter-th. Zero Initial disables sampling.
+type LogSamplingConfig struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
+}
+
+type BlocklistConfig struct {
+	// Path of the destination blocklist. Empty disables the blocklist.
+	Path string `mapstructure:"path"`
+}
+
+// WebhooksConfig controls the webhooks link owners register for events of
+// their links.
+type WebhooksConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// A delivery is attempted up to MaxAttempts times, backing off from
+	// InitialBackoff up to MaxBackoff, before it becomes a dead letter.
 	MaxAttempts    int           `mapstructure:"max_attempts"`
-	CommitInterval time.Duration `mapstructure:"commit_interval"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+	PollInterval   time.Duration `mapstructure:"poll_interval"`
+	// AllowPrivateNetworks lets endpoints resolve to loopback, private and
+	// link-local addresses.
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+	// HistoryLimit deliveries and dead letters are kept per webhook for
+	// HistoryTTL.
+	HistoryLimit int64         `mapstructure:"history_limit"`
+	HistoryTTL   time.Duration `mapstructure:"history_ttl"`
 }
 
+// LoadConfig reads the config file at path, applies defaults and APP_*
+// environment overrides, and validates the result. Secrets can also be read
+// from the file named by APP_<KEY>_FILE, e.g. APP_REDIS_PASSWORD_FILE.
 func LoadConfig(path string) (*Config, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(path)
-
-	viper.SetDefault("app.port", 8080)
-	viper.SetDefault("redis.port", 6379)
-	viper.SetDefault("redis.db", 0)
-	viper.SetDefault("redis.pool_size", 10)
-	
-	viper.SetDefault("kafka.write_timeout", "5s")
-	viper.SetDefault("kafka.required_acks", 1)
-	viper.SetDefault("kafka.batch_size", 100)
-	viper.SetDefault("kafka.batch_timeout", "1s")
-	viper.SetDefault("kafka.max_attempts", 3)
-	viper.SetDefault("kafka.commit_interval", "1s")
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
-		}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+
+	v.SetDefault("app.port", 8080)
+	v.SetDefault("app.http_port", 8081)
+	v.SetDefault("app.admin_port", 9090)
+	v.SetDefault("app.password_max_attempts", 5)
+	v.SetDefault("app.password_attempt_window", "15m")
+	v.SetDefault("app.delete_retention", "720h")
+	v.SetDefault("app.idempotency_ttl", "24h")
+	v.SetDefault("app.base_url", "http://localhost:8081")
+	v.SetDefault("redis.mode", "standalone")
+	v.SetDefault("redis.port", 6379)
+	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.pool_size", 10)
+
+	v.SetDefault("kafka.write_timeout", "5s")
+	v.SetDefault("kafka.required_acks", 1)
+	v.SetDefault("kafka.batch_size", 100)
+	v.SetDefault("kafka.batch_timeout", "1s")
+	v.SetDefault("kafka.max_attempts", 3)
+	v.SetDefault("kafka.commit_interval", "1s")
+
+	v.SetDefault("events.sinks", []string{"kafka"})
+	v.SetDefault("events.stream", "url-events")
+	v.SetDefault("events.stream_max_len", 100000)
+	v.SetDefault("events.spool.dir", "")
+	v.SetDefault("events.spool.max_bytes", 256<<20)
+	v.SetDefault("events.spool.segment_bytes", 4<<20)
+	v.SetDefault("events.spool.replay_interval", "5s")
+
+	v.SetDefault("cache.enabled", true)
+	v.SetDefault("cache.size", 10000)
+	v.SetDefault("cache.max_ttl", "1m")
+
+	v.SetDefault("codegen.strategy", "random")
+	v.SetDefault("codegen.length", 8)
+	v.SetDefault("codegen.counter_key", "codegen:counter")
+
+	v.SetDefault("blocklist.path", "")
+
+	v.SetDefault("webhooks.enabled", false)
+	v.SetDefault("webhooks.max_attempts", 8)
+	v.SetDefault("webhooks.initial_backoff", "10s")
+	v.SetDefault("webhooks.max_backoff", "1h")
+	v.SetDefault("webhooks.timeout", "10s")
+	v.SetDefault("webhooks.poll_interval", "1s")
+	v.SetDefault("webhooks.allow_private_networks", false)
+	v.SetDefault("webhooks.history_limit", 100)
+	v.SetDefault("webhooks.history_ttl", "168h")
+
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.encoding", "json")
+	v.SetDefault("log.sampling.initial", 100)
+	v.SetDefault("log.sampling.thereafter", 100)
+	v.SetDefault("log.output_paths", []string{"stderr"})
+	v.SetDefault("log.error_output_paths", []string{"stderr"})
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	viper.AutomaticEnv()
-	viper.SetEnvPrefix("APP")
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	v.SetEnvPrefix("APP")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	bindEnvs := []string{
-		"app.name", "app.env", "app.port",
-		"redis.host", "redis.port", "redis.password", "redis.db", "redis.pool_size",
+		"app.name", "app.env", "app.port", "app.http_port", "app.admin_port",
+		"app.password_max_attempts", "app.password_attempt_window",
+		"app.delete_retention", "app.idempotency_ttl", "app.base_url", "app.domains",
+		"app.trusted_proxies",
+		"app.tls.cert_file", "app.tls.key_file", "app.tls.ca_file", "app.tls.require_client_cert",
+		"redis.mode", "redis.host", "redis.port", "redis.addrs", "redis.master_name",
+		"redis.sentinel_password", "redis.username", "redis.password", "redis.db", "redis.pool_size",
+		"redis.tls.enabled", "redis.tls.cert_file", "redis.tls.key_file", "redis.tls.ca_file",
+		"redis.tls.server_name", "redis.tls.insecure_skip_verify",
 		"kafka.brokers", "kafka.topic", "kafka.write_timeout", "kafka.required_acks",
 		"kafka.batch_size", "kafka.batch_bytes", "kafka.batch_timeout",
 		"kafka.max_attempts", "kafka.commit_interval",
+		"kafka.tls.enabled", "kafka.tls.cert_file", "kafka.tls.key_file", "kafka.tls.ca_file",
+		"kafka.tls.server_name", "kafka.tls.insecure_skip_verify",
+		"kafka.sasl.mechanism", "kafka.sasl.username", "kafka.sasl.password",
+		"events.sinks", "events.stream", "events.stream_max_len",
+		"events.spool.dir", "events.spool.max_bytes", "events.spool.segment_bytes",
+		"events.spool.replay_interval",
+		"cache.enabled", "cache.size", "cache.max_ttl", "cache.group_id",
+		"codegen.strategy", "codegen.length", "codegen.counter_key", "codegen.salt",
+		"blocklist.path",
+		"webhooks.enabled", "webhooks.max_attempts", "webhooks.initial_backoff",
+		"webhooks.max_backoff", "webhooks.timeout", "webhooks.poll_interval",
+		"webhooks.allow_private_networks", "webhooks.history_limit", "webhooks.history_ttl",
+		"log.level", "log.encoding", "log.sampling.initial", "log.sampling.thereafter",
+		"log.output_paths", "log.error_output_paths",
 	}
 
 	for _, key := range bindEnvs {
-		if err := viper.BindEnv(key); err != nil {
+		if err := v.BindEnv(key); err != nil {
 			return nil, fmt.Errorf("failed to bind env for %s: %w", key, err)
 		}
 	}
 
+	if err := loadSecretFiles(v); err != nil {
+		return nil, err
+	}
+
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
-}
\ No newline at end of file
+}
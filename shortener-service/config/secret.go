@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret is a config value that must not show up in logs. It prints and
+// marshals as a placeholder; convert it to a string to use the value.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+// MarshalText keeps the value out of JSON, YAML and other encodings.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+var secretType = reflect.TypeFor[Secret]()
+
+// secretKeys returns the keys of all Secret fields, e.g. "redis.password".
+func secretKeys() []string {
+	var keys []string
+	cfg := reflect.TypeFor[Config]()
+	for i := range cfg.NumField() {
+		section := cfg.Field(i)
+		keys = appendSecretKeys(keys, strings.ToLower(section.Name), section.Type)
+	}
+	return keys
+}
+
+func appendSecretKeys(keys []string, prefix string, t reflect.Type) []string {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		key := prefix
+		if name != "" {
+			key += "." + name
+		}
+		switch {
+		case field.Type == secretType:
+			keys = append(keys, key)
+		case field.Type.Kind() == reflect.Struct:
+			keys = appendSecretKeys(keys, key, field.Type)
+		}
+	}
+	return keys
+}
+
+// loadSecretFiles reads secrets from the files named by APP_<KEY>_FILE
+// variables, as mounted by Docker and Kubernetes secrets.
+func loadSecretFiles(v *viper.Viper) error {
+	for _, key := range secretKeys() {
+		env := "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		path := os.Getenv(env + "_FILE")
+		if path == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(env); ok {
+			return fmt.Errorf("%s and %s_FILE are mutually exclusive", env, env)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		v.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return nil
+}
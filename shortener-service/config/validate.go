@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Validate checks the config and reports every problem at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	checkPort := func(key string, port int) {
+		check(port > 0 && port <= 65535, "%s: must be between 1 and 65535, got %d", key, port)
+	}
+	checkPositive := func(key string, d time.Duration) {
+		check(d > 0, "%s: must be positive, got %s", key, d)
+	}
+
+	checkPort("app.port", c.App.Port)
+	checkPort("app.http_port", c.App.HTTPPort)
+	checkPort("app.admin_port", c.App.AdminPort)
+	check(c.App.Port != c.App.HTTPPort && c.App.Port != c.App.AdminPort && c.App.HTTPPort != c.App.AdminPort,
+		"app: port, http_port and admin_port must differ")
+	check(c.App.PasswordMaxAttempts > 0, "app.password_max_attempts: must be positive, got %d", c.App.PasswordMaxAttempts)
+	checkPositive("app.password_attempt_window", c.App.PasswordAttemptWindow)
+	check(c.App.DeleteRetention >= 0, "app.delete_retention: cannot be negative, got %s", c.App.DeleteRetention)
+	checkPositive("app.idempotency_ttl", c.App.IdempotencyTTL)
+	check(c.App.BaseURL != "", "app.base_url: is required")
+	for i, proxy := range c.App.TrustedProxies {
+		_, err := parseProxy(proxy)
+		check(err == nil, "app.trusted_proxies[%d]: must be an IP address or CIDR range, got %q", i, proxy)
+	}
+	errs = append(errs, c.App.TLS.validate("app.tls")...)
+	check(c.App.TLS.CAFile == "" || c.App.TLS.CertFile != "", "app.tls.ca_file: requires cert_file")
+	check(!c.App.TLS.RequireClientCert || c.App.TLS.CAFile != "",
+		"app.tls.require_client_cert: requires ca_file")
+	check(len(c.App.TLS.Identities) == 0 || c.App.TLS.CAFile != "",
+		"app.tls.identities: requires ca_file")
+	for i, identity := range c.App.TLS.Identities {
+		check(identity.ID != "" && identity.Name != "", "app.tls.identities[%d]: id and name are required", i)
+	}
+
+	switch c.Redis.Mode {
+	case "", "standalone":
+		check(c.Redis.Host != "", "redis.host: is required in standalone mode")
+		checkPort("redis.port", c.Redis.Port)
+	case "sentinel":
+		check(len(c.Redis.Addrs) > 0, "redis.addrs: is required in sentinel mode")
+		check(c.Redis.MasterName != "", "redis.master_name: is required in sentinel mode")
+	case "cluster":
+		check(len(c.Redis.Addrs) > 0, "redis.addrs: is required in cluster mode")
+		check(c.Redis.DB == 0, "redis.db: cluster mode supports only db 0")
+	default:
+		check(false, "redis.mode: unknown mode %q", c.Redis.Mode)
+	}
+	errs = append(errs, c.Redis.TLS.validate("redis.tls")...)
+	check(c.Redis.PoolSize > 0, "redis.pool_size: must be positive, got %d", c.Redis.PoolSize)
+
+	check(len(c.Events.Sinks) > 0, "events.sinks: at least one sink is required")
+	for i, sink := range c.Events.Sinks {
+		check(!slices.Contains(c.Events.Sinks[:i], sink), "events.sinks: %q is listed twice", sink)
+		switch sink {
+		case "kafka", "redis", "log":
+		case "none":
+			check(len(c.Events.Sinks) == 1, "events.sinks: none cannot be combined with other sinks")
+		default:
+			check(false, "events.sinks: unknown sink %q", sink)
+		}
+	}
+	if c.Events.Uses("redis") {
+		check(c.Events.Stream != "", "events.stream: is required with the redis sink")
+		check(c.Events.StreamMaxLen >= 0, "events.stream_max_len: cannot be negative, got %d", c.Events.StreamMaxLen)
+	}
+
+	if c.Events.Spool.Dir != "" {
+		check(c.Events.Uses("kafka"), "events.spool.dir: requires the kafka sink")
+		check(c.Events.Spool.MaxBytes > 0, "events.spool.max_bytes: must be positive, got %d", c.Events.Spool.MaxBytes)
+		check(c.Events.Spool.SegmentBytes > 0 && c.Events.Spool.SegmentBytes <= c.Events.Spool.MaxBytes,
+			"events.spool.segment_bytes: must be positive and at most max_bytes, got %d", c.Events.Spool.SegmentBytes)
+		checkPositive("events.spool.replay_interval", c.Events.Spool.ReplayInterval)
+	}
+
+	// Kafka carries the events and the cache invalidations, so it is only
+	// needed with the kafka sink.
+	if c.Events.Uses("kafka") {
+		check(len(c.Kafka.Brokers) > 0, "kafka.brokers: at least one broker is required")
+		for i, broker := range c.Kafka.Brokers {
+			check(broker != "", "kafka.brokers[%d]: cannot be empty", i)
+		}
+		check(c.Kafka.Topic != "", "kafka.topic: is required")
+		checkPositive("kafka.write_timeout", c.Kafka.WriteTimeout)
+		checkPositive("kafka.batch_timeout", c.Kafka.BatchTimeout)
+		checkPositive("kafka.commit_interval", c.Kafka.CommitInterval)
+		check(c.Kafka.RequiredAcks >= -1 && c.Kafka.RequiredAcks <= 1,
+			"kafka.required_acks: must be -1, 0 or 1, got %d", c.Kafka.RequiredAcks)
+		check(c.Kafka.BatchSize > 0, "kafka.batch_size: must be positive, got %d", c.Kafka.BatchSize)
+		check(c.Kafka.MaxAttempts > 0, "kafka.max_attempts: must be positive, got %d", c.Kafka.MaxAttempts)
+		errs = append(errs, c.Kafka.TLS.validate("kafka.tls")...)
+		switch c.Kafka.SASL.Mechanism {
+		case "":
+		case "plain", "scram-sha-256", "scram-sha-512":
+			check(c.Kafka.SASL.Username != "", "kafka.sasl.username: is required with a mechanism")
+		default:
+			check(false, "kafka.sasl.mechanism: unknown mechanism %q", c.Kafka.SASL.Mechanism)
+		}
+	}
+
+	if c.Cache.Enabled {
+		check(c.Cache.Size > 0, "cache.size: must be positive, got %d", c.Cache.Size)
+		checkPositive("cache.max_ttl", c.Cache.MaxTTL)
+	}
+
+	switch c.CodeGen.Strategy {
+	case "", "random", "counter", "hash":
+	default:
+		check(false, "codegen.strategy: unknown strategy %q", c.CodeGen.Strategy)
+	}
+	// Codes follow the alias rules, which allow up to 64 characters.
+	check(c.CodeGen.Length > 0 && c.CodeGen.Length <= 64, "codegen.length: must be 1-64, got %d", c.CodeGen.Length)
+
+	if c.Webhooks.Enabled {
+		check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts: must be positive, got %d", c.Webhooks.MaxAttempts)
+		checkPositive("webhooks.initial_backoff", c.Webhooks.InitialBackoff)
+		check(c.Webhooks.MaxBackoff >= c.Webhooks.InitialBackoff,
+			"webhooks.max_backoff: must be at least initial_backoff, got %s", c.Webhooks.MaxBackoff)
+		checkPositive("webhooks.timeout", c.Webhooks.Timeout)
+		checkPositive("webhooks.poll_interval", c.Webhooks.PollInterval)
+		check(c.Webhooks.HistoryLimit > 0, "webhooks.history_limit: must be positive, got %d", c.Webhooks.HistoryLimit)
+		checkPositive("webhooks.history_ttl", c.Webhooks.HistoryTTL)
+	}
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		check(false, "log.level: %v", err)
+	}
+	check(c.Log.Encoding == "json" || c.Log.Encoding == "console",
+		"log.encoding: must be json or console, got %q", c.Log.Encoding)
+	check(c.Log.Sampling.Initial >= 0 && c.Log.Sampling.Thereafter >= 0, "log.sampling: cannot be negative")
+	check(len(c.Log.OutputPaths) > 0, "log.output_paths: at least one path is required")
+	check(len(c.Log.ErrorOutputPaths) > 0, "log.error_output_paths: at least one path is required")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c TLSConfig) validate(key string) []error {
+	var errs []error
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("%s: cert_file and key_file must be set together", key))
+	}
+	return errs
+}
+
+func (c ClientTLSConfig) validate(key string) []error {
+	errs := c.TLSConfig.validate(key)
+	if !c.Enabled && (c.CertFile != "" || c.CAFile != "" || c.ServerName != "" || c.InsecureSkipVerify) {
+		errs = append(errs, fmt.Errorf("%s: options are set but enabled is false", key))
+	}
+	return errs
+}
@@ -8,10 +8,12 @@ package url
 
 import (
 	_ "google.golang.org/genproto/googleapis/api/annotations"
+	httpbody "google.golang.org/genproto/googleapis/api/httpbody"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -24,10 +26,443 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type QRFormat int32
+
+const (
+	QRFormat_QR_FORMAT_UNSPECIFIED QRFormat = 0
+	QRFormat_QR_FORMAT_PNG         QRFormat = 1
+	QRFormat_QR_FORMAT_SVG         QRFormat = 2
+)
+
+// Enum value maps for QRFormat.
+var (
+	QRFormat_name = map[int32]string{
+		0: "QR_FORMAT_UNSPECIFIED",
+		1: "QR_FORMAT_PNG",
+		2: "QR_FORMAT_SVG",
+	}
+	QRFormat_value = map[string]int32{
+		"QR_FORMAT_UNSPECIFIED": 0,
+		"QR_FORMAT_PNG":         1,
+		"QR_FORMAT_SVG":         2,
+	}
+)
+
+func (x QRFormat) Enum() *QRFormat {
+	p := new(QRFormat)
+	*p = x
+	return p
+}
+
+func (x QRFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QRFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[0].Descriptor()
+}
+
+func (QRFormat) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[0]
+}
+
+func (x QRFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QRFormat.Descriptor instead.
+func (QRFormat) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{0}
+}
+
+// ErrorCorrection is the share of the code that can be damaged and still
+// read: about 7%, 15%, 25% and 30%.
+type ErrorCorrection int32
+
+const (
+	ErrorCorrection_ERROR_CORRECTION_UNSPECIFIED ErrorCorrection = 0
+	ErrorCorrection_ERROR_CORRECTION_LOW         ErrorCorrection = 1
+	ErrorCorrection_ERROR_CORRECTION_MEDIUM      ErrorCorrection = 2
+	ErrorCorrection_ERROR_CORRECTION_QUARTILE    ErrorCorrection = 3
+	ErrorCorrection_ERROR_CORRECTION_HIGH        ErrorCorrection = 4
+)
+
+// Enum value maps for ErrorCorrection.
+var (
+	ErrorCorrection_name = map[int32]string{
+		0: "ERROR_CORRECTION_UNSPECIFIED",
+		1: "ERROR_CORRECTION_LOW",
+		2: "ERROR_CORRECTION_MEDIUM",
+		3: "ERROR_CORRECTION_QUARTILE",
+		4: "ERROR_CORRECTION_HIGH",
+	}
+	ErrorCorrection_value = map[string]int32{
+		"ERROR_CORRECTION_UNSPECIFIED": 0,
+		"ERROR_CORRECTION_LOW":         1,
+		"ERROR_CORRECTION_MEDIUM":      2,
+		"ERROR_CORRECTION_QUARTILE":    3,
+		"ERROR_CORRECTION_HIGH":        4,
+	}
+)
+
+func (x ErrorCorrection) Enum() *ErrorCorrection {
+	p := new(ErrorCorrection)
+	*p = x
+	return p
+}
+
+func (x ErrorCorrection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCorrection) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[1].Descriptor()
+}
+
+func (ErrorCorrection) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[1]
+}
+
+func (x ErrorCorrection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCorrection.Descriptor instead.
+func (ErrorCorrection) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{1}
+}
+
+type LinkStatus int32
+
+const (
+	LinkStatus_LINK_STATUS_UNSPECIFIED LinkStatus = 0
+	LinkStatus_LINK_STATUS_ACTIVE      LinkStatus = 1
+	LinkStatus_LINK_STATUS_DISABLED    LinkStatus = 2
+	LinkStatus_LINK_STATUS_DELETED     LinkStatus = 3
+)
+
+// Enum value maps for LinkStatus.
+var (
+	LinkStatus_name = map[int32]string{
+		0: "LINK_STATUS_UNSPECIFIED",
+		1: "LINK_STATUS_ACTIVE",
+		2: "LINK_STATUS_DISABLED",
+		3: "LINK_STATUS_DELETED",
+	}
+	LinkStatus_value = map[string]int32{
+		"LINK_STATUS_UNSPECIFIED": 0,
+		"LINK_STATUS_ACTIVE":      1,
+		"LINK_STATUS_DISABLED":    2,
+		"LINK_STATUS_DELETED":     3,
+	}
+)
+
+func (x LinkStatus) Enum() *LinkStatus {
+	p := new(LinkStatus)
+	*p = x
+	return p
+}
+
+func (x LinkStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LinkStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[2].Descriptor()
+}
+
+func (LinkStatus) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[2]
+}
+
+func (x LinkStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LinkStatus.Descriptor instead.
+func (LinkStatus) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{2}
+}
+
+// MergeMode tells what happens to parameters already in the destination.
+type MergeMode int32
+
+const (
+	// Same as MERGE_MODE_OVERRIDE.
+	MergeMode_MERGE_MODE_UNSPECIFIED   MergeMode = 0
+	MergeMode_MERGE_MODE_OVERRIDE      MergeMode = 1
+	MergeMode_MERGE_MODE_KEEP_ORIGINAL MergeMode = 2
+	// Fail the redirect when a parameter has a different value.
+	MergeMode_MERGE_MODE_REJECT_CONFLICTS MergeMode = 3
+)
+
+// Enum value maps for MergeMode.
+var (
+	MergeMode_name = map[int32]string{
+		0: "MERGE_MODE_UNSPECIFIED",
+		1: "MERGE_MODE_OVERRIDE",
+		2: "MERGE_MODE_KEEP_ORIGINAL",
+		3: "MERGE_MODE_REJECT_CONFLICTS",
+	}
+	MergeMode_value = map[string]int32{
+		"MERGE_MODE_UNSPECIFIED":      0,
+		"MERGE_MODE_OVERRIDE":         1,
+		"MERGE_MODE_KEEP_ORIGINAL":    2,
+		"MERGE_MODE_REJECT_CONFLICTS": 3,
+	}
+)
+
+func (x MergeMode) Enum() *MergeMode {
+	p := new(MergeMode)
+	*p = x
+	return p
+}
+
+func (x MergeMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MergeMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[3].Descriptor()
+}
+
+func (MergeMode) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[3]
+}
+
+func (x MergeMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MergeMode.Descriptor instead.
+func (MergeMode) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{3}
+}
+
+type Platform int32
+
+const (
+	Platform_PLATFORM_UNSPECIFIED Platform = 0
+	Platform_PLATFORM_IOS         Platform = 1
+	Platform_PLATFORM_ANDROID     Platform = 2
+	Platform_PLATFORM_DESKTOP     Platform = 3
+)
+
+// Enum value maps for Platform.
+var (
+	Platform_name = map[int32]string{
+		0: "PLATFORM_UNSPECIFIED",
+		1: "PLATFORM_IOS",
+		2: "PLATFORM_ANDROID",
+		3: "PLATFORM_DESKTOP",
+	}
+	Platform_value = map[string]int32{
+		"PLATFORM_UNSPECIFIED": 0,
+		"PLATFORM_IOS":         1,
+		"PLATFORM_ANDROID":     2,
+		"PLATFORM_DESKTOP":     3,
+	}
+)
+
+func (x Platform) Enum() *Platform {
+	p := new(Platform)
+	*p = x
+	return p
+}
+
+func (x Platform) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Platform) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[4].Descriptor()
+}
+
+func (Platform) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[4]
+}
+
+func (x Platform) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Platform.Descriptor instead.
+func (Platform) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{4}
+}
+
+type SplitMode int32
+
+const (
+	// Same as SPLIT_MODE_RANDOM.
+	SplitMode_SPLIT_MODE_UNSPECIFIED SplitMode = 0
+	// Every visit picks a variant.
+	SplitMode_SPLIT_MODE_RANDOM SplitMode = 1
+	// Visitors keep their variant, identified by the x-visitor-id metadata or
+	// a cookie over HTTP.
+	SplitMode_SPLIT_MODE_STICKY SplitMode = 2
+)
+
+// Enum value maps for SplitMode.
+var (
+	SplitMode_name = map[int32]string{
+		0: "SPLIT_MODE_UNSPECIFIED",
+		1: "SPLIT_MODE_RANDOM",
+		2: "SPLIT_MODE_STICKY",
+	}
+	SplitMode_value = map[string]int32{
+		"SPLIT_MODE_UNSPECIFIED": 0,
+		"SPLIT_MODE_RANDOM":      1,
+		"SPLIT_MODE_STICKY":      2,
+	}
+)
+
+func (x SplitMode) Enum() *SplitMode {
+	p := new(SplitMode)
+	*p = x
+	return p
+}
+
+func (x SplitMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SplitMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[5].Descriptor()
+}
+
+func (SplitMode) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[5]
+}
+
+func (x SplitMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SplitMode.Descriptor instead.
+func (SplitMode) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{5}
+}
+
+type WebhookEvent int32
+
+const (
+	WebhookEvent_WEBHOOK_EVENT_UNSPECIFIED WebhookEvent = 0
+	WebhookEvent_WEBHOOK_EVENT_CREATED     WebhookEvent = 1
+	WebhookEvent_WEBHOOK_EVENT_DELETED     WebhookEvent = 2
+	WebhookEvent_WEBHOOK_EVENT_VISITED     WebhookEvent = 3
+	// The link ran out of clicks or reached its expiry time.
+	WebhookEvent_WEBHOOK_EVENT_EXPIRED WebhookEvent = 4
+)
+
+// Enum value maps for WebhookEvent.
+var (
+	WebhookEvent_name = map[int32]string{
+		0: "WEBHOOK_EVENT_UNSPECIFIED",
+		1: "WEBHOOK_EVENT_CREATED",
+		2: "WEBHOOK_EVENT_DELETED",
+		3: "WEBHOOK_EVENT_VISITED",
+		4: "WEBHOOK_EVENT_EXPIRED",
+	}
+	WebhookEvent_value = map[string]int32{
+		"WEBHOOK_EVENT_UNSPECIFIED": 0,
+		"WEBHOOK_EVENT_CREATED":     1,
+		"WEBHOOK_EVENT_DELETED":     2,
+		"WEBHOOK_EVENT_VISITED":     3,
+		"WEBHOOK_EVENT_EXPIRED":     4,
+	}
+)
+
+func (x WebhookEvent) Enum() *WebhookEvent {
+	p := new(WebhookEvent)
+	*p = x
+	return p
+}
+
+func (x WebhookEvent) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WebhookEvent) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[6].Descriptor()
+}
+
+func (WebhookEvent) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[6]
+}
+
+func (x WebhookEvent) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WebhookEvent.Descriptor instead.
+func (WebhookEvent) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{6}
+}
+
+type DeliveryStatus int32
+
+const (
+	DeliveryStatus_DELIVERY_STATUS_UNSPECIFIED DeliveryStatus = 0
+	// Waiting for its first or next attempt.
+	DeliveryStatus_DELIVERY_STATUS_PENDING   DeliveryStatus = 1
+	DeliveryStatus_DELIVERY_STATUS_DELIVERED DeliveryStatus = 2
+	// Failed every attempt.
+	DeliveryStatus_DELIVERY_STATUS_DEAD DeliveryStatus = 3
+)
+
+// Enum value maps for DeliveryStatus.
+var (
+	DeliveryStatus_name = map[int32]string{
+		0: "DELIVERY_STATUS_UNSPECIFIED",
+		1: "DELIVERY_STATUS_PENDING",
+		2: "DELIVERY_STATUS_DELIVERED",
+		3: "DELIVERY_STATUS_DEAD",
+	}
+	DeliveryStatus_value = map[string]int32{
+		"DELIVERY_STATUS_UNSPECIFIED": 0,
+		"DELIVERY_STATUS_PENDING":     1,
+		"DELIVERY_STATUS_DELIVERED":   2,
+		"DELIVERY_STATUS_DEAD":        3,
+	}
+)
+
+func (x DeliveryStatus) Enum() *DeliveryStatus {
+	p := new(DeliveryStatus)
+	*p = x
+	return p
+}
+
+func (x DeliveryStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeliveryStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_url_service_proto_enumTypes[7].Descriptor()
+}
+
+func (DeliveryStatus) Type() protoreflect.EnumType {
+	return &file_url_service_proto_enumTypes[7]
+}
+
+func (x DeliveryStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeliveryStatus.Descriptor instead.
+func (DeliveryStatus) EnumDescriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{7}
+}
+
 type URL struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
-	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Full short link, e.g. "https://sho.rt/abc123".
+	ShortUrl    string `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl string `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	// Bare code of the link.
+	Code string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// Host the link was minted on.
+	Domain        string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -76,9 +511,25 @@ func (x *URL) GetOriginalUrl() string {
 	return ""
 }
 
+func (x *URL) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *URL) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
 type OriginalURL struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Name of the variant url was chosen from, if the link has variants.
+	Variant       string `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -120,9 +571,21 @@ func (x *OriginalURL) GetUrl() string {
 	return ""
 }
 
+func (x *OriginalURL) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
 type ShortURL struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Bare code or full short link. A full link only resolves on the domain
+	// it was minted on.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Raw query string of the visit, passed through to the destination when
+	// the link allows it. Only used by GetOriginalURL.
+	Query         string `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -164,28 +627,43 @@ func (x *ShortURL) GetUrl() string {
 	return ""
 }
 
-type GenerateShortURLRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OriginalUrl   string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
-	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+func (x *ShortURL) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type GetQRCodeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Bare code or full short link.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Defaults to QR_FORMAT_PNG.
+	Format QRFormat `protobuf:"varint,2,opt,name=format,proto3,enum=url_service.v1.QRFormat" json:"format,omitempty"`
+	// Width and height of the image in pixels, at most 2048. Defaults to 256.
+	Size uint32 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// Defaults to ERROR_CORRECTION_MEDIUM.
+	ErrorCorrection ErrorCorrection `protobuf:"varint,4,opt,name=error_correction,json=errorCorrection,proto3,enum=url_service.v1.ErrorCorrection" json:"error_correction,omitempty"`
+	// Quiet zone around the code in modules, at most 16. Defaults to 4.
+	Margin        *uint32 `protobuf:"varint,5,opt,name=margin,proto3,oneof" json:"margin,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GenerateShortURLRequest) Reset() {
-	*x = GenerateShortURLRequest{}
+func (x *GetQRCodeRequest) Reset() {
+	*x = GetQRCodeRequest{}
 	mi := &file_url_service_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenerateShortURLRequest) String() string {
+func (x *GetQRCodeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateShortURLRequest) ProtoMessage() {}
+func (*GetQRCodeRequest) ProtoMessage() {}
 
-func (x *GenerateShortURLRequest) ProtoReflect() protoreflect.Message {
+func (x *GetQRCodeRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_url_service_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -197,44 +675,1255 @@ func (x *GenerateShortURLRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateShortURLRequest.ProtoReflect.Descriptor instead.
-func (*GenerateShortURLRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetQRCodeRequest.ProtoReflect.Descriptor instead.
+func (*GetQRCodeRequest) Descriptor() ([]byte, []int) {
 	return file_url_service_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *GenerateShortURLRequest) GetOriginalUrl() string {
+func (x *GetQRCodeRequest) GetUrl() string {
 	if x != nil {
-		return x.OriginalUrl
+		return x.Url
 	}
 	return ""
 }
 
-func (x *GenerateShortURLRequest) GetTtl() *durationpb.Duration {
+func (x *GetQRCodeRequest) GetFormat() QRFormat {
 	if x != nil {
-		return x.Ttl
+		return x.Format
 	}
-	return nil
+	return QRFormat_QR_FORMAT_UNSPECIFIED
 }
 
-var File_url_service_proto protoreflect.FileDescriptor
+func (x *GetQRCodeRequest) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
 
-const file_url_service_proto_rawDesc = "" +
-	"\n" +
-	"\x11url_service.proto\x12\x0eurl_service.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a\x1egoogle/protobuf/duration.proto\"E\n" +
-	"\x03URL\x12\x1b\n" +
-	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
-	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\"\x1f\n" +
-	"\vOriginalURL\x12\x10\n" +
-	"\x03url\x18\x01 \x01(\tR\x03url\"!\n" +
-	"\bShortURL\x12\x15\n" +
-	"\x03url\x18\x01 \x01(\tB\x03\xe0A\x02R\x03url\"n\n" +
-	"\x17GenerateShortURLRequest\x12&\n" +
-	"\foriginal_url\x18\x01 \x01(\tB\x03\xe0A\x02R\voriginalUrl\x12+\n" +
-	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl2\xb4\x02\n" +
+func (x *GetQRCodeRequest) GetErrorCorrection() ErrorCorrection {
+	if x != nil {
+		return x.ErrorCorrection
+	}
+	return ErrorCorrection_ERROR_CORRECTION_UNSPECIFIED
+}
+
+func (x *GetQRCodeRequest) GetMargin() uint32 {
+	if x != nil && x.Margin != nil {
+		return *x.Margin
+	}
+	return 0
+}
+
+type DeleteShortURLRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Bare code or full short link.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Only delete the link if it still points to expected_original_url.
+	ExpectedOriginalUrl string `protobuf:"bytes,2,opt,name=expected_original_url,json=expectedOriginalUrl,proto3" json:"expected_original_url,omitempty"`
+	// Only delete the link if it is still at this version, as reported by
+	// GetURLStats. Zero skips the check.
+	ExpectedVersion int64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteShortURLRequest) Reset() {
+	*x = DeleteShortURLRequest{}
+	mi := &file_url_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteShortURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteShortURLRequest) ProtoMessage() {}
+
+func (x *DeleteShortURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteShortURLRequest.ProtoReflect.Descriptor instead.
+func (*DeleteShortURLRequest) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteShortURLRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *DeleteShortURLRequest) GetExpectedOriginalUrl() string {
+	if x != nil {
+		return x.ExpectedOriginalUrl
+	}
+	return ""
+}
+
+func (x *DeleteShortURLRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type GenerateShortURLRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	OriginalUrl string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Ttl         *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Resolving a link created with a password requires the same password,
+	// passed in the x-link-password metadata.
+	Password string `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	// The link is deleted after it has been resolved max_clicks times.
+	// Zero means unlimited.
+	MaxClicks int64 `protobuf:"varint,4,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// The link does not resolve before not_before.
+	NotBefore *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	// Absolute expiry time; mutually exclusive with ttl.
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Rules are tried in order; visitors matching none go to original_url.
+	Rules []*RedirectRule `protobuf:"bytes,7,rep,name=rules,proto3" json:"rules,omitempty"`
+	// Weighted destinations that replace original_url for visitors matching
+	// no rule.
+	Variants     []*Variant    `protobuf:"bytes,8,rep,name=variants,proto3" json:"variants,omitempty"`
+	SplitMode    SplitMode     `protobuf:"varint,9,opt,name=split_mode,json=splitMode,proto3,enum=url_service.v1.SplitMode" json:"split_mode,omitempty"`
+	QueryOptions *QueryOptions `protobuf:"bytes,10,opt,name=query_options,json=queryOptions,proto3" json:"query_options,omitempty"`
+	// Branded domain to mint the link on, one of app.domains. Defaults to the
+	// host of app.base_url.
+	Domain string `protobuf:"bytes,11,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Custom code instead of a generated one: 1-64 letters, digits, '-' or
+	// '_'. Fails with AlreadyExists when taken.
+	Alias string `protobuf:"bytes,12,opt,name=alias,proto3" json:"alias,omitempty"`
+	// Retries with the same key get the response of the first request instead
+	// of a new link; reusing a key with a different request fails with
+	// FailedPrecondition. May also be sent as idempotency-key metadata.
+	IdempotencyKey string `protobuf:"bytes,13,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GenerateShortURLRequest) Reset() {
+	*x = GenerateShortURLRequest{}
+	mi := &file_url_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateShortURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateShortURLRequest) ProtoMessage() {}
+
+func (x *GenerateShortURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateShortURLRequest.ProtoReflect.Descriptor instead.
+func (*GenerateShortURLRequest) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GenerateShortURLRequest) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *GenerateShortURLRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *GenerateShortURLRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *GenerateShortURLRequest) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *GenerateShortURLRequest) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *GenerateShortURLRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *GenerateShortURLRequest) GetRules() []*RedirectRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *GenerateShortURLRequest) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *GenerateShortURLRequest) GetSplitMode() SplitMode {
+	if x != nil {
+		return x.SplitMode
+	}
+	return SplitMode_SPLIT_MODE_UNSPECIFIED
+}
+
+func (x *GenerateShortURLRequest) GetQueryOptions() *QueryOptions {
+	if x != nil {
+		return x.QueryOptions
+	}
+	return nil
+}
+
+func (x *GenerateShortURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *GenerateShortURLRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *GenerateShortURLRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type URLStats struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Code     string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	// Empty unless the link resolves for anyone, or the caller owns it: the
+	// destination of a password-protected, disabled, deleted or not yet
+	// active link is not given away.
+	OriginalUrl string     `protobuf:"bytes,3,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Domain      string     `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
+	Status      LinkStatus `protobuf:"varint,5,opt,name=status,proto3,enum=url_service.v1.LinkStatus" json:"status,omitempty"`
+	// Zero when the link has no click limit.
+	MaxClicks         int64                  `protobuf:"varint,6,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	ClicksLeft        int64                  `protobuf:"varint,7,opt,name=clicks_left,json=clicksLeft,proto3" json:"clicks_left,omitempty"`
+	NotBefore         *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	ExpiresAt         *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	PasswordProtected bool                   `protobuf:"varint,10,opt,name=password_protected,json=passwordProtected,proto3" json:"password_protected,omitempty"`
+	Rules             int32                  `protobuf:"varint,11,opt,name=rules,proto3" json:"rules,omitempty"`
+	Variants          int32                  `protobuf:"varint,12,opt,name=variants,proto3" json:"variants,omitempty"`
+	// Grows whenever the link is changed; see
+	// DeleteShortURLRequest.expected_version.
+	Version       int64 `protobuf:"varint,13,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URLStats) Reset() {
+	*x = URLStats{}
+	mi := &file_url_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLStats) ProtoMessage() {}
+
+func (x *URLStats) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLStats.ProtoReflect.Descriptor instead.
+func (*URLStats) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *URLStats) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *URLStats) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *URLStats) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *URLStats) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *URLStats) GetStatus() LinkStatus {
+	if x != nil {
+		return x.Status
+	}
+	return LinkStatus_LINK_STATUS_UNSPECIFIED
+}
+
+func (x *URLStats) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *URLStats) GetClicksLeft() int64 {
+	if x != nil {
+		return x.ClicksLeft
+	}
+	return 0
+}
+
+func (x *URLStats) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *URLStats) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *URLStats) GetPasswordProtected() bool {
+	if x != nil {
+		return x.PasswordProtected
+	}
+	return false
+}
+
+func (x *URLStats) GetRules() int32 {
+	if x != nil {
+		return x.Rules
+	}
+	return 0
+}
+
+func (x *URLStats) GetVariants() int32 {
+	if x != nil {
+		return x.Variants
+	}
+	return 0
+}
+
+func (x *URLStats) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// QueryOptions control the query string of the destination on redirect.
+type QueryOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Merge the visit's query parameters into the destination.
+	Passthrough bool      `protobuf:"varint,1,opt,name=passthrough,proto3" json:"passthrough,omitempty"`
+	MergeMode   MergeMode `protobuf:"varint,2,opt,name=merge_mode,json=mergeMode,proto3,enum=url_service.v1.MergeMode" json:"merge_mode,omitempty"`
+	// utm_* parameters added to the destination on every visit.
+	Utm           map[string]string `protobuf:"bytes,3,rep,name=utm,proto3" json:"utm,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryOptions) Reset() {
+	*x = QueryOptions{}
+	mi := &file_url_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryOptions) ProtoMessage() {}
+
+func (x *QueryOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryOptions.ProtoReflect.Descriptor instead.
+func (*QueryOptions) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *QueryOptions) GetPassthrough() bool {
+	if x != nil {
+		return x.Passthrough
+	}
+	return false
+}
+
+func (x *QueryOptions) GetMergeMode() MergeMode {
+	if x != nil {
+		return x.MergeMode
+	}
+	return MergeMode_MERGE_MODE_UNSPECIFIED
+}
+
+func (x *QueryOptions) GetUtm() map[string]string {
+	if x != nil {
+		return x.Utm
+	}
+	return nil
+}
+
+// RedirectRule sends visitors matching all of its conditions to destination.
+// At least one condition is required.
+type RedirectRule struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Platform Platform               `protobuf:"varint,1,opt,name=platform,proto3,enum=url_service.v1.Platform" json:"platform,omitempty"`
+	// Language range matched against Accept-Language, e.g. "de" or "pt-BR".
+	Language      string `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	Destination   string `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedirectRule) Reset() {
+	*x = RedirectRule{}
+	mi := &file_url_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedirectRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedirectRule) ProtoMessage() {}
+
+func (x *RedirectRule) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedirectRule.ProtoReflect.Descriptor instead.
+func (*RedirectRule) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RedirectRule) GetPlatform() Platform {
+	if x != nil {
+		return x.Platform
+	}
+	return Platform_PLATFORM_UNSPECIFIED
+}
+
+func (x *RedirectRule) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *RedirectRule) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+type Variant struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to the variant's position.
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Destination   string `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	Weight        uint32 `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variant) Reset() {
+	*x = Variant{}
+	mi := &file_url_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variant) ProtoMessage() {}
+
+func (x *Variant) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Variant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Variant) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *Variant) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+// Deliveries are JSON POSTs signed in the X-Shortener-Signature header as
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed with secret>".
+type Webhook struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url    string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Events []WebhookEvent         `protobuf:"varint,3,rep,packed,name=events,proto3,enum=url_service.v1.WebhookEvent" json:"events,omitempty"`
+	// Only set in the response to RegisterWebhook.
+	Secret        string                 `protobuf:"bytes,4,opt,name=secret,proto3" json:"secret,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Webhook) Reset() {
+	*x = Webhook{}
+	mi := &file_url_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webhook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webhook) ProtoMessage() {}
+
+func (x *Webhook) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webhook.ProtoReflect.Descriptor instead.
+func (*Webhook) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Webhook) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Webhook) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Webhook) GetEvents() []WebhookEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Webhook) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *Webhook) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type RegisterWebhookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// http or https endpoint deliveries are posted to.
+	Url           string         `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Events        []WebhookEvent `protobuf:"varint,2,rep,packed,name=events,proto3,enum=url_service.v1.WebhookEvent" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterWebhookRequest) Reset() {
+	*x = RegisterWebhookRequest{}
+	mi := &file_url_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterWebhookRequest) ProtoMessage() {}
+
+func (x *RegisterWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterWebhookRequest.ProtoReflect.Descriptor instead.
+func (*RegisterWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RegisterWebhookRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *RegisterWebhookRequest) GetEvents() []WebhookEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type ListWebhooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksRequest) Reset() {
+	*x = ListWebhooksRequest{}
+	mi := &file_url_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksRequest) ProtoMessage() {}
+
+func (x *ListWebhooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhooksRequest) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{12}
+}
+
+type ListWebhooksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Webhooks      []*Webhook             `protobuf:"bytes,1,rep,name=webhooks,proto3" json:"webhooks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksResponse) Reset() {
+	*x = ListWebhooksResponse{}
+	mi := &file_url_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksResponse) ProtoMessage() {}
+
+func (x *ListWebhooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhooksResponse) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListWebhooksResponse) GetWebhooks() []*Webhook {
+	if x != nil {
+		return x.Webhooks
+	}
+	return nil
+}
+
+type DeleteWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookRequest) Reset() {
+	*x = DeleteWebhookRequest{}
+	mi := &file_url_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookRequest) ProtoMessage() {}
+
+func (x *DeleteWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteWebhookRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListWebhookDeliveriesRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId string                 `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	// Only list deliveries that failed every attempt.
+	DeadLettersOnly bool `protobuf:"varint,2,opt,name=dead_letters_only,json=deadLettersOnly,proto3" json:"dead_letters_only,omitempty"`
+	// Defaults to and is capped at 100.
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhookDeliveriesRequest) Reset() {
+	*x = ListWebhookDeliveriesRequest{}
+	mi := &file_url_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhookDeliveriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhookDeliveriesRequest) ProtoMessage() {}
+
+func (x *ListWebhookDeliveriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhookDeliveriesRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhookDeliveriesRequest) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListWebhookDeliveriesRequest) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
+	}
+	return ""
+}
+
+func (x *ListWebhookDeliveriesRequest) GetDeadLettersOnly() bool {
+	if x != nil {
+		return x.DeadLettersOnly
+	}
+	return false
+}
+
+func (x *ListWebhookDeliveriesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListWebhookDeliveriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deliveries    []*WebhookDelivery     `protobuf:"bytes,1,rep,name=deliveries,proto3" json:"deliveries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhookDeliveriesResponse) Reset() {
+	*x = ListWebhookDeliveriesResponse{}
+	mi := &file_url_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhookDeliveriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhookDeliveriesResponse) ProtoMessage() {}
+
+func (x *ListWebhookDeliveriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhookDeliveriesResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhookDeliveriesResponse) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListWebhookDeliveriesResponse) GetDeliveries() []*WebhookDelivery {
+	if x != nil {
+		return x.Deliveries
+	}
+	return nil
+}
+
+type WebhookDelivery struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Event    WebhookEvent           `protobuf:"varint,2,opt,name=event,proto3,enum=url_service.v1.WebhookEvent" json:"event,omitempty"`
+	ShortUrl string                 `protobuf:"bytes,3,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Status   DeliveryStatus         `protobuf:"varint,4,opt,name=status,proto3,enum=url_service.v1.DeliveryStatus" json:"status,omitempty"`
+	Attempts int32                  `protobuf:"varint,5,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	// Status code and error of the latest attempt; the code is zero when no
+	// response came back.
+	LastStatusCode int32                  `protobuf:"varint,6,opt,name=last_status_code,json=lastStatusCode,proto3" json:"last_status_code,omitempty"`
+	LastError      string                 `protobuf:"bytes,7,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Only set for pending deliveries.
+	NextAttemptAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=next_attempt_at,json=nextAttemptAt,proto3" json:"next_attempt_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebhookDelivery) Reset() {
+	*x = WebhookDelivery{}
+	mi := &file_url_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookDelivery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookDelivery) ProtoMessage() {}
+
+func (x *WebhookDelivery) ProtoReflect() protoreflect.Message {
+	mi := &file_url_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookDelivery.ProtoReflect.Descriptor instead.
+func (*WebhookDelivery) Descriptor() ([]byte, []int) {
+	return file_url_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WebhookDelivery) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetEvent() WebhookEvent {
+	if x != nil {
+		return x.Event
+	}
+	return WebhookEvent_WEBHOOK_EVENT_UNSPECIFIED
+}
+
+func (x *WebhookDelivery) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetStatus() DeliveryStatus {
+	if x != nil {
+		return x.Status
+	}
+	return DeliveryStatus_DELIVERY_STATUS_UNSPECIFIED
+}
+
+func (x *WebhookDelivery) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetLastStatusCode() int32 {
+	if x != nil {
+		return x.LastStatusCode
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *WebhookDelivery) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *WebhookDelivery) GetNextAttemptAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextAttemptAt
+	}
+	return nil
+}
+
+var File_url_service_proto protoreflect.FileDescriptor
+
+const file_url_service_proto_rawDesc = "" +
+	"\n" +
+	"\x11url_service.proto\x12\x0eurl_service.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/api/annotations.proto\x1a\x19google/api/httpbody.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"q\n" +
+	"\x03URL\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\"9\n" +
+	"\vOriginalURL\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x18\n" +
+	"\avariant\x18\x02 \x01(\tR\avariant\"7\n" +
+	"\bShortURL\x12\x15\n" +
+	"\x03url\x18\x01 \x01(\tB\x03\xe0A\x02R\x03url\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\"\xe3\x01\n" +
+	"\x10GetQRCodeRequest\x12\x15\n" +
+	"\x03url\x18\x01 \x01(\tB\x03\xe0A\x02R\x03url\x120\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x18.url_service.v1.QRFormatR\x06format\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\rR\x04size\x12J\n" +
+	"\x10error_correction\x18\x04 \x01(\x0e2\x1f.url_service.v1.ErrorCorrectionR\x0ferrorCorrection\x12\x1b\n" +
+	"\x06margin\x18\x05 \x01(\rH\x00R\x06margin\x88\x01\x01B\t\n" +
+	"\a_margin\"\x8d\x01\n" +
+	"\x15DeleteShortURLRequest\x12\x15\n" +
+	"\x03url\x18\x01 \x01(\tB\x03\xe0A\x02R\x03url\x122\n" +
+	"\x15expected_original_url\x18\x02 \x01(\tR\x13expectedOriginalUrl\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\"\xe1\x04\n" +
+	"\x17GenerateShortURLRequest\x12&\n" +
+	"\foriginal_url\x18\x01 \x01(\tB\x03\xe0A\x02R\voriginalUrl\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x12\x1f\n" +
+	"\bpassword\x18\x03 \x01(\tB\x03\xe0A\x04R\bpassword\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x04 \x01(\x03R\tmaxClicks\x129\n" +
+	"\n" +
+	"not_before\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tnotBefore\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x122\n" +
+	"\x05rules\x18\a \x03(\v2\x1c.url_service.v1.RedirectRuleR\x05rules\x123\n" +
+	"\bvariants\x18\b \x03(\v2\x17.url_service.v1.VariantR\bvariants\x128\n" +
+	"\n" +
+	"split_mode\x18\t \x01(\x0e2\x19.url_service.v1.SplitModeR\tsplitMode\x12A\n" +
+	"\rquery_options\x18\n" +
+	" \x01(\v2\x1c.url_service.v1.QueryOptionsR\fqueryOptions\x12\x16\n" +
+	"\x06domain\x18\v \x01(\tR\x06domain\x12\x14\n" +
+	"\x05alias\x18\f \x01(\tR\x05alias\x12'\n" +
+	"\x0fidempotency_key\x18\r \x01(\tR\x0eidempotencyKey\"\xdb\x03\n" +
+	"\bURLStats\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12!\n" +
+	"\foriginal_url\x18\x03 \x01(\tR\voriginalUrl\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\x122\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x1a.url_service.v1.LinkStatusR\x06status\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x06 \x01(\x03R\tmaxClicks\x12\x1f\n" +
+	"\vclicks_left\x18\a \x01(\x03R\n" +
+	"clicksLeft\x129\n" +
+	"\n" +
+	"not_before\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tnotBefore\x129\n" +
+	"\n" +
+	"expires_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12-\n" +
+	"\x12password_protected\x18\n" +
+	" \x01(\bR\x11passwordProtected\x12\x14\n" +
+	"\x05rules\x18\v \x01(\x05R\x05rules\x12\x1a\n" +
+	"\bvariants\x18\f \x01(\x05R\bvariants\x12\x18\n" +
+	"\aversion\x18\r \x01(\x03R\aversion\"\xdb\x01\n" +
+	"\fQueryOptions\x12 \n" +
+	"\vpassthrough\x18\x01 \x01(\bR\vpassthrough\x128\n" +
+	"\n" +
+	"merge_mode\x18\x02 \x01(\x0e2\x19.url_service.v1.MergeModeR\tmergeMode\x127\n" +
+	"\x03utm\x18\x03 \x03(\v2%.url_service.v1.QueryOptions.UtmEntryR\x03utm\x1a6\n" +
+	"\bUtmEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x87\x01\n" +
+	"\fRedirectRule\x124\n" +
+	"\bplatform\x18\x01 \x01(\x0e2\x18.url_service.v1.PlatformR\bplatform\x12\x1a\n" +
+	"\blanguage\x18\x02 \x01(\tR\blanguage\x12%\n" +
+	"\vdestination\x18\x03 \x01(\tB\x03\xe0A\x02R\vdestination\"a\n" +
+	"\aVariant\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12%\n" +
+	"\vdestination\x18\x02 \x01(\tB\x03\xe0A\x02R\vdestination\x12\x1b\n" +
+	"\x06weight\x18\x03 \x01(\rB\x03\xe0A\x02R\x06weight\"\xb9\x01\n" +
+	"\aWebhook\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x124\n" +
+	"\x06events\x18\x03 \x03(\x0e2\x1c.url_service.v1.WebhookEventR\x06events\x12\x1b\n" +
+	"\x06secret\x18\x04 \x01(\tB\x03\xe0A\x03R\x06secret\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"j\n" +
+	"\x16RegisterWebhookRequest\x12\x15\n" +
+	"\x03url\x18\x01 \x01(\tB\x03\xe0A\x02R\x03url\x129\n" +
+	"\x06events\x18\x02 \x03(\x0e2\x1c.url_service.v1.WebhookEventB\x03\xe0A\x02R\x06events\"\x15\n" +
+	"\x13ListWebhooksRequest\"K\n" +
+	"\x14ListWebhooksResponse\x123\n" +
+	"\bwebhooks\x18\x01 \x03(\v2\x17.url_service.v1.WebhookR\bwebhooks\"+\n" +
+	"\x14DeleteWebhookRequest\x12\x13\n" +
+	"\x02id\x18\x01 \x01(\tB\x03\xe0A\x02R\x02id\"\x84\x01\n" +
+	"\x1cListWebhookDeliveriesRequest\x12\"\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tB\x03\xe0A\x02R\twebhookId\x12*\n" +
+	"\x11dead_letters_only\x18\x02 \x01(\bR\x0fdeadLettersOnly\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"`\n" +
+	"\x1dListWebhookDeliveriesResponse\x12?\n" +
+	"\n" +
+	"deliveries\x18\x01 \x03(\v2\x1f.url_service.v1.WebhookDeliveryR\n" +
+	"deliveries\"\xc9\x03\n" +
+	"\x0fWebhookDelivery\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x122\n" +
+	"\x05event\x18\x02 \x01(\x0e2\x1c.url_service.v1.WebhookEventR\x05event\x12\x1b\n" +
+	"\tshort_url\x18\x03 \x01(\tR\bshortUrl\x126\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x1e.url_service.v1.DeliveryStatusR\x06status\x12\x1a\n" +
+	"\battempts\x18\x05 \x01(\x05R\battempts\x12(\n" +
+	"\x10last_status_code\x18\x06 \x01(\x05R\x0elastStatusCode\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\a \x01(\tR\tlastError\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12B\n" +
+	"\x0fnext_attempt_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\rnextAttemptAt*K\n" +
+	"\bQRFormat\x12\x19\n" +
+	"\x15QR_FORMAT_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rQR_FORMAT_PNG\x10\x01\x12\x11\n" +
+	"\rQR_FORMAT_SVG\x10\x02*\xa4\x01\n" +
+	"\x0fErrorCorrection\x12 \n" +
+	"\x1cERROR_CORRECTION_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14ERROR_CORRECTION_LOW\x10\x01\x12\x1b\n" +
+	"\x17ERROR_CORRECTION_MEDIUM\x10\x02\x12\x1d\n" +
+	"\x19ERROR_CORRECTION_QUARTILE\x10\x03\x12\x19\n" +
+	"\x15ERROR_CORRECTION_HIGH\x10\x04*t\n" +
+	"\n" +
+	"LinkStatus\x12\x1b\n" +
+	"\x17LINK_STATUS_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12LINK_STATUS_ACTIVE\x10\x01\x12\x18\n" +
+	"\x14LINK_STATUS_DISABLED\x10\x02\x12\x17\n" +
+	"\x13LINK_STATUS_DELETED\x10\x03*\x7f\n" +
+	"\tMergeMode\x12\x1a\n" +
+	"\x16MERGE_MODE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13MERGE_MODE_OVERRIDE\x10\x01\x12\x1c\n" +
+	"\x18MERGE_MODE_KEEP_ORIGINAL\x10\x02\x12\x1f\n" +
+	"\x1bMERGE_MODE_REJECT_CONFLICTS\x10\x03*b\n" +
+	"\bPlatform\x12\x18\n" +
+	"\x14PLATFORM_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fPLATFORM_IOS\x10\x01\x12\x14\n" +
+	"\x10PLATFORM_ANDROID\x10\x02\x12\x14\n" +
+	"\x10PLATFORM_DESKTOP\x10\x03*U\n" +
+	"\tSplitMode\x12\x1a\n" +
+	"\x16SPLIT_MODE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11SPLIT_MODE_RANDOM\x10\x01\x12\x15\n" +
+	"\x11SPLIT_MODE_STICKY\x10\x02*\x99\x01\n" +
+	"\fWebhookEvent\x12\x1d\n" +
+	"\x19WEBHOOK_EVENT_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15WEBHOOK_EVENT_CREATED\x10\x01\x12\x19\n" +
+	"\x15WEBHOOK_EVENT_DELETED\x10\x02\x12\x19\n" +
+	"\x15WEBHOOK_EVENT_VISITED\x10\x03\x12\x19\n" +
+	"\x15WEBHOOK_EVENT_EXPIRED\x10\x04*\x87\x01\n" +
+	"\x0eDeliveryStatus\x12\x1f\n" +
+	"\x1bDELIVERY_STATUS_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17DELIVERY_STATUS_PENDING\x10\x01\x12\x1d\n" +
+	"\x19DELIVERY_STATUS_DELIVERED\x10\x02\x12\x18\n" +
+	"\x14DELIVERY_STATUS_DEAD\x10\x032\x8d\n" +
+	"\n" +
 	"\x10ShortenerService\x12\\\n" +
 	"\x0eGetOriginalURL\x12\x18.url_service.v1.ShortURL\x1a\x1b.url_service.v1.OriginalURL\"\x13\x82\xd3\xe4\x93\x02\r\x12\v/v1/{url=*}\x12i\n" +
-	"\x10GenerateShortURL\x12'.url_service.v1.GenerateShortURLRequest\x1a\x13.url_service.v1.URL\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/generate\x12W\n" +
-	"\x0eDeleteShortURL\x12\x18.url_service.v1.ShortURL\x1a\x16.google.protobuf.Empty\"\x13\x82\xd3\xe4\x93\x02\r*\v/v1/{url=*}B:Z8github.com/OrtemRepos/ShortURL/shortener-service/gen/urlb\x06proto3"
+	"\x10GenerateShortURL\x12'.url_service.v1.GenerateShortURLRequest\x1a\x13.url_service.v1.URL\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/generate\x12d\n" +
+	"\x0eDeleteShortURL\x12%.url_service.v1.DeleteShortURLRequest\x1a\x16.google.protobuf.Empty\"\x13\x82\xd3\xe4\x93\x02\r*\v/v1/{url=*}\x12`\n" +
+	"\x0fRestoreShortURL\x12\x18.url_service.v1.ShortURL\x1a\x16.google.protobuf.Empty\"\x1b\x82\xd3\xe4\x93\x02\x15\"\x13/v1/{url=*}:restore\x12`\n" +
+	"\x0fDisableShortURL\x12\x18.url_service.v1.ShortURL\x1a\x16.google.protobuf.Empty\"\x1b\x82\xd3\xe4\x93\x02\x15\"\x13/v1/{url=*}:disable\x12^\n" +
+	"\x0eEnableShortURL\x12\x18.url_service.v1.ShortURL\x1a\x16.google.protobuf.Empty\"\x1a\x82\xd3\xe4\x93\x02\x14\"\x12/v1/{url=*}:enable\x12\\\n" +
+	"\vGetURLStats\x12\x18.url_service.v1.ShortURL\x1a\x18.url_service.v1.URLStats\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/v1/{url=*}/stats\x12[\n" +
+	"\tGetQRCode\x12 .url_service.v1.GetQRCodeRequest\x1a\x14.google.api.HttpBody\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/{url=*}/qr\x12k\n" +
+	"\x0fRegisterWebhook\x12&.url_service.v1.RegisterWebhookRequest\x1a\x17.url_service.v1.Webhook\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/webhooks\x12o\n" +
+	"\fListWebhooks\x12#.url_service.v1.ListWebhooksRequest\x1a$.url_service.v1.ListWebhooksResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/webhooks\x12h\n" +
+	"\rDeleteWebhook\x12$.url_service.v1.DeleteWebhookRequest\x1a\x16.google.protobuf.Empty\"\x19\x82\xd3\xe4\x93\x02\x13*\x11/v1/webhooks/{id}\x12\xa2\x01\n" +
+	"\x15ListWebhookDeliveries\x12,.url_service.v1.ListWebhookDeliveriesRequest\x1a-.url_service.v1.ListWebhookDeliveriesResponse\",\x82\xd3\xe4\x93\x02&\x12$/v1/webhooks/{webhook_id}/deliveriesB:Z8github.com/OrtemRepos/ShortURL/shortener-service/gen/urlb\x06proto3"
 
 var (
 	file_url_service_proto_rawDescOnce sync.Once
@@ -248,28 +1937,96 @@ func file_url_service_proto_rawDescGZIP() []byte {
 	return file_url_service_proto_rawDescData
 }
 
-var file_url_service_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_url_service_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_url_service_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_url_service_proto_goTypes = []any{
-	(*URL)(nil),                     // 0: url_service.v1.URL
-	(*OriginalURL)(nil),             // 1: url_service.v1.OriginalURL
-	(*ShortURL)(nil),                // 2: url_service.v1.ShortURL
-	(*GenerateShortURLRequest)(nil), // 3: url_service.v1.GenerateShortURLRequest
-	(*durationpb.Duration)(nil),     // 4: google.protobuf.Duration
-	(*emptypb.Empty)(nil),           // 5: google.protobuf.Empty
+	(QRFormat)(0),                         // 0: url_service.v1.QRFormat
+	(ErrorCorrection)(0),                  // 1: url_service.v1.ErrorCorrection
+	(LinkStatus)(0),                       // 2: url_service.v1.LinkStatus
+	(MergeMode)(0),                        // 3: url_service.v1.MergeMode
+	(Platform)(0),                         // 4: url_service.v1.Platform
+	(SplitMode)(0),                        // 5: url_service.v1.SplitMode
+	(WebhookEvent)(0),                     // 6: url_service.v1.WebhookEvent
+	(DeliveryStatus)(0),                   // 7: url_service.v1.DeliveryStatus
+	(*URL)(nil),                           // 8: url_service.v1.URL
+	(*OriginalURL)(nil),                   // 9: url_service.v1.OriginalURL
+	(*ShortURL)(nil),                      // 10: url_service.v1.ShortURL
+	(*GetQRCodeRequest)(nil),              // 11: url_service.v1.GetQRCodeRequest
+	(*DeleteShortURLRequest)(nil),         // 12: url_service.v1.DeleteShortURLRequest
+	(*GenerateShortURLRequest)(nil),       // 13: url_service.v1.GenerateShortURLRequest
+	(*URLStats)(nil),                      // 14: url_service.v1.URLStats
+	(*QueryOptions)(nil),                  // 15: url_service.v1.QueryOptions
+	(*RedirectRule)(nil),                  // 16: url_service.v1.RedirectRule
+	(*Variant)(nil),                       // 17: url_service.v1.Variant
+	(*Webhook)(nil),                       // 18: url_service.v1.Webhook
+	(*RegisterWebhookRequest)(nil),        // 19: url_service.v1.RegisterWebhookRequest
+	(*ListWebhooksRequest)(nil),           // 20: url_service.v1.ListWebhooksRequest
+	(*ListWebhooksResponse)(nil),          // 21: url_service.v1.ListWebhooksResponse
+	(*DeleteWebhookRequest)(nil),          // 22: url_service.v1.DeleteWebhookRequest
+	(*ListWebhookDeliveriesRequest)(nil),  // 23: url_service.v1.ListWebhookDeliveriesRequest
+	(*ListWebhookDeliveriesResponse)(nil), // 24: url_service.v1.ListWebhookDeliveriesResponse
+	(*WebhookDelivery)(nil),               // 25: url_service.v1.WebhookDelivery
+	nil,                                   // 26: url_service.v1.QueryOptions.UtmEntry
+	(*durationpb.Duration)(nil),           // 27: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),         // 28: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                 // 29: google.protobuf.Empty
+	(*httpbody.HttpBody)(nil),             // 30: google.api.HttpBody
 }
 var file_url_service_proto_depIdxs = []int32{
-	4, // 0: url_service.v1.GenerateShortURLRequest.ttl:type_name -> google.protobuf.Duration
-	2, // 1: url_service.v1.ShortenerService.GetOriginalURL:input_type -> url_service.v1.ShortURL
-	3, // 2: url_service.v1.ShortenerService.GenerateShortURL:input_type -> url_service.v1.GenerateShortURLRequest
-	2, // 3: url_service.v1.ShortenerService.DeleteShortURL:input_type -> url_service.v1.ShortURL
-	1, // 4: url_service.v1.ShortenerService.GetOriginalURL:output_type -> url_service.v1.OriginalURL
-	0, // 5: url_service.v1.ShortenerService.GenerateShortURL:output_type -> url_service.v1.URL
-	5, // 6: url_service.v1.ShortenerService.DeleteShortURL:output_type -> google.protobuf.Empty
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: url_service.v1.GetQRCodeRequest.format:type_name -> url_service.v1.QRFormat
+	1,  // 1: url_service.v1.GetQRCodeRequest.error_correction:type_name -> url_service.v1.ErrorCorrection
+	27, // 2: url_service.v1.GenerateShortURLRequest.ttl:type_name -> google.protobuf.Duration
+	28, // 3: url_service.v1.GenerateShortURLRequest.not_before:type_name -> google.protobuf.Timestamp
+	28, // 4: url_service.v1.GenerateShortURLRequest.expires_at:type_name -> google.protobuf.Timestamp
+	16, // 5: url_service.v1.GenerateShortURLRequest.rules:type_name -> url_service.v1.RedirectRule
+	17, // 6: url_service.v1.GenerateShortURLRequest.variants:type_name -> url_service.v1.Variant
+	5,  // 7: url_service.v1.GenerateShortURLRequest.split_mode:type_name -> url_service.v1.SplitMode
+	15, // 8: url_service.v1.GenerateShortURLRequest.query_options:type_name -> url_service.v1.QueryOptions
+	2,  // 9: url_service.v1.URLStats.status:type_name -> url_service.v1.LinkStatus
+	28, // 10: url_service.v1.URLStats.not_before:type_name -> google.protobuf.Timestamp
+	28, // 11: url_service.v1.URLStats.expires_at:type_name -> google.protobuf.Timestamp
+	3,  // 12: url_service.v1.QueryOptions.merge_mode:type_name -> url_service.v1.MergeMode
+	26, // 13: url_service.v1.QueryOptions.utm:type_name -> url_service.v1.QueryOptions.UtmEntry
+	4,  // 14: url_service.v1.RedirectRule.platform:type_name -> url_service.v1.Platform
+	6,  // 15: url_service.v1.Webhook.events:type_name -> url_service.v1.WebhookEvent
+	28, // 16: url_service.v1.Webhook.created_at:type_name -> google.protobuf.Timestamp
+	6,  // 17: url_service.v1.RegisterWebhookRequest.events:type_name -> url_service.v1.WebhookEvent
+	18, // 18: url_service.v1.ListWebhooksResponse.webhooks:type_name -> url_service.v1.Webhook
+	25, // 19: url_service.v1.ListWebhookDeliveriesResponse.deliveries:type_name -> url_service.v1.WebhookDelivery
+	6,  // 20: url_service.v1.WebhookDelivery.event:type_name -> url_service.v1.WebhookEvent
+	7,  // 21: url_service.v1.WebhookDelivery.status:type_name -> url_service.v1.DeliveryStatus
+	28, // 22: url_service.v1.WebhookDelivery.created_at:type_name -> google.protobuf.Timestamp
+	28, // 23: url_service.v1.WebhookDelivery.updated_at:type_name -> google.protobuf.Timestamp
+	28, // 24: url_service.v1.WebhookDelivery.next_attempt_at:type_name -> google.protobuf.Timestamp
+	10, // 25: url_service.v1.ShortenerService.GetOriginalURL:input_type -> url_service.v1.ShortURL
+	13, // 26: url_service.v1.ShortenerService.GenerateShortURL:input_type -> url_service.v1.GenerateShortURLRequest
+	12, // 27: url_service.v1.ShortenerService.DeleteShortURL:input_type -> url_service.v1.DeleteShortURLRequest
+	10, // 28: url_service.v1.ShortenerService.RestoreShortURL:input_type -> url_service.v1.ShortURL
+	10, // 29: url_service.v1.ShortenerService.DisableShortURL:input_type -> url_service.v1.ShortURL
+	10, // 30: url_service.v1.ShortenerService.EnableShortURL:input_type -> url_service.v1.ShortURL
+	10, // 31: url_service.v1.ShortenerService.GetURLStats:input_type -> url_service.v1.ShortURL
+	11, // 32: url_service.v1.ShortenerService.GetQRCode:input_type -> url_service.v1.GetQRCodeRequest
+	19, // 33: url_service.v1.ShortenerService.RegisterWebhook:input_type -> url_service.v1.RegisterWebhookRequest
+	20, // 34: url_service.v1.ShortenerService.ListWebhooks:input_type -> url_service.v1.ListWebhooksRequest
+	22, // 35: url_service.v1.ShortenerService.DeleteWebhook:input_type -> url_service.v1.DeleteWebhookRequest
+	23, // 36: url_service.v1.ShortenerService.ListWebhookDeliveries:input_type -> url_service.v1.ListWebhookDeliveriesRequest
+	9,  // 37: url_service.v1.ShortenerService.GetOriginalURL:output_type -> url_service.v1.OriginalURL
+	8,  // 38: url_service.v1.ShortenerService.GenerateShortURL:output_type -> url_service.v1.URL
+	29, // 39: url_service.v1.ShortenerService.DeleteShortURL:output_type -> google.protobuf.Empty
+	29, // 40: url_service.v1.ShortenerService.RestoreShortURL:output_type -> google.protobuf.Empty
+	29, // 41: url_service.v1.ShortenerService.DisableShortURL:output_type -> google.protobuf.Empty
+	29, // 42: url_service.v1.ShortenerService.EnableShortURL:output_type -> google.protobuf.Empty
+	14, // 43: url_service.v1.ShortenerService.GetURLStats:output_type -> url_service.v1.URLStats
+	30, // 44: url_service.v1.ShortenerService.GetQRCode:output_type -> google.api.HttpBody
+	18, // 45: url_service.v1.ShortenerService.RegisterWebhook:output_type -> url_service.v1.Webhook
+	21, // 46: url_service.v1.ShortenerService.ListWebhooks:output_type -> url_service.v1.ListWebhooksResponse
+	29, // 47: url_service.v1.ShortenerService.DeleteWebhook:output_type -> google.protobuf.Empty
+	24, // 48: url_service.v1.ShortenerService.ListWebhookDeliveries:output_type -> url_service.v1.ListWebhookDeliveriesResponse
+	37, // [37:49] is the sub-list for method output_type
+	25, // [25:37] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_url_service_proto_init() }
@@ -277,18 +2034,20 @@ func file_url_service_proto_init() {
 	if File_url_service_proto != nil {
 		return
 	}
+	file_url_service_proto_msgTypes[3].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_url_service_proto_rawDesc), len(file_url_service_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      8,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_url_service_proto_goTypes,
 		DependencyIndexes: file_url_service_proto_depIdxs,
+		EnumInfos:         file_url_service_proto_enumTypes,
 		MessageInfos:      file_url_service_proto_msgTypes,
 	}.Build()
 	File_url_service_proto = out.File
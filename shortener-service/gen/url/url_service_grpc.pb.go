@@ -8,6 +8,7 @@ package url
 
 import (
 	context "context"
+	httpbody "google.golang.org/genproto/googleapis/api/httpbody"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -20,9 +21,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ShortenerService_GetOriginalURL_FullMethodName   = "/url_service.v1.ShortenerService/GetOriginalURL"
-	ShortenerService_GenerateShortURL_FullMethodName = "/url_service.v1.ShortenerService/GenerateShortURL"
-	ShortenerService_DeleteShortURL_FullMethodName   = "/url_service.v1.ShortenerService/DeleteShortURL"
+	ShortenerService_GetOriginalURL_FullMethodName        = "/url_service.v1.ShortenerService/GetOriginalURL"
+	ShortenerService_GenerateShortURL_FullMethodName      = "/url_service.v1.ShortenerService/GenerateShortURL"
+	ShortenerService_DeleteShortURL_FullMethodName        = "/url_service.v1.ShortenerService/DeleteShortURL"
+	ShortenerService_RestoreShortURL_FullMethodName       = "/url_service.v1.ShortenerService/RestoreShortURL"
+	ShortenerService_DisableShortURL_FullMethodName       = "/url_service.v1.ShortenerService/DisableShortURL"
+	ShortenerService_EnableShortURL_FullMethodName        = "/url_service.v1.ShortenerService/EnableShortURL"
+	ShortenerService_GetURLStats_FullMethodName           = "/url_service.v1.ShortenerService/GetURLStats"
+	ShortenerService_GetQRCode_FullMethodName             = "/url_service.v1.ShortenerService/GetQRCode"
+	ShortenerService_RegisterWebhook_FullMethodName       = "/url_service.v1.ShortenerService/RegisterWebhook"
+	ShortenerService_ListWebhooks_FullMethodName          = "/url_service.v1.ShortenerService/ListWebhooks"
+	ShortenerService_DeleteWebhook_FullMethodName         = "/url_service.v1.ShortenerService/DeleteWebhook"
+	ShortenerService_ListWebhookDeliveries_FullMethodName = "/url_service.v1.ShortenerService/ListWebhookDeliveries"
 )
 
 // ShortenerServiceClient is the client API for ShortenerService service.
@@ -31,7 +41,29 @@ const (
 type ShortenerServiceClient interface {
 	GetOriginalURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*OriginalURL, error)
 	GenerateShortURL(ctx context.Context, in *GenerateShortURLRequest, opts ...grpc.CallOption) (*URL, error)
-	DeleteShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteShortURL fails with NotFound when there is no link to delete and
+	// with FailedPrecondition when the link does not match the request's
+	// expectations.
+	DeleteShortURL(ctx context.Context, in *DeleteShortURLRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// RestoreShortURL brings back a deleted link while it is still retained.
+	RestoreShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DisableShortURL stops a link from resolving until it is enabled again.
+	DisableShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	EnableShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetURLStats describes a link without resolving it, including deleted
+	// links that can still be restored.
+	GetURLStats(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*URLStats, error)
+	// GetQRCode renders the full short link as a QR code image.
+	GetQRCode(ctx context.Context, in *GetQRCodeRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// RegisterWebhook subscribes an endpoint to events of the links the
+	// caller creates. The response carries the signing secret, which is not
+	// shown again.
+	RegisterWebhook(ctx context.Context, in *RegisterWebhookRequest, opts ...grpc.CallOption) (*Webhook, error)
+	ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error)
+	DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListWebhookDeliveries returns the latest deliveries of a webhook, newest
+	// first.
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error)
 }
 
 type shortenerServiceClient struct {
@@ -62,7 +94,7 @@ func (c *shortenerServiceClient) GenerateShortURL(ctx context.Context, in *Gener
 	return out, nil
 }
 
-func (c *shortenerServiceClient) DeleteShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+func (c *shortenerServiceClient) DeleteShortURL(ctx context.Context, in *DeleteShortURLRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, ShortenerService_DeleteShortURL_FullMethodName, in, out, cOpts...)
@@ -72,13 +104,125 @@ func (c *shortenerServiceClient) DeleteShortURL(ctx context.Context, in *ShortUR
 	return out, nil
 }
 
+func (c *shortenerServiceClient) RestoreShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ShortenerService_RestoreShortURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) DisableShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ShortenerService_DisableShortURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) EnableShortURL(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ShortenerService_EnableShortURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) GetURLStats(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*URLStats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(URLStats)
+	err := c.cc.Invoke(ctx, ShortenerService_GetURLStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) GetQRCode(ctx context.Context, in *GetQRCodeRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(httpbody.HttpBody)
+	err := c.cc.Invoke(ctx, ShortenerService_GetQRCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) RegisterWebhook(ctx context.Context, in *RegisterWebhookRequest, opts ...grpc.CallOption) (*Webhook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Webhook)
+	err := c.cc.Invoke(ctx, ShortenerService_RegisterWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhooksResponse)
+	err := c.cc.Invoke(ctx, ShortenerService_ListWebhooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ShortenerService_DeleteWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhookDeliveriesResponse)
+	err := c.cc.Invoke(ctx, ShortenerService_ListWebhookDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShortenerServiceServer is the server API for ShortenerService service.
 // All implementations must embed UnimplementedShortenerServiceServer
 // for forward compatibility.
 type ShortenerServiceServer interface {
 	GetOriginalURL(context.Context, *ShortURL) (*OriginalURL, error)
 	GenerateShortURL(context.Context, *GenerateShortURLRequest) (*URL, error)
-	DeleteShortURL(context.Context, *ShortURL) (*emptypb.Empty, error)
+	// DeleteShortURL fails with NotFound when there is no link to delete and
+	// with FailedPrecondition when the link does not match the request's
+	// expectations.
+	DeleteShortURL(context.Context, *DeleteShortURLRequest) (*emptypb.Empty, error)
+	// RestoreShortURL brings back a deleted link while it is still retained.
+	RestoreShortURL(context.Context, *ShortURL) (*emptypb.Empty, error)
+	// DisableShortURL stops a link from resolving until it is enabled again.
+	DisableShortURL(context.Context, *ShortURL) (*emptypb.Empty, error)
+	EnableShortURL(context.Context, *ShortURL) (*emptypb.Empty, error)
+	// GetURLStats describes a link without resolving it, including deleted
+	// links that can still be restored.
+	GetURLStats(context.Context, *ShortURL) (*URLStats, error)
+	// GetQRCode renders the full short link as a QR code image.
+	GetQRCode(context.Context, *GetQRCodeRequest) (*httpbody.HttpBody, error)
+	// RegisterWebhook subscribes an endpoint to events of the links the
+	// caller creates. The response carries the signing secret, which is not
+	// shown again.
+	RegisterWebhook(context.Context, *RegisterWebhookRequest) (*Webhook, error)
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error)
+	DeleteWebhook(context.Context, *DeleteWebhookRequest) (*emptypb.Empty, error)
+	// ListWebhookDeliveries returns the latest deliveries of a webhook, newest
+	// first.
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error)
 	mustEmbedUnimplementedShortenerServiceServer()
 }
 
@@ -95,9 +239,36 @@ func (UnimplementedShortenerServiceServer) GetOriginalURL(context.Context, *Shor
 func (UnimplementedShortenerServiceServer) GenerateShortURL(context.Context, *GenerateShortURLRequest) (*URL, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GenerateShortURL not implemented")
 }
-func (UnimplementedShortenerServiceServer) DeleteShortURL(context.Context, *ShortURL) (*emptypb.Empty, error) {
+func (UnimplementedShortenerServiceServer) DeleteShortURL(context.Context, *DeleteShortURLRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteShortURL not implemented")
 }
+func (UnimplementedShortenerServiceServer) RestoreShortURL(context.Context, *ShortURL) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreShortURL not implemented")
+}
+func (UnimplementedShortenerServiceServer) DisableShortURL(context.Context, *ShortURL) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableShortURL not implemented")
+}
+func (UnimplementedShortenerServiceServer) EnableShortURL(context.Context, *ShortURL) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableShortURL not implemented")
+}
+func (UnimplementedShortenerServiceServer) GetURLStats(context.Context, *ShortURL) (*URLStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLStats not implemented")
+}
+func (UnimplementedShortenerServiceServer) GetQRCode(context.Context, *GetQRCodeRequest) (*httpbody.HttpBody, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQRCode not implemented")
+}
+func (UnimplementedShortenerServiceServer) RegisterWebhook(context.Context, *RegisterWebhookRequest) (*Webhook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterWebhook not implemented")
+}
+func (UnimplementedShortenerServiceServer) ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhooks not implemented")
+}
+func (UnimplementedShortenerServiceServer) DeleteWebhook(context.Context, *DeleteWebhookRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedShortenerServiceServer) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
 func (UnimplementedShortenerServiceServer) mustEmbedUnimplementedShortenerServiceServer() {}
 func (UnimplementedShortenerServiceServer) testEmbeddedByValue()                          {}
 
@@ -156,7 +327,7 @@ func _ShortenerService_GenerateShortURL_Handler(srv interface{}, ctx context.Con
 }
 
 func _ShortenerService_DeleteShortURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ShortURL)
+	in := new(DeleteShortURLRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -168,7 +339,169 @@ func _ShortenerService_DeleteShortURL_Handler(srv interface{}, ctx context.Conte
 		FullMethod: ShortenerService_DeleteShortURL_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ShortenerServiceServer).DeleteShortURL(ctx, req.(*ShortURL))
+		return srv.(ShortenerServiceServer).DeleteShortURL(ctx, req.(*DeleteShortURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_RestoreShortURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).RestoreShortURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_RestoreShortURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).RestoreShortURL(ctx, req.(*ShortURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_DisableShortURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).DisableShortURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_DisableShortURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).DisableShortURL(ctx, req.(*ShortURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_EnableShortURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).EnableShortURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_EnableShortURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).EnableShortURL(ctx, req.(*ShortURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_GetURLStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).GetURLStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_GetURLStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).GetURLStats(ctx, req.(*ShortURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_GetQRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQRCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).GetQRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_GetQRCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).GetQRCode(ctx, req.(*GetQRCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_RegisterWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).RegisterWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_RegisterWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).RegisterWebhook(ctx, req.(*RegisterWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_ListWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).ListWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_ListWebhooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).ListWebhooks(ctx, req.(*ListWebhooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_DeleteWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).DeleteWebhook(ctx, req.(*DeleteWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_ListWebhookDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -192,6 +525,42 @@ var ShortenerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteShortURL",
 			Handler:    _ShortenerService_DeleteShortURL_Handler,
 		},
+		{
+			MethodName: "RestoreShortURL",
+			Handler:    _ShortenerService_RestoreShortURL_Handler,
+		},
+		{
+			MethodName: "DisableShortURL",
+			Handler:    _ShortenerService_DisableShortURL_Handler,
+		},
+		{
+			MethodName: "EnableShortURL",
+			Handler:    _ShortenerService_EnableShortURL_Handler,
+		},
+		{
+			MethodName: "GetURLStats",
+			Handler:    _ShortenerService_GetURLStats_Handler,
+		},
+		{
+			MethodName: "GetQRCode",
+			Handler:    _ShortenerService_GetQRCode_Handler,
+		},
+		{
+			MethodName: "RegisterWebhook",
+			Handler:    _ShortenerService_RegisterWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhooks",
+			Handler:    _ShortenerService_ListWebhooks_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _ShortenerService_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _ShortenerService_ListWebhookDeliveries_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "url_service.proto",
@@ -0,0 +1,150 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// reloadDelay is how long the file has to stay unchanged before a reload.
+const reloadDelay = 100 * time.Millisecond
+
+var ErrInvalidURL = errors.New("destination is not a valid absolute url")
+
+var rejections = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "shortener_blocklist_rejections_total",
+	Help: "Destinations rejected by the blocklist.",
+}, []string{"reason"})
+
+// Blocklist rejects destinations matching the rules in a file and picks up
+// changes to the file without a restart.
+type Blocklist struct {
+	path   string
+	rules  atomic.Pointer[Rules]
+	logger *zap.Logger
+	// loaded describes the file the rules were last loaded from.
+	loaded os.FileInfo
+}
+
+func New(path string, logger *zap.Logger) (*Blocklist, error) {
+	b := &Blocklist{path: path, logger: logger}
+	if err := b.reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Check returns a *BlockedError if rawURL is blocked.
+func (b *Blocklist) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	if _, err := canonicalHost(u.Hostname()); err != nil {
+		return err
+	}
+
+	if blocked := b.rules.Load().Match(u); blocked != nil {
+		rejections.WithLabelValues(string(blocked.Reason)).Inc()
+		return blocked
+	}
+	return nil
+}
+
+// Watch reloads the rules whenever the file changes, until ctx is cancelled.
+// A file that fails to parse leaves the previous rules in place.
+func (b *Blocklist) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watching the directory also catches editors and config management
+	// replacing the file instead of writing to it, and Kubernetes swapping
+	// the symlink the path goes through. Such events name another entry of
+	// the directory, so any event is followed by a look at the file the path
+	// resolves to.
+	if err := watcher.Add(filepath.Dir(b.path)); err != nil {
+		return fmt.Errorf("failed to watch blocklist: %w", err)
+	}
+
+	// Writes often arrive as a truncate followed by more writes, so reloads
+	// wait for the file to settle instead of picking up a partial file.
+	settled := time.NewTimer(reloadDelay)
+	settled.Stop()
+	defer settled.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Write | fsnotify.Create | fsnotify.Rename | fsnotify.Remove) {
+				settled.Reset(reloadDelay)
+			}
+		case <-settled.C:
+			if !b.changed() {
+				continue
+			}
+			if err := b.reload(); err != nil {
+				b.logger.Error("failed to reload blocklist, keeping previous rules", zap.Error(err))
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			b.logger.Error("blocklist watcher failed", zap.Error(err))
+		}
+	}
+}
+
+// changed reports whether the file the path resolves to is no longer the
+// one the rules were loaded from, or has been written to since.
+func (b *Blocklist) changed() bool {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		// Let reload report it.
+		return true
+	}
+	return !os.SameFile(info, b.loaded) ||
+		!info.ModTime().Equal(b.loaded.ModTime()) ||
+		info.Size() != b.loaded.Size()
+}
+
+func (b *Blocklist) reload() error {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat blocklist: %w", err)
+	}
+	rules, err := Parse(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse blocklist %s: %w", b.path, err)
+	}
+	b.rules.Store(rules)
+	b.loaded = info
+
+	b.logger.Info("blocklist loaded",
+		zap.String("path", b.path),
+		zap.Int("rules", rules.Len()))
+	return nil
+}
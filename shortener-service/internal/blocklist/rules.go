@@ -0,0 +1,232 @@
+package blocklist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reason tells which kind of rule rejected a destination.
+type Reason string
+
+const (
+	ReasonDomain     Reason = "BLOCKED_DOMAIN"
+	ReasonIP         Reason = "BLOCKED_IP"
+	ReasonURLPattern Reason = "BLOCKED_URL_PATTERN"
+)
+
+// BlockedError is returned for destinations matching a rule.
+type BlockedError struct {
+	Reason Reason
+	Rule   string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("destination is blocked by rule %q", e.Rule)
+}
+
+type pattern struct {
+	rule string
+	re   *regexp.Regexp
+}
+
+// Rules is a parsed blocklist. Each line of a blocklist file holds one rule:
+//
+//	example.com          the host example.com
+//	*.example.com        any subdomain of example.com
+//	203.0.113.7          an IP literal host
+//	198.51.100.0/24      IP literal hosts within a CIDR range
+//	example.com/login*   host and path, where * matches any characters
+//
+// Empty lines and lines starting with # are ignored.
+type Rules struct {
+	domains   map[string]struct{}
+	wildcards []string
+	prefixes  []netip.Prefix
+	patterns  []pattern
+	count     int
+}
+
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{domains: make(map[string]struct{})}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		rule := strings.TrimSpace(scanner.Text())
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		if err := rules.add(strings.ToLower(rule)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *Rules) add(rule string) error {
+	r.count++
+
+	if prefix, err := netip.ParsePrefix(rule); err == nil {
+		r.prefixes = append(r.prefixes, prefix.Masked())
+		return nil
+	}
+	if addr, err := netip.ParseAddr(rule); err == nil {
+		r.prefixes = append(r.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		return nil
+	}
+
+	if strings.Contains(rule, "/") {
+		re, err := compilePattern(rule)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", rule, err)
+		}
+		r.patterns = append(r.patterns, pattern{rule: rule, re: re})
+		return nil
+	}
+
+	if suffix, ok := strings.CutPrefix(rule, "*."); ok {
+		if suffix == "" || strings.Contains(suffix, "*") {
+			return fmt.Errorf("invalid wildcard domain %q", rule)
+		}
+		r.wildcards = append(r.wildcards, "."+suffix)
+		return nil
+	}
+	if strings.Contains(rule, "*") {
+		return fmt.Errorf("wildcards are only allowed as the leftmost label: %q", rule)
+	}
+	r.domains[strings.TrimSuffix(rule, ".")] = struct{}{}
+	return nil
+}
+
+// compilePattern turns a glob over host and path into an anchored regexp.
+func compilePattern(rule string) (*regexp.Regexp, error) {
+	parts := strings.Split(rule, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// Len returns the number of rules.
+func (r *Rules) Len() int {
+	return r.count
+}
+
+// Match returns the error for the first rule u matches, or nil. Numeric hosts
+// are matched as the IPv4 address browsers take them for.
+func (r *Rules) Match(u *url.URL) *BlockedError {
+	host, err := canonicalHost(u.Hostname())
+	if err != nil {
+		host = strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addr = addr.Unmap()
+		for _, prefix := range r.prefixes {
+			if prefix.Contains(addr) {
+				return &BlockedError{Reason: ReasonIP, Rule: prefix.String()}
+			}
+		}
+	} else {
+		if _, ok := r.domains[host]; ok {
+			return &BlockedError{Reason: ReasonDomain, Rule: host}
+		}
+		for _, suffix := range r.wildcards {
+			if strings.HasSuffix(host, suffix) {
+				return &BlockedError{Reason: ReasonDomain, Rule: "*" + suffix}
+			}
+		}
+	}
+
+	target := host + strings.ToLower(u.EscapedPath())
+	if target == host {
+		target += "/"
+	}
+	for _, p := range r.patterns {
+		if p.re.MatchString(target) {
+			return &BlockedError{Reason: ReasonURLPattern, Rule: p.rule}
+		}
+	}
+	return nil
+}
+
+// canonicalHost lowercases host and, if its last label is a number, parses it
+// as an IPv4 address the way browsers do (WHATWG URL), so that 2130706433,
+// 0x7f000001, 127.1 and 0177.0.0.1 all become 127.0.0.1. Hosts ending in a
+// number that are no valid IPv4 address fail with ErrInvalidURL, as they do
+// in browsers.
+func canonicalHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if strings.Contains(host, ":") {
+		return host, nil
+	}
+	labels := strings.Split(host, ".")
+	if !isNumeric(labels[len(labels)-1]) {
+		return host, nil
+	}
+	if len(labels) > 4 {
+		return "", ErrInvalidURL
+	}
+
+	numbers := make([]uint64, len(labels))
+	for i, label := range labels {
+		n, err := parseIPv4Number(label)
+		if err != nil {
+			return "", ErrInvalidURL
+		}
+		numbers[i] = n
+	}
+	ipv4 := numbers[len(numbers)-1]
+	if ipv4 >= 1<<(8*(5-len(numbers))) {
+		return "", ErrInvalidURL
+	}
+	for i, n := range numbers[:len(numbers)-1] {
+		if n > 255 {
+			return "", ErrInvalidURL
+		}
+		ipv4 += n << (8 * (3 - i))
+	}
+	return netip.AddrFrom4([4]byte{byte(ipv4 >> 24), byte(ipv4 >> 16), byte(ipv4 >> 8), byte(ipv4)}).String(), nil
+}
+
+// isNumeric reports whether a host label is a decimal or 0x-prefixed
+// hexadecimal number.
+func isNumeric(label string) bool {
+	digits, base := label, "0123456789"
+	if rest, ok := strings.CutPrefix(label, "0x"); ok {
+		digits, base = rest, "0123456789abcdef"
+	} else if label == "" {
+		return false
+	}
+	for _, c := range digits {
+		if !strings.ContainsRune(base, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIPv4Number parses a label of a numeric host: 0x-prefixed labels are
+// hexadecimal, other labels with a leading zero octal.
+func parseIPv4Number(label string) (uint64, error) {
+	switch {
+	case label == "":
+		return 0, ErrInvalidURL
+	case strings.HasPrefix(label, "0x"):
+		if label == "0x" {
+			return 0, nil
+		}
+		return strconv.ParseUint(label[2:], 16, 64)
+	case len(label) > 1 && label[0] == '0':
+		return strconv.ParseUint(label[1:], 8, 64)
+	}
+	return strconv.ParseUint(label, 10, 64)
+}
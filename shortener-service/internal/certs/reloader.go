@@ -0,0 +1,157 @@
+// Package certs serves TLS certificates from files that can change while the
+// service runs.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// reloadDelay is how long the files have to stay unchanged before a reload.
+const reloadDelay = 100 * time.Millisecond
+
+type bundle struct {
+	cert *tls.Certificate
+	cas  *x509.CertPool
+}
+
+// Reloader holds a certificate, its key and optionally a CA pool, and reloads
+// them when their files change.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	current  atomic.Pointer[bundle]
+	logger   *zap.Logger
+}
+
+// NewReloader loads the certificate and key, and the CA bundle when caFile is
+// set.
+func NewReloader(certFile, keyFile, caFile string, logger *zap.Logger) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		logger:   logger,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServerConfig returns a TLS config that always presents the latest
+// certificate and verifies client certificates against the latest CA pool
+// with clientAuth.
+func (r *Reloader) ServerConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			current := r.current.Load()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*current.cert},
+				ClientCAs:    current.cas,
+				ClientAuth:   clientAuth,
+				// gRPC clients insist on negotiating HTTP/2.
+				NextProtos: []string{"h2"},
+			}, nil
+		},
+	}
+}
+
+// Watch reloads the files whenever they change, until ctx is cancelled.
+// Files that fail to load leave the previous certificates in place.
+func (r *Reloader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Kubernetes updates mounted secrets by swapping a symlink, so every
+	// change in the directories is taken as a change of the files.
+	var dirs []string
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if dir := filepath.Dir(file); file != "" && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch certificates: %w", err)
+		}
+	}
+
+	// A rotation writes the certificate and key separately; waiting for the
+	// files to settle avoids loading a certificate with the old key.
+	settled := time.NewTimer(reloadDelay)
+	settled.Stop()
+	defer settled.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Write | fsnotify.Create | fsnotify.Rename | fsnotify.Remove) {
+				settled.Reset(reloadDelay)
+			}
+		case <-settled.C:
+			if err := r.reload(); err != nil {
+				r.logger.Error("failed to reload certificates, keeping previous ones", zap.Error(err))
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			r.logger.Error("certificate watcher failed", zap.Error(err))
+		}
+	}
+}
+
+func (r *Reloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	next := &bundle{cert: &cert}
+	if r.caFile != "" {
+		if next.cas, err = LoadCAs(r.caFile); err != nil {
+			return err
+		}
+	}
+	r.current.Store(next)
+
+	r.logger.Info("certificates loaded",
+		zap.String("cert_file", r.certFile),
+		zap.Time("not_after", cert.Leaf.NotAfter))
+	return nil
+}
+
+// LoadCAs reads a pool of PEM encoded CA certificates.
+func LoadCAs(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,76 @@
+package codegen
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+)
+
+const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var (
+	ErrInvalidLength     = errors.New("code length must be positive")
+	ErrInvalidCode       = errors.New("invalid code")
+	ErrKeyspaceExhausted = errors.New("code keyspace exhausted")
+)
+
+// CodeGenerator produces the short code for a URL that is about to be saved.
+type CodeGenerator interface {
+	Generate(ctx context.Context, url *domain.URL) (string, error)
+}
+
+// Deterministic is implemented by generators that always give a URL the same
+// code, so that a taken code is not worth generating again.
+type Deterministic interface {
+	Deterministic() bool
+}
+
+// Salted is implemented by deterministic generators that can derive other
+// codes for a URL, for when its own code is taken by a link it cannot share.
+// The same URL and salt always give the same code; an empty salt gives the
+// code Generate does.
+type Salted interface {
+	GenerateSalted(ctx context.Context, url *domain.URL, salt string) (string, error)
+}
+
+var base = big.NewInt(int64(len(alphabet)))
+
+// encode writes n in base62, left-padded with zeros to at least length digits.
+func encode(n *big.Int, length int) string {
+	var sb strings.Builder
+	digits := make([]byte, 0, length)
+	rem := new(big.Int)
+	n = new(big.Int).Set(n)
+	for n.Sign() > 0 {
+		n.DivMod(n, base, rem)
+		digits = append(digits, alphabet[rem.Int64()])
+	}
+	for len(digits) < length {
+		digits = append(digits, alphabet[0])
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		sb.WriteByte(digits[i])
+	}
+	return sb.String()
+}
+
+func decode(code string) (*big.Int, error) {
+	n := new(big.Int)
+	for i := 0; i < len(code); i++ {
+		idx := strings.IndexByte(alphabet, code[i])
+		if idx < 0 {
+			return nil, ErrInvalidCode
+		}
+		n.Mul(n, base)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+	return n, nil
+}
+
+// keyspace returns the number of distinct codes of the given length.
+func keyspace(length int) *big.Int {
+	return new(big.Int).Exp(base, big.NewInt(int64(length)), nil)
+}
@@ -0,0 +1,97 @@
+package codegen
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// CounterGenerator encodes a Redis INCR counter in base62. Counter values are
+// passed through an affine permutation of the code keyspace,
+//
+//	code = (n*multiplier + offset) mod 62^length,
+//
+// so that consecutive links do not get consecutive codes, while Decode can
+// still recover the counter value. Multiplier and offset are derived from
+// the salt.
+type CounterGenerator struct {
+	client     redis.UniversalClient
+	key        string
+	length     int
+	modulus    *big.Int
+	multiplier *big.Int
+	inverse    *big.Int
+	offset     *big.Int
+}
+
+func NewCounterGenerator(client redis.UniversalClient, key string, length int, salt string) (*CounterGenerator, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	modulus := keyspace(length)
+	sum := sha256.Sum256([]byte(salt))
+	offset := new(big.Int).SetBytes(sum[:16])
+	offset.Mod(offset, modulus)
+
+	// The multiplier must be coprime with the modulus to be invertible.
+	multiplier := new(big.Int).SetBytes(sum[16:])
+	multiplier.Mod(multiplier, modulus)
+	one := big.NewInt(1)
+	for new(big.Int).GCD(nil, nil, multiplier, modulus).Cmp(one) != 0 {
+		multiplier.Add(multiplier, one)
+		multiplier.Mod(multiplier, modulus)
+	}
+
+	return &CounterGenerator{
+		client:     client,
+		key:        key,
+		length:     length,
+		modulus:    modulus,
+		multiplier: multiplier,
+		inverse:    new(big.Int).ModInverse(multiplier, modulus),
+		offset:     offset,
+	}, nil
+}
+
+func (g *CounterGenerator) Generate(ctx context.Context, _ *domain.URL) (string, error) {
+	n, err := g.client.Incr(ctx, g.key).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to increment counter: %w", err)
+	}
+	return g.Encode(n)
+}
+
+// Encode returns the code for counter value n.
+func (g *CounterGenerator) Encode(n int64) (string, error) {
+	v := big.NewInt(n)
+	if v.Sign() < 0 || v.Cmp(g.modulus) >= 0 {
+		return "", ErrKeyspaceExhausted
+	}
+	v.Mul(v, g.multiplier)
+	v.Add(v, g.offset)
+	v.Mod(v, g.modulus)
+	return encode(v, g.length), nil
+}
+
+// Decode returns the counter value a code was generated from.
+func (g *CounterGenerator) Decode(code string) (int64, error) {
+	if len(code) != g.length {
+		return 0, ErrInvalidCode
+	}
+	v, err := decode(code)
+	if err != nil {
+		return 0, err
+	}
+	v.Sub(v, g.offset)
+	v.Mul(v, g.inverse)
+	v.Mod(v, g.modulus)
+	if !v.IsInt64() {
+		return 0, ErrInvalidCode
+	}
+	return v.Int64(), nil
+}
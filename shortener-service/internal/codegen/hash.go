@@ -0,0 +1,78 @@
+package codegen
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+)
+
+// HashGenerator derives the code from the normalized URL, so the same
+// destination always gets the same code. Salted codes mix in a salt as well.
+type HashGenerator struct {
+	length int
+}
+
+func NewHashGenerator(length int) (*HashGenerator, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+	return &HashGenerator{length: length}, nil
+}
+
+func (g *HashGenerator) Generate(ctx context.Context, u *domain.URL) (string, error) {
+	return g.GenerateSalted(ctx, u, "")
+}
+
+func (g *HashGenerator) GenerateSalted(_ context.Context, u *domain.URL, salt string) (string, error) {
+	normalized, err := Normalize(u.OriginalURL)
+	if err != nil {
+		return "", err
+	}
+	if salt != "" {
+		normalized += "\x00" + salt
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	code := encode(new(big.Int).SetBytes(sum[:]), g.length)
+	return code[len(code)-g.length:], nil
+}
+
+func (g *HashGenerator) Deterministic() bool {
+	return true
+}
+
+// Normalize brings equivalent spellings of a URL to one form: scheme and host
+// are lowercased, default ports are dropped, an empty path becomes "/" and
+// query parameters are sorted. Fragments are kept, since they can take the
+// visitor to another place or, in single-page apps, another page.
+func Normalize(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && !isDefaultPort(u.Scheme, port) {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+	u.RawQuery = u.Query().Encode()
+
+	return u.String(), nil
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
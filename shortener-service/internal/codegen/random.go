@@ -0,0 +1,45 @@
+package codegen
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+)
+
+// maxUnbiased is the largest multiple of len(alphabet) that fits in a byte;
+// random bytes at or above it are rejected to keep the distribution uniform.
+const maxUnbiased = 256 - 256%len(alphabet)
+
+// RandomGenerator produces crypto-random base62 codes.
+type RandomGenerator struct {
+	length int
+}
+
+func NewRandomGenerator(length int) (*RandomGenerator, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+	return &RandomGenerator{length: length}, nil
+}
+
+func (g *RandomGenerator) Generate(_ context.Context, _ *domain.URL) (string, error) {
+	code := make([]byte, 0, g.length)
+	buf := make([]byte, g.length*2)
+	for len(code) < g.length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) >= maxUnbiased {
+				continue
+			}
+			code = append(code, alphabet[int(b)%len(alphabet)])
+			if len(code) == g.length {
+				break
+			}
+		}
+	}
+	return string(code), nil
+}
@@ -2,97 +2,578 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/codegen"
 	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
-	"github.com/segmentio/kafka-go"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/logging"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
 	"go.uber.org/zap"
 )
 
 type URLRepository interface {
 	Save(ctx context.Context, url *domain.URL, expTime time.Duration) error
 	Get(ctx context.Context, shortURL string) (*domain.URL, error)
-	Delete(ctx context.Context, shortURL string) error
+	Delete(ctx context.Context, shortURL string, retention time.Duration, cond domain.Precondition) error
+	Restore(ctx context.Context, shortURL string) error
+	SetStatus(ctx context.Context, shortURL string, status domain.Status) error
+	ConsumeClick(ctx context.Context, shortURL string, retention time.Duration) (int64, error)
 }
 
+// maxGenerateAttempts bounds how many codes Save tries when generated codes
+// are already taken.
+const maxGenerateAttempts = 5
+
+// publishTimeout bounds how long an event may take to publish.
+const publishTimeout = 2 * time.Second
+
+// EventPublisher delivers link lifecycle events.
+type EventPublisher interface {
+	Publish(ctx context.Context, event events.Event) error
+}
+
+// FailureLimiter throttles repeated failed password attempts by a client on
+// a short URL. Allow counts the attempt and reports whether it may go ahead;
+// Reset forgets the attempts once the client gets the password right.
+type FailureLimiter interface {
+	Allow(ctx context.Context, shortURL, client string) (bool, error)
+	Reset(ctx context.Context, shortURL, client string) error
+}
+
+// DestinationChecker vets the original URL of new short URLs.
+type DestinationChecker interface {
+	Check(rawURL string) error
+}
+
+var (
+	ErrConflictingExpiry = errors.New("ttl and expires_at are mutually exclusive")
+	ErrInvalidSchedule   = errors.New("invalid activation window")
+	ErrNegativeTTL       = fmt.Errorf("%w: ttl cannot be negative", ErrInvalidSchedule)
+	ErrExpiryInPast      = fmt.Errorf("%w: expires_at is in the past", ErrInvalidSchedule)
+	ErrNotBeforeExpiry   = fmt.Errorf("%w: not_before must be before expiry", ErrInvalidSchedule)
+	ErrNotYetActive      = errors.New("url is not active yet")
+	ErrURLDisabled       = errors.New("url is disabled")
+	ErrURLDeleted        = errors.New("url is deleted")
+	ErrPasswordRequired  = errors.New("password required")
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrTooManyAttempts   = errors.New("too many failed attempts")
+)
+
 type Controller struct {
-	logger *zap.Logger
-	repo   URLRepository
-	writer *kafka.Writer
+	logger    *zap.Logger
+	repo      URLRepository
+	publisher EventPublisher
+	generator codegen.CodeGenerator
+	limiter   FailureLimiter
+	checker   DestinationChecker
+	retention time.Duration
+	domains   *domain.Domains
 }
 
-func NewController(repo URLRepository, writer *kafka.Writer, logger *zap.Logger) *Controller {
-	return &Controller{
-		repo:   repo,
-		writer: writer,
-		logger: logger,
+type Option func(*Controller)
+
+// WithFailureLimiter enables throttling of failed password attempts.
+func WithFailureLimiter(limiter FailureLimiter) Option {
+	return func(ctrl *Controller) {
+		ctrl.limiter = limiter
 	}
 }
 
+// WithDestinationChecker rejects new short URLs whose original URL the
+// checker refuses.
+func WithDestinationChecker(checker DestinationChecker) Option {
+	return func(ctrl *Controller) {
+		ctrl.checker = checker
+	}
+}
+
+// WithDeleteRetention keeps deleted URLs restorable for the given period.
+// Without it deleted URLs are removed right away.
+func WithDeleteRetention(retention time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.retention = retention
+	}
+}
+
+// WithDomains mints new URLs on the given domains and builds full short
+// links from them. Without it URLs have no domain and links are bare codes.
+func WithDomains(domains *domain.Domains) Option {
+	return func(ctrl *Controller) {
+		ctrl.domains = domains
+	}
+}
+
+func NewController(repo URLRepository, publisher EventPublisher, generator codegen.CodeGenerator, logger *zap.Logger, opts ...Option) *Controller {
+	ctrl := &Controller{
+		repo:      repo,
+		publisher: publisher,
+		generator: generator,
+		logger:    logger,
+	}
+	for _, opt := range opts {
+		opt(ctrl)
+	}
+	return ctrl
+}
+
 func (ctrl *Controller) Save(ctx context.Context, url *domain.URL, expTime time.Duration) error {
-	if url.ShortURL == "" {
-		url.ShortURL = url.GenerateShortURL()
+	for i := range url.Rules {
+		if err := url.Rules[i].Validate(); err != nil {
+			return err
+		}
+	}
+	if err := url.ValidateVariants(); err != nil {
+		return err
+	}
+	if err := url.Query.Validate(); err != nil {
+		return err
+	}
+	if url.ShortURL != "" {
+		if err := domain.ValidateAlias(url.ShortURL); err != nil {
+			return err
+		}
+	}
+	if err := ctrl.resolveDomain(url); err != nil {
+		return err
+	}
+
+	if ctrl.checker != nil {
+		if err := ctrl.checker.Check(url.OriginalURL); err != nil {
+			return err
+		}
+		for _, rule := range url.Rules {
+			if err := ctrl.checker.Check(rule.Destination); err != nil {
+				return err
+			}
+		}
+		for _, variant := range url.Variants {
+			if err := ctrl.checker.Check(variant.Destination); err != nil {
+				return err
+			}
+		}
+	}
+
+	expTime, err := ctrl.schedule(url, expTime, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if url.ShortURL != "" {
+		if err := ctrl.repo.Save(ctx, url, expTime); err != nil {
+			return err
+		}
+	} else {
+		reused, err := ctrl.saveGenerated(ctx, url, expTime)
+		if err != nil {
+			return err
+		}
+		if reused {
+			return nil
+		}
+	}
+
+	created := map[string]string{
+		"original_url": url.OriginalURL,
+		"short_url":    url.ShortURL,
+		"domain":       url.Domain,
+	}
+	if !url.ExpiresAt.IsZero() {
+		created["expires_at"] = url.ExpiresAt.UTC().Format(time.RFC3339Nano)
+	}
+	msgData, _ := json.Marshal(created)
+	ctrl.publish("url_created", url.ShortURL, url.Owner, msgData)
+
+	return nil
+}
+
+// resolveDomain settles the domain a new URL is minted on.
+func (ctrl *Controller) resolveDomain(url *domain.URL) error {
+	if ctrl.domains == nil {
+		if url.Domain != "" {
+			return fmt.Errorf("%w: %s", domain.ErrUnknownDomain, url.Domain)
+		}
+		return nil
+	}
+	resolved, err := ctrl.domains.Resolve(url.Domain)
+	if err != nil {
+		return err
+	}
+	url.Domain = resolved
+	return nil
+}
+
+// ShortLink returns the full short link of url.
+func (ctrl *Controller) ShortLink(url *domain.URL) string {
+	if ctrl.domains == nil {
+		return url.ShortURL
+	}
+	return ctrl.domains.Link(url)
+}
+
+// saveGenerated generates a code for url and saves it, retrying with a new
+// code when the generated one is already taken. A deterministic generator
+// gives the same code every time, so the existing link is handed out instead
+// if it can be shared, and saveGenerated reports that it did; otherwise the
+// code is salted, if the generator can, and saving retried.
+func (ctrl *Controller) saveGenerated(ctx context.Context, url *domain.URL, expTime time.Duration) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		shortURL, err := ctrl.generate(ctx, url, attempt)
+		if err != nil {
+			return false, fmt.Errorf("failed to generate short url: %w", err)
+		}
+		url.ShortURL = shortURL
 		ctrl.logger.Debug(
 			"short url generated",
 			zap.String("short_url", url.ShortURL),
-			zap.String("original_url", url.OriginalURL),
+			logging.URL("original_url", url.OriginalURL),
 		)
+
+		err = ctrl.repo.Save(ctx, url, expTime)
+		if errors.Is(err, repository.ErrURLExists) && ctrl.deterministic() {
+			var reused bool
+			reused, err = ctrl.reuse(ctx, url)
+			if _, salted := ctrl.generator.(codegen.Salted); reused || !salted {
+				return reused, err
+			}
+		}
+		if !errors.Is(err, repository.ErrURLExists) || attempt == maxGenerateAttempts {
+			if err != nil {
+				url.ShortURL = ""
+			}
+			return false, err
+		}
+	}
+}
+
+// generate makes the code for the given attempt to save url. Deterministic
+// generators that can salt their codes get a salt: the attempt for plain
+// links, so that the codes they share stay the same, and a random one for
+// links with settings, which are never shared.
+func (ctrl *Controller) generate(ctx context.Context, url *domain.URL, attempt int) (string, error) {
+	salted, ok := ctrl.generator.(codegen.Salted)
+	if !ok || !ctrl.deterministic() {
+		return ctrl.generator.Generate(ctx, url)
+	}
+	var salt string
+	switch {
+	case !plain(url):
+		salt = rand.Text()
+	case attempt > 1:
+		salt = strconv.Itoa(attempt - 1)
+	}
+	return salted.GenerateSalted(ctx, url, salt)
+}
+
+func (ctrl *Controller) deterministic() bool {
+	d, ok := ctrl.generator.(codegen.Deterministic)
+	return ok && d.Deterministic()
+}
+
+// reuse replaces url with the link already saved under its code, provided
+// both are plain links to the same destination on the same domain. Links
+// with settings of their own are never shared.
+func (ctrl *Controller) reuse(ctx context.Context, url *domain.URL) (bool, error) {
+	existing, err := ctrl.repo.Get(ctx, url.ShortURL)
+	if errors.Is(err, repository.ErrURLNotFound) {
+		err = repository.ErrURLExists
+	}
+	if err != nil {
+		url.ShortURL = ""
+		return false, err
+	}
+	if !shareable(existing, url) {
+		url.ShortURL = ""
+		return false, repository.ErrURLExists
+	}
+
+	ctrl.logger.Debug("reusing short url", zap.String("short_url", existing.ShortURL))
+	*url = *existing
+	return true, nil
+}
+
+func shareable(existing, requested *domain.URL) bool {
+	switch {
+	case existing.Status == domain.StatusDisabled, existing.Status == domain.StatusDeleted:
+		return false
+	case existing.Domain != requested.Domain:
+		return false
+	}
+	if !plain(existing) || !plain(requested) {
+		return false
+	}
+	a, errA := codegen.Normalize(existing.OriginalURL)
+	b, errB := codegen.Normalize(requested.OriginalURL)
+	return errA == nil && errB == nil && a == b
+}
+
+// plain reports whether url has nothing to it beyond its destination.
+func plain(url *domain.URL) bool {
+	return url.PasswordHash == "" && url.MaxClicks == 0 &&
+		url.NotBefore.IsZero() && url.ExpiresAt.IsZero() &&
+		len(url.Rules) == 0 && len(url.Variants) == 0 && url.Query.IsZero()
+}
+
+// Delete soft-deletes the URL if it still meets cond; it can be restored
+// until the retention period passes. It fails with
+// repository.ErrURLNotFound if there is no URL to delete.
+func (ctrl *Controller) Delete(ctx context.Context, shortURL string, cond domain.Precondition) error {
+	if err := domain.ValidateCode(shortURL); err != nil {
+		return err
+	}
+
+	// The owner is only needed to route the event, so a failed lookup does
+	// not stop the delete.
+	var owner string
+	if url, err := ctrl.repo.Get(ctx, shortURL); err == nil {
+		owner = url.Owner
 	}
 
-	if err := ctrl.repo.Save(ctx, url, expTime); err != nil {
+	if err := ctrl.repo.Delete(ctx, shortURL, ctrl.retention, cond); err != nil {
 		return err
 	}
-	go func() {
-		msgData, _ := json.Marshal(map[string]string{
-			"original_url": url.OriginalURL,
-			"short_url":    url.ShortURL,
+
+	ctrl.publish("url_deleted", shortURL, owner, []byte(shortURL))
+
+	return nil
+}
+
+func (ctrl *Controller) Restore(ctx context.Context, shortURL string) error {
+	if err := domain.ValidateCode(shortURL); err != nil {
+		return err
+	}
+	if err := ctrl.repo.Restore(ctx, shortURL); err != nil {
+		return err
+	}
+
+	ctrl.publishStatus("url_restored", shortURL)
+
+	return nil
+}
+
+// Disable stops the URL from redirecting without deleting it.
+func (ctrl *Controller) Disable(ctx context.Context, shortURL string) error {
+	if err := domain.ValidateCode(shortURL); err != nil {
+		return err
+	}
+	if err := ctrl.repo.SetStatus(ctx, shortURL, domain.StatusDisabled); err != nil {
+		return err
+	}
+
+	ctrl.publishStatus("url_disabled", shortURL)
+
+	return nil
+}
+
+func (ctrl *Controller) Enable(ctx context.Context, shortURL string) error {
+	if err := domain.ValidateCode(shortURL); err != nil {
+		return err
+	}
+	if err := ctrl.repo.SetStatus(ctx, shortURL, domain.StatusActive); err != nil {
+		return err
+	}
+
+	ctrl.publishStatus("url_enabled", shortURL)
+
+	return nil
+}
+
+// uncachedReader is implemented by repositories that cache reads.
+type uncachedReader interface {
+	GetUncached(ctx context.Context, shortURL string) (*domain.URL, error)
+}
+
+// Inspect returns the URL stored under shortURL whatever its status, without
+// counting a visit. It bypasses caches so counters are current.
+func (ctrl *Controller) Inspect(ctx context.Context, shortURL string) (*domain.URL, error) {
+	if err := domain.ValidateCode(shortURL); err != nil {
+		return nil, err
+	}
+	if repo, ok := ctrl.repo.(uncachedReader); ok {
+		return repo.GetUncached(ctx, shortURL)
+	}
+	return ctrl.repo.Get(ctx, shortURL)
+}
+
+// Lookup returns the URL stored under shortURL on host without counting a
+// visit.
+func (ctrl *Controller) Lookup(ctx context.Context, shortURL, host string) (*domain.URL, error) {
+	if err := domain.ValidateCode(shortURL); err != nil {
+		return nil, err
+	}
+	url, err := ctrl.repo.Get(ctx, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	if !url.ServedOn(host) {
+		return nil, repository.ErrURLNotFound
+	}
+	if url.Status == domain.StatusDeleted {
+		return nil, ErrURLDeleted
+	}
+	return url, nil
+}
+
+// Get resolves shortURL for a visit and tells where to send the visitor.
+func (ctrl *Controller) Get(ctx context.Context, shortURL string, visit domain.Visit) (*domain.Redirect, error) {
+	if err := domain.ValidateCode(shortURL); err != nil {
+		return nil, err
+	}
+	url, err := ctrl.repo.Get(ctx, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	if !url.ServedOn(visit.Host) {
+		return nil, repository.ErrURLNotFound
+	}
+
+	switch url.Status {
+	case domain.StatusDisabled:
+		return nil, ErrURLDisabled
+	case domain.StatusDeleted:
+		return nil, ErrURLDeleted
+	}
+
+	if !url.IsActive(time.Now()) {
+		return nil, ErrNotYetActive
+	}
+
+	if url.HasPassword() {
+		if err := ctrl.checkPassword(ctx, url, visit); err != nil {
+			return nil, err
+		}
+	}
+
+	redirect := url.Redirect(visit)
+	redirect.Location, err = url.Query.Apply(redirect.Location, visit.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	if url.HasClickLimit() {
+		if err := ctrl.consumeClick(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+
+	msgData, _ := json.Marshal(map[string]string{
+		"short_url": url.ShortURL,
+		"location":  redirect.Location,
+		"variant":   redirect.Variant,
+	})
+	ctrl.publish("url_visited", url.ShortURL, url.Owner, msgData)
+
+	return redirect, nil
+}
+
+// consumeClick takes one click off a click-limited URL. The repository deletes
+// the URL with its last click, keeping it for the retention period like
+// Delete does, which is announced with a url_exhausted event. A URL already
+// used up, which a stale cache may still show as active, is reported as
+// deleted.
+func (ctrl *Controller) consumeClick(ctx context.Context, url *domain.URL) error {
+	left, err := ctrl.repo.ConsumeClick(ctx, url.ShortURL, ctrl.retention)
+	if errors.Is(err, repository.ErrURLExhausted) {
+		return ErrURLDeleted
+	}
+	if err != nil {
+		return err
+	}
+
+	if left == 0 {
+		msgData, _ := json.Marshal(map[string]any{
+			"short_url":  url.ShortURL,
+			"max_clicks": url.MaxClicks,
 		})
+		ctrl.publish("url_exhausted", url.ShortURL, url.Owner, msgData)
+	}
+	return nil
+}
 
-		kafkaCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
+// schedule validates the activation window of a new URL and settles its
+// expiry: a relative TTL is turned into ExpiresAt and vice versa. It returns
+// the TTL the URL should be stored with.
+func (ctrl *Controller) schedule(url *domain.URL, expTime time.Duration, now time.Time) (time.Duration, error) {
+	if expTime < 0 {
+		return 0, ErrNegativeTTL
+	}
+
+	if !url.ExpiresAt.IsZero() {
+		if expTime > 0 {
+			return 0, ErrConflictingExpiry
+		}
+		expTime = url.ExpiresAt.Sub(now)
+		if expTime <= 0 {
+			return 0, ErrExpiryInPast
+		}
+	} else if expTime > 0 {
+		url.ExpiresAt = now.Add(expTime)
+	}
+
+	if !url.NotBefore.IsZero() && !url.ExpiresAt.IsZero() && !url.NotBefore.Before(url.ExpiresAt) {
+		return 0, ErrNotBeforeExpiry
+	}
+	return expTime, nil
+}
+
+func (ctrl *Controller) checkPassword(ctx context.Context, url *domain.URL, visit domain.Visit) error {
+	if visit.Password == "" {
+		return ErrPasswordRequired
+	}
+
+	if ctrl.limiter != nil {
+		allowed, err := ctrl.limiter.Allow(ctx, url.ShortURL, visit.Client())
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrTooManyAttempts
+		}
+	}
+
+	if !url.CheckPassword(visit.Password) {
+		return ErrInvalidPassword
+	}
 
-		if err := ctrl.writer.WriteMessages(kafkaCtx,
-			kafka.Message{
-				Key:   []byte("url_created"),
-				Value: msgData,
-			},
-		); err != nil {
-			ctrl.logger.Error("kafka write failed", 
-				zap.Error(err), 
+	if ctrl.limiter != nil {
+		if err := ctrl.limiter.Reset(ctx, url.ShortURL, visit.Client()); err != nil {
+			ctrl.logger.Warn("failed to reset password attempts",
+				zap.Error(err),
 				zap.String("short_url", url.ShortURL),
 			)
 		}
-	}()
-
+	}
 	return nil
 }
 
-func (ctrl *Controller) Delete(ctx context.Context, shortURL string) error {
-	if err := ctrl.repo.Delete(ctx, shortURL); err != nil {
-		return err
-	}
+// publishStatus announces a status change of shortURL.
+func (ctrl *Controller) publishStatus(event, shortURL string) {
+	msgData, _ := json.Marshal(map[string]string{"short_url": shortURL})
+	ctrl.publish(event, shortURL, "", msgData)
+}
 
+// publish delivers an event in the background.
+func (ctrl *Controller) publish(event, shortURL, owner string, value []byte) {
 	go func() {
-		kafkaCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
 		defer cancel()
 
-		if err := ctrl.writer.WriteMessages(kafkaCtx,
-			kafka.Message{
-				Key:   []byte("url_deleted"),
-				Value: []byte(shortURL),
-			},
-		); err != nil {
-			ctrl.logger.Error("kafka delete event failed",
+		if err := ctrl.publisher.Publish(ctx, events.Event{
+			Name:     event,
+			ShortURL: shortURL,
+			Owner:    owner,
+			Payload:  value,
+		}); err != nil {
+			ctrl.logger.Error("event publish failed",
 				zap.Error(err),
+				zap.String("event", event),
 				zap.String("short_url", shortURL),
 			)
 		}
 	}()
-
-	return nil
-}
-
-func (ctrl *Controller) Get(ctx context.Context, shortURL string) (*domain.URL, error) {
-	return ctrl.repo.Get(ctx, shortURL)
 }
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+var ErrUnknownDomain = errors.New("unknown domain")
+
+// Domains are the public hosts short links are served on: the host of the
+// base URL and any branded domains sharing its scheme and path.
+type Domains struct {
+	base  *url.URL
+	hosts map[string]bool
+}
+
+func NewDomains(baseURL string, branded []string) (*Domains, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: must be an absolute http(s) url", baseURL)
+	}
+	base.Host = strings.ToLower(base.Host)
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	base.RawQuery, base.Fragment = "", ""
+
+	d := &Domains{base: base, hosts: map[string]bool{base.Host: true}}
+	for _, host := range branded {
+		if host == "" || strings.ContainsAny(host, "/?#@") {
+			return nil, fmt.Errorf("invalid domain %q", host)
+		}
+		d.hosts[strings.ToLower(host)] = true
+	}
+	return d, nil
+}
+
+// Default returns the host of the base URL.
+func (d *Domains) Default() string {
+	return d.base.Host
+}
+
+// Resolve returns the canonical form of host, or ErrUnknownDomain when it is
+// not one of the domains. An empty host resolves to the default.
+func (d *Domains) Resolve(host string) (string, error) {
+	if host == "" {
+		return d.Default(), nil
+	}
+	host = strings.ToLower(host)
+	if !d.hosts[host] {
+		return "", fmt.Errorf("%w: %s", ErrUnknownDomain, host)
+	}
+	return host, nil
+}
+
+// Link returns the full short link of u on its domain.
+func (d *Domains) Link(u *URL) string {
+	link := *d.base
+	if u.Domain != "" {
+		link.Host = u.Domain
+	}
+	return link.JoinPath(u.ShortURL).String()
+}
+
+// ParseShortLink splits a full short link into its host and code. Anything
+// that is not an absolute URL is taken as a bare code.
+func ParseShortLink(link string) (host, code string) {
+	if !strings.Contains(link, "://") {
+		return "", link
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return "", link
+	}
+	return strings.ToLower(u.Host), path.Base(u.Path)
+}
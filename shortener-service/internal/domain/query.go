@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidQueryOptions = errors.New("invalid query options")
+	ErrQueryConflict       = errors.New("conflicting query parameters")
+)
+
+// MergeMode tells what happens when a parameter is already in the
+// destination's query.
+type MergeMode string
+
+const (
+	// MergeOverride replaces the destination's values.
+	MergeOverride MergeMode = "override"
+	// MergeKeepOriginal keeps the destination's values.
+	MergeKeepOriginal MergeMode = "keep_original"
+	// MergeRejectConflicts fails the redirect unless the values are equal.
+	MergeRejectConflicts MergeMode = "reject_conflicts"
+)
+
+// QueryOptions control the query string of the destination.
+type QueryOptions struct {
+	// Passthrough merges the visit's query parameters into the destination.
+	Passthrough bool      `json:"passthrough,omitempty"`
+	Merge       MergeMode `json:"merge,omitempty"`
+	// UTM parameters are merged into the destination on every visit, before
+	// passed through parameters.
+	UTM map[string]string `json:"utm,omitempty"`
+}
+
+func (q QueryOptions) IsZero() bool {
+	return !q.Passthrough && q.Merge == "" && len(q.UTM) == 0
+}
+
+func (q QueryOptions) Validate() error {
+	switch q.Merge {
+	case "", MergeOverride, MergeKeepOriginal, MergeRejectConflicts:
+	default:
+		return fmt.Errorf("%w: unknown merge mode %q", ErrInvalidQueryOptions, q.Merge)
+	}
+	for key := range q.UTM {
+		if !strings.HasPrefix(key, "utm_") || len(key) == len("utm_") {
+			return fmt.Errorf("%w: %q is not a utm parameter", ErrInvalidQueryOptions, key)
+		}
+	}
+	return nil
+}
+
+// Apply merges the UTM parameters and, with passthrough, the visit's query
+// into location.
+func (q QueryOptions) Apply(location string, query url.Values) (string, error) {
+	if len(q.UTM) == 0 && (!q.Passthrough || len(query) == 0) {
+		return location, nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination: %w", err)
+	}
+
+	mode := q.Merge
+	if mode == "" {
+		mode = MergeOverride
+	}
+
+	merged := u.Query()
+	if len(q.UTM) > 0 {
+		utm := make(url.Values, len(q.UTM))
+		for key, value := range q.UTM {
+			utm.Set(key, value)
+		}
+		if merged, err = MergeQuery(merged, utm, mode); err != nil {
+			return "", err
+		}
+	}
+	if q.Passthrough {
+		if merged, err = MergeQuery(merged, query, mode); err != nil {
+			return "", err
+		}
+	}
+
+	u.RawQuery = merged.Encode()
+	return u.String(), nil
+}
+
+// MergeQuery merges src into dst, resolving parameters present in both by
+// mode. It does not modify its arguments.
+func MergeQuery(dst, src url.Values, mode MergeMode) (url.Values, error) {
+	merged := make(url.Values, len(dst)+len(src))
+	for key, values := range dst {
+		merged[key] = slices.Clone(values)
+	}
+
+	for key, values := range src {
+		existing, ok := merged[key]
+		if !ok {
+			merged[key] = slices.Clone(values)
+			continue
+		}
+
+		switch mode {
+		case MergeOverride:
+			merged[key] = slices.Clone(values)
+		case MergeKeepOriginal:
+		case MergeRejectConflicts:
+			if !slices.Equal(existing, values) {
+				return nil, fmt.Errorf("%w: %s", ErrQueryConflict, key)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown merge mode %q", ErrInvalidQueryOptions, mode)
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRule = errors.New("invalid redirect rule")
+
+// Platform is the kind of device a visitor uses.
+type Platform string
+
+const (
+	PlatformIOS     Platform = "ios"
+	PlatformAndroid Platform = "android"
+	PlatformDesktop Platform = "desktop"
+)
+
+// Rule sends visitors matching all of its conditions to Destination instead
+// of the URL's original URL. Empty conditions match everyone.
+type Rule struct {
+	Platform Platform `json:"platform,omitempty"`
+	// Language is a language range such as "de" or "pt-br", matched against
+	// the visitor's Accept-Language on subtag boundaries.
+	Language    string `json:"language,omitempty"`
+	Destination string `json:"destination"`
+}
+
+// Validate checks the rule and normalises its language.
+func (r *Rule) Validate() error {
+	if r.Destination == "" {
+		return fmt.Errorf("%w: destination is required", ErrInvalidRule)
+	}
+	switch r.Platform {
+	case "", PlatformIOS, PlatformAndroid, PlatformDesktop:
+	default:
+		return fmt.Errorf("%w: unknown platform %q", ErrInvalidRule, r.Platform)
+	}
+	if r.Platform == "" && r.Language == "" {
+		return fmt.Errorf("%w: platform or language is required", ErrInvalidRule)
+	}
+
+	if r.Language != "" {
+		r.Language = strings.ToLower(r.Language)
+		for _, subtag := range strings.Split(r.Language, "-") {
+			if !isAlphanumeric(subtag) {
+				return fmt.Errorf("%w: invalid language %q", ErrInvalidRule, r.Language)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Rule) matches(platform Platform, languages []string) bool {
+	if r.Platform != "" && r.Platform != platform {
+		return false
+	}
+	if r.Language == "" {
+		return true
+	}
+	for _, lang := range languages {
+		if lang == r.Language || strings.HasPrefix(lang, r.Language+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRule returns the destination of the first rule the visit matches.
+func (u *URL) matchRule(visit Visit) (string, bool) {
+	if len(u.Rules) == 0 {
+		return "", false
+	}
+
+	platform := PlatformFromUserAgent(visit.UserAgent)
+	languages := ParseAcceptLanguage(visit.AcceptLanguage)
+	for _, rule := range u.Rules {
+		if rule.matches(platform, languages) {
+			return rule.Destination, true
+		}
+	}
+	return "", false
+}
+
+// PlatformFromUserAgent tells the platform from a User-Agent header. Empty
+// user agents have no platform.
+func PlatformFromUserAgent(userAgent string) Platform {
+	switch {
+	case userAgent == "":
+		return ""
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"),
+		strings.Contains(userAgent, "iPod"):
+		return PlatformIOS
+	case strings.Contains(userAgent, "Android"):
+		return PlatformAndroid
+	default:
+		return PlatformDesktop
+	}
+}
+
+// ParseAcceptLanguage returns the lowercased language tags of an
+// Accept-Language header, most preferred first. Tags with q=0 are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	languages := make([]string, len(tags))
+	for i, t := range tags {
+		languages[i] = t.tag
+	}
+	return languages
+}
+
+func isAlphanumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -1,37 +1,167 @@
 package domain
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
-	"math/big"
-	"strconv"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
 
-	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
-const maxInt64 = 1<<63 - 1
+// Status tells whether a URL resolves.
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusDisabled Status = "disabled"
+	// StatusDeleted URLs are kept for a retention period so they can be
+	// restored.
+	StatusDeleted Status = "deleted"
+)
 
 type URL struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	ShortURL     string `json:"short_url"`
+	OriginalURL  string `json:"original_url"`
+	PasswordHash string `json:"-"`
+	// MaxClicks limits how many times the URL can be resolved; zero means
+	// unlimited. ClicksLeft is what remains of it.
+	MaxClicks  int64 `json:"max_clicks,omitempty"`
+	ClicksLeft int64 `json:"clicks_left,omitempty"`
+	// The URL resolves only from NotBefore until ExpiresAt; zero values leave
+	// the window open on that side.
+	NotBefore time.Time `json:"not_before,omitzero"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	Status    Status    `json:"status,omitempty"`
+	// Rules are tried in order before falling back to OriginalURL.
+	Rules []Rule `json:"rules,omitempty"`
+	// Variants replace OriginalURL for visitors matching no rule.
+	Variants []Variant    `json:"variants,omitempty"`
+	Split    SplitMode    `json:"split,omitempty"`
+	Query    QueryOptions `json:"query,omitzero"`
+	// Domain is the host the URL was minted on. URLs without one resolve on
+	// every domain.
+	Domain string `json:"domain,omitempty"`
+	// Version starts at 1 and grows whenever the URL is changed.
+	Version int64 `json:"version,omitempty"`
+	// Owner is the caller that created the URL and gets its webhook events.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Precondition makes a change apply only to the URL as the caller last saw
+// it. Zero fields are not checked.
+type Precondition struct {
+	OriginalURL string
+	Version     int64
+}
+
+// Visit carries what the visitor supplied when resolving a short URL.
+type Visit struct {
+	Password       string
+	UserAgent      string
+	AcceptLanguage string
+	// VisitorID identifies returning visitors for sticky variants.
+	VisitorID string
+	Query     url.Values
+	// Host the visit was sent to; empty skips the domain check.
+	Host string
+	// ClientIP is the address the visit came from.
+	ClientIP string
+}
+
+// Client identifies who made the visit for throttling: the client IP, or the
+// visitor ID when the IP is not known.
+func (v Visit) Client() string {
+	if v.ClientIP != "" {
+		return v.ClientIP
+	}
+	return v.VisitorID
+}
+
+// maxAliasLength bounds custom codes.
+const maxAliasLength = 64
+
+var (
+	ErrInvalidAlias = errors.New("alias must be 1-64 letters, digits, '-' or '_'")
+	ErrInvalidCode  = errors.New("short code must be 1-64 letters, digits, '-' or '_'")
+)
+
+// ValidateAlias checks a custom code chosen instead of a generated one.
+func ValidateAlias(alias string) error {
+	if !validCode(alias) {
+		return ErrInvalidAlias
+	}
+	return nil
+}
+
+// ValidateCode checks the code of an existing link named in a request.
+// Generated codes and aliases follow the same rules, so a code that breaks
+// them cannot name a link.
+func ValidateCode(code string) error {
+	if !validCode(code) {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func validCode(code string) bool {
+	if code == "" || len(code) > maxAliasLength {
+		return false
+	}
+	for _, r := range code {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
 }
 
-func (u *URL) GenerateShortURL() string {
-	randomInt, err := rand.Int(rand.Reader, big.NewInt(maxInt64))
+// SetPassword protects the URL with a password. Only its bcrypt hash is kept.
+func (u *URL) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		zap.L().Fatal("random number generation error", zap.Error(err))
+		return err
 	}
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+// IsActive reports whether the URL's activation time has come.
+func (u *URL) IsActive(now time.Time) bool {
+	return u.NotBefore.IsZero() || !now.Before(u.NotBefore)
+}
+
+// ServedOn reports whether the URL resolves on host. Ports are ignored, so
+// a domain also matches when it is served on a non-default port.
+func (u *URL) ServedOn(host string) bool {
+	return host == "" || u.Domain == "" || strings.EqualFold(hostname(u.Domain), hostname(host))
+}
+
+// hostname strips the port from host, as found in a Host header.
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
+
+func (u *URL) HasClickLimit() bool {
+	return u.MaxClicks > 0
+}
+
+func (u *URL) HasPassword() bool {
+	return u.PasswordHash != ""
+}
 
-	randomStr := strconv.FormatInt(randomInt.Int64(), 10)
-	hash := sha256.Sum256([]byte(u.OriginalURL + randomStr))
-	u.ShortURL = hex.EncodeToString(hash[:])[:8]
-	return u.ShortURL
+func (u *URL) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
 }
 
 func NewURL(originalURL string) *URL {
 	return &URL{
 		OriginalURL: originalURL,
 		ShortURL:    "",
+		Status:      StatusActive,
 	}
 }
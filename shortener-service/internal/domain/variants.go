@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+)
+
+var ErrInvalidVariant = errors.New("invalid variant")
+
+// SplitMode tells how visitors are spread over a URL's variants.
+type SplitMode string
+
+const (
+	// SplitRandom picks a variant for every visit.
+	SplitRandom SplitMode = "random"
+	// SplitSticky keeps sending a visitor to the same variant.
+	SplitSticky SplitMode = "sticky"
+)
+
+// Variant is one of several weighted destinations of a URL.
+type Variant struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+	Weight      int    `json:"weight"`
+}
+
+// Redirect is where a visit to a short URL ends up.
+type Redirect struct {
+	URL      *URL
+	Location string
+	// Variant is the name of the chosen variant, if any.
+	Variant string
+}
+
+// Redirect decides where the visit goes: to the first rule it matches,
+// otherwise to one of the variants, otherwise to the original URL.
+func (u *URL) Redirect(visit Visit) *Redirect {
+	if destination, ok := u.matchRule(visit); ok {
+		return &Redirect{URL: u, Location: destination}
+	}
+	if variant := u.chooseVariant(visit.VisitorID); variant != nil {
+		return &Redirect{URL: u, Location: variant.Destination, Variant: variant.Name}
+	}
+	return &Redirect{URL: u, Location: u.OriginalURL}
+}
+
+// IsSticky reports whether visitors need an ID to keep their variant.
+func (u *URL) IsSticky() bool {
+	return u.Split == SplitSticky && len(u.Variants) > 0
+}
+
+// ValidateVariants checks the variants and names unnamed ones after their
+// position.
+func (u *URL) ValidateVariants() error {
+	switch u.Split {
+	case "", SplitRandom, SplitSticky:
+	default:
+		return fmt.Errorf("%w: unknown split mode %q", ErrInvalidVariant, u.Split)
+	}
+	if u.Split != "" && len(u.Variants) == 0 {
+		return fmt.Errorf("%w: split mode requires variants", ErrInvalidVariant)
+	}
+
+	names := make(map[string]struct{}, len(u.Variants))
+	for i := range u.Variants {
+		v := &u.Variants[i]
+		if v.Destination == "" {
+			return fmt.Errorf("%w: destination is required", ErrInvalidVariant)
+		}
+		if v.Weight <= 0 {
+			return fmt.Errorf("%w: weight must be positive", ErrInvalidVariant)
+		}
+		if v.Name == "" {
+			v.Name = strconv.Itoa(i)
+		}
+		if _, ok := names[v.Name]; ok {
+			return fmt.Errorf("%w: duplicate name %q", ErrInvalidVariant, v.Name)
+		}
+		names[v.Name] = struct{}{}
+	}
+	return nil
+}
+
+// chooseVariant picks a variant with a probability proportional to its
+// weight. Sticky URLs pick by a hash of the visitor, so the same visitor gets
+// the same variant as long as the variants do not change.
+func (u *URL) chooseVariant(visitorID string) *Variant {
+	total := 0
+	for _, v := range u.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	var n int
+	if u.Split == SplitSticky && visitorID != "" {
+		h := fnv.New64a()
+		h.Write([]byte(u.ShortURL))
+		h.Write([]byte{0})
+		h.Write([]byte(visitorID))
+		n = int(h.Sum64() % uint64(total))
+	} else {
+		n = rand.IntN(total)
+	}
+
+	for i := range u.Variants {
+		n -= u.Variants[i].Weight
+		if n < 0 {
+			return &u.Variants[i]
+		}
+	}
+	return nil
+}
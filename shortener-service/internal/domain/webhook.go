@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// WebhookEvent is a link event webhooks subscribe to.
+type WebhookEvent string
+
+const (
+	WebhookCreated WebhookEvent = "created"
+	WebhookDeleted WebhookEvent = "deleted"
+	WebhookVisited WebhookEvent = "visited"
+	// WebhookExpired is sent when a link runs out of clicks or reaches its
+	// expiry time.
+	WebhookExpired WebhookEvent = "expired"
+)
+
+var webhookEvents = []WebhookEvent{WebhookCreated, WebhookDeleted, WebhookVisited, WebhookExpired}
+
+var (
+	ErrInvalidWebhookURL    = errors.New("webhook url must be an absolute http or https url")
+	ErrPrivateWebhookURL    = errors.New("webhook url must not point to a private network")
+	ErrInvalidWebhookEvents = errors.New("invalid webhook events")
+)
+
+// Webhook is an endpoint an owner registered for events of their links.
+type Webhook struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+	URL   string `json:"url"`
+	// Secret signs deliveries; it is only shown to the owner at
+	// registration.
+	Secret    string         `json:"-"`
+	Events    []WebhookEvent `json:"events"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Validate checks the endpoint and events of a new webhook and drops
+// duplicate events.
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	if len(w.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidWebhookEvents)
+	}
+	var events []WebhookEvent
+	for _, event := range w.Events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhookEvents, event)
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	w.Events = events
+	return nil
+}
+
+// Subscribed reports whether the webhook wants event.
+func (w *Webhook) Subscribed(event WebhookEvent) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// DeliveryStatus tells where a webhook delivery stands.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries wait for their first or next attempt.
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead deliveries ran out of attempts and were moved to the
+	// webhook's dead letters.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery is one event sent to one webhook.
+type Delivery struct {
+	ID        string
+	WebhookID string
+	Event     WebhookEvent
+	ShortURL  string
+	// Payload is the JSON body sent to the endpoint.
+	Payload  []byte
+	Status   DeliveryStatus
+	Attempts int
+	// LastStatusCode and LastError describe the latest attempt; the code is
+	// zero when no response came back.
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	NextAttemptAt  time.Time
+}
+
+// ExpiringURL is a URL whose expiry is announced to its owner's webhooks.
+type ExpiringURL struct {
+	ShortURL string
+	Owner    string
+}
@@ -0,0 +1,61 @@
+// Package events publishes link lifecycle events to Kafka, Redis streams,
+// logs or memory.
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Event announces a change of a short URL. Name is also the Kafka message
+// key consumers dispatch on, Payload is its JSON body.
+type Event struct {
+	Name     string
+	ShortURL string
+	// Owner of the URL, when known.
+	Owner   string
+	Payload []byte
+}
+
+// Publisher delivers events to a sink.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NopPublisher drops every event.
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(context.Context, Event) error {
+	return nil
+}
+
+// fanOut publishes to several sinks at once.
+type fanOut []Publisher
+
+// FanOut returns a Publisher that delivers every event to all publishers
+// concurrently, so a slow sink does not hold up the others. It fails when
+// any of them fails.
+func FanOut(publishers ...Publisher) Publisher {
+	switch len(publishers) {
+	case 0:
+		return NopPublisher{}
+	case 1:
+		return publishers[0]
+	}
+	return fanOut(publishers)
+}
+
+func (f fanOut) Publish(ctx context.Context, event Event) error {
+	errs := make([]error, len(f))
+	var wg sync.WaitGroup
+	for i, p := range f {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = p.Publish(ctx, event)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
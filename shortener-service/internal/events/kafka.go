@@ -0,0 +1,23 @@
+package events
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher writes events to the writer's topic keyed by event name.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(writer *kafka.Writer) *KafkaPublisher {
+	return &KafkaPublisher{writer: writer}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Name),
+		Value: event.Payload,
+	})
+}
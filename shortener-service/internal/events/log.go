@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/logging"
+	"go.uber.org/zap"
+)
+
+// LogPublisher logs events at info level. Original URLs in payloads are
+// redacted like everywhere else in the logs.
+type LogPublisher struct {
+	logger *zap.Logger
+}
+
+func NewLogPublisher(logger *zap.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, event Event) error {
+	fields := []zap.Field{
+		zap.String("event", event.Name),
+		zap.String("short_url", event.ShortURL),
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(event.Payload, &payload); err == nil {
+		if originalURL, ok := payload["original_url"].(string); ok {
+			payload["original_url"] = logging.RedactURL(originalURL)
+		}
+		fields = append(fields, zap.Any("payload", payload))
+	}
+	p.logger.Info("event", fields...)
+	return nil
+}
@@ -0,0 +1,443 @@
+package events
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSegmentBytes   = 4 << 20
+	defaultReplayInterval = 5 * time.Second
+
+	segmentSuffix = ".seg"
+	// recordHeaderSize is the size of the length and CRC-32C preceding each
+	// record's data, a JSON-encoded Event.
+	recordHeaderSize = 8
+)
+
+var (
+	ErrEventTooLarge = errors.New("event does not fit in the spool")
+	errCorruptRecord = errors.New("corrupt spool record")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	spoolEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "shortener_event_spool_events",
+		Help: "Events waiting in the spool.",
+	})
+	spoolBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "shortener_event_spool_bytes",
+		Help: "Size of the spool segments on disk.",
+	})
+	spoolDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_event_spool_dropped_total",
+		Help: "Spooled events lost because the spool was full or corrupt.",
+	}, []string{"reason"})
+	spoolReplayed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shortener_event_spool_replayed_total",
+		Help: "Spooled events delivered by the replayer.",
+	})
+)
+
+// Spool protects a publisher against outages: events it fails to deliver
+// are appended to segment files on disk and replayed in order once it
+// recovers. While events are waiting, new ones are queued behind them.
+// Delivery is at least once; events being replayed when the process stops
+// are delivered again.
+type Spool struct {
+	dir            string
+	publisher      Publisher
+	maxBytes       int64
+	segmentBytes   int64
+	replayInterval time.Duration
+	logger         *zap.Logger
+
+	mu sync.Mutex
+	// segments are ordered oldest first; new events go to the last one
+	// while active is open.
+	segments []*segment
+	active   *os.File
+	nextID   uint64
+	events   int64
+	bytes    int64
+}
+
+type segment struct {
+	id     uint64
+	path   string
+	size   int64
+	events int64
+	// replayed events at the start of the segment were delivered already.
+	replayed int64
+}
+
+type SpoolOption func(*Spool)
+
+// WithSegmentSize sets the size at which a new segment file is started.
+// Full segments are dropped as a whole when the spool is full.
+func WithSegmentSize(bytes int64) SpoolOption {
+	return func(s *Spool) {
+		s.segmentBytes = bytes
+	}
+}
+
+// WithReplayInterval sets how often Run tries to replay spooled events.
+func WithReplayInterval(interval time.Duration) SpoolOption {
+	return func(s *Spool) {
+		s.replayInterval = interval
+	}
+}
+
+// NewSpool keeps up to maxBytes of events in dir, picking up events spooled
+// by a previous run.
+func NewSpool(dir string, publisher Publisher, maxBytes int64, logger *zap.Logger, opts ...SpoolOption) (*Spool, error) {
+	s := &Spool{
+		dir:            dir,
+		publisher:      publisher,
+		maxBytes:       maxBytes,
+		segmentBytes:   defaultSegmentBytes,
+		replayInterval: defaultReplayInterval,
+		logger:         logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.segmentBytes = min(s.segmentBytes, s.maxBytes)
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Publish delivers event, spooling it if delivery fails or older events are
+// still waiting. It only fails when the event cannot be spooled either.
+func (s *Spool) Publish(ctx context.Context, event Event) error {
+	if s.Depth() == 0 {
+		err := s.publisher.Publish(ctx, event)
+		if err == nil {
+			return nil
+		}
+		s.logger.Warn("event publish failed, spooling",
+			zap.Error(err),
+			zap.String("event", event.Name),
+			zap.String("short_url", event.ShortURL),
+		)
+	}
+	return s.append(event)
+}
+
+// Depth returns the number of events waiting in the spool.
+func (s *Spool) Depth() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events
+}
+
+// Run replays spooled events every replay interval until ctx is cancelled.
+func (s *Spool) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Replay(ctx); err != nil {
+				s.logger.Warn("spool replay failed, retrying later",
+					zap.Error(err),
+					zap.Int64("depth", s.Depth()),
+				)
+			}
+		}
+	}
+}
+
+// Replay delivers spooled events oldest first and deletes the segments it
+// drained. It stops at the first event that cannot be delivered.
+func (s *Spool) Replay(ctx context.Context) error {
+	for {
+		seg := s.oldest()
+		if seg == nil {
+			return nil
+		}
+
+		events, err := readSegment(seg.path)
+		if err != nil && !errors.Is(err, errCorruptRecord) {
+			return err
+		}
+
+		s.mu.Lock()
+		next := seg.replayed
+		s.mu.Unlock()
+		for _, event := range events[min(next, int64(len(events))):] {
+			if err := s.publisher.Publish(ctx, event); err != nil {
+				return fmt.Errorf("failed to replay %s event: %w", event.Name, err)
+			}
+			s.delivered(seg)
+		}
+		s.remove(seg, "corrupt")
+	}
+}
+
+// Close closes the segment being written. Spooled events stay on disk.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeActive()
+}
+
+// load picks up the segments left in the directory.
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		id, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seg := &segment{id: id, path: filepath.Join(s.dir, entry.Name())}
+
+		// Records after a corrupt one, usually a write torn by a crash,
+		// cannot be found and are lost.
+		events, err := readSegment(seg.path)
+		if errors.Is(err, errCorruptRecord) {
+			s.logger.Warn("spool segment is corrupt, skipping the rest of it",
+				zap.String("segment", seg.path),
+				zap.Int("recovered", len(events)),
+			)
+			spoolDropped.WithLabelValues("corrupt").Inc()
+		} else if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat spool segment: %w", err)
+		}
+		seg.size = info.Size()
+		seg.events = int64(len(events))
+
+		s.segments = append(s.segments, seg)
+		s.events += seg.events
+		s.bytes += seg.size
+		s.nextID = max(s.nextID, id+1)
+	}
+	slices.SortFunc(s.segments, func(a, b *segment) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	s.updateMetrics()
+
+	if s.events > 0 {
+		s.logger.Info("found spooled events", zap.Int64("depth", s.events))
+	}
+	return nil
+}
+
+func (s *Spool) append(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	record := make([]byte, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:], crc32.Checksum(data, crcTable))
+	copy(record[recordHeaderSize:], data)
+	size := int64(len(record))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if size > s.segmentBytes {
+		spoolDropped.WithLabelValues("too_large").Inc()
+		return ErrEventTooLarge
+	}
+	for s.bytes+size > s.maxBytes && len(s.segments) > 0 {
+		s.dropOldest()
+	}
+
+	if s.active != nil && s.segments[len(s.segments)-1].size+size > s.segmentBytes {
+		if err := s.closeActive(); err != nil {
+			return err
+		}
+	}
+	if s.active == nil {
+		if err := s.openSegment(); err != nil {
+			return err
+		}
+	}
+
+	seg := s.segments[len(s.segments)-1]
+	n, err := s.active.Write(record)
+	seg.size += int64(n)
+	s.bytes += int64(n)
+	if err != nil {
+		// The torn record ends the segment; later events go to a new one.
+		_ = s.closeActive()
+		s.updateMetrics()
+		return fmt.Errorf("failed to spool event: %w", err)
+	}
+	seg.events++
+	s.events++
+	s.updateMetrics()
+	return nil
+}
+
+// oldest returns the segment to replay next, closing it for writing.
+func (s *Spool) oldest() *segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.segments) == 0 {
+		return nil
+	}
+	if len(s.segments) == 1 && s.active != nil {
+		if err := s.closeActive(); err != nil {
+			s.logger.Error("failed to close spool segment", zap.Error(err))
+		}
+	}
+	return s.segments[0]
+}
+
+// delivered records that the next event of seg was replayed.
+func (s *Spool) delivered(seg *segment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spoolReplayed.Inc()
+	seg.replayed++
+	// A segment dropped while it was replayed is no longer counted.
+	if slices.Contains(s.segments, seg) {
+		s.events--
+		s.updateMetrics()
+	}
+}
+
+// remove deletes seg, counting its undelivered events as dropped for reason.
+func (s *Spool) remove(seg *segment, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(seg, reason)
+}
+
+// dropOldest makes room by dropping the oldest segment. It is called with
+// s.mu held.
+func (s *Spool) dropOldest() {
+	seg := s.segments[0]
+	if len(s.segments) == 1 && s.active != nil {
+		_ = s.closeActive()
+	}
+	s.logger.Warn("spool is full, dropping oldest events",
+		zap.Int64("dropped", seg.events-seg.replayed),
+		zap.Int64("max_bytes", s.maxBytes),
+	)
+	s.removeLocked(seg, "full")
+}
+
+func (s *Spool) removeLocked(seg *segment, reason string) {
+	i := slices.Index(s.segments, seg)
+	if i < 0 {
+		return
+	}
+	if lost := seg.events - seg.replayed; lost > 0 {
+		spoolDropped.WithLabelValues(reason).Add(float64(lost))
+		s.events -= lost
+	}
+	s.segments = slices.Delete(s.segments, i, i+1)
+	s.bytes -= seg.size
+	s.updateMetrics()
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Error("failed to delete spool segment", zap.Error(err), zap.String("segment", seg.path))
+	}
+}
+
+// openSegment starts a new segment. It is called with s.mu held.
+func (s *Spool) openSegment() error {
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.nextID, segmentSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	s.segments = append(s.segments, &segment{id: s.nextID, path: path})
+	s.nextID++
+	s.active = f
+	return nil
+}
+
+// closeActive ends the segment being written. It is called with s.mu held.
+func (s *Spool) closeActive() error {
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.active = nil
+	if err != nil {
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+	return nil
+}
+
+func (s *Spool) updateMetrics() {
+	spoolEvents.Set(float64(s.events))
+	spoolBytes.Set(float64(s.bytes))
+}
+
+// readSegment returns the events in a segment. When it hits a corrupt
+// record it returns the events before it and errCorruptRecord.
+func readSegment(path string) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+
+	var events []Event
+	for len(data) > 0 {
+		if len(data) < recordHeaderSize {
+			return events, errCorruptRecord
+		}
+		size := binary.BigEndian.Uint32(data)
+		checksum := binary.BigEndian.Uint32(data[4:])
+		if uint64(len(data)-recordHeaderSize) < uint64(size) {
+			return events, errCorruptRecord
+		}
+		record := data[recordHeaderSize : recordHeaderSize+int(size)]
+		if crc32.Checksum(record, crcTable) != checksum {
+			return events, errCorruptRecord
+		}
+		var event Event
+		if err := json.Unmarshal(record, &event); err != nil {
+			return events, errCorruptRecord
+		}
+		events = append(events, event)
+		data = data[recordHeaderSize+int(size):]
+	}
+	return events, nil
+}
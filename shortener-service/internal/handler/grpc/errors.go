@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/blocklist"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/codegen"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/qr"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain identifies this service in error details.
+const errorDomain = "shortener-service"
+
+// retryDelay is suggested to clients for errors that go away by themselves.
+const retryDelay = time.Second
+
+// errorMapping tells how an error is reported to clients. Errors with a
+// field are the client's fault and keep their own message, which names the
+// problem; the others get a fixed message.
+type errorMapping struct {
+	err     error
+	code    codes.Code
+	reason  string
+	message string
+	field   string
+	retry   time.Duration
+}
+
+// errorMappings are tried in order with errors.Is.
+var errorMappings = []errorMapping{
+	{err: repository.ErrURLNotFound, code: codes.NotFound, reason: "URL_NOT_FOUND", message: "URL not found"},
+	{err: controller.ErrURLDeleted, code: codes.FailedPrecondition, reason: "URL_DELETED", message: "URL is deleted"},
+	{err: controller.ErrURLDisabled, code: codes.FailedPrecondition, reason: "URL_DISABLED", message: "URL is disabled"},
+	{err: controller.ErrNotYetActive, code: codes.FailedPrecondition, reason: "URL_NOT_YET_ACTIVE", message: "URL is not active yet"},
+	{err: repository.ErrURLNotDeleted, code: codes.FailedPrecondition, reason: "URL_NOT_DELETED", message: "URL is not deleted"},
+	{err: repository.ErrURLExpired, code: codes.FailedPrecondition, reason: "URL_EXPIRED", message: "URL has expired"},
+	{err: repository.ErrURLChanged, code: codes.FailedPrecondition, reason: "URL_CHANGED", message: "URL does not match the expected original URL or version"},
+	{err: repository.ErrURLExists, code: codes.AlreadyExists, reason: "URL_EXISTS", message: "short URL already exists"},
+	{err: controller.ErrPasswordRequired, code: codes.PermissionDenied, reason: "PASSWORD_REQUIRED", message: "password required"},
+	{err: controller.ErrInvalidPassword, code: codes.PermissionDenied, reason: "INVALID_PASSWORD", message: "invalid password"},
+	{err: controller.ErrTooManyAttempts, code: codes.ResourceExhausted, reason: "TOO_MANY_ATTEMPTS", message: "too many failed password attempts"},
+	{err: repository.ErrIdempotencyMismatch, code: codes.FailedPrecondition, reason: "IDEMPOTENCY_KEY_REUSED", message: "idempotency key was used for a different request"},
+	{err: repository.ErrIdempotencyInProgress, code: codes.Aborted, reason: "IDEMPOTENCY_KEY_IN_PROGRESS", message: "request with this idempotency key is in progress", retry: retryDelay},
+	{err: repository.ErrWebhookNotFound, code: codes.NotFound, reason: "WEBHOOK_NOT_FOUND", message: "webhook not found"},
+	{err: codegen.ErrKeyspaceExhausted, code: codes.ResourceExhausted, reason: "KEYSPACE_EXHAUSTED", message: "no short codes left"},
+
+	{err: repository.ErrShortURLEmpty, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "url"},
+	{err: repository.ErrOriginalURLEmpty, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "original_url"},
+	{err: blocklist.ErrInvalidURL, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "original_url"},
+	{err: domain.ErrUnknownDomain, code: codes.InvalidArgument, reason: "UNKNOWN_DOMAIN", field: "domain"},
+	{err: domain.ErrInvalidAlias, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "alias"},
+	{err: domain.ErrInvalidCode, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "url"},
+	{err: controller.ErrConflictingExpiry, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "expires_at"},
+	{err: controller.ErrNegativeTTL, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "ttl"},
+	{err: controller.ErrExpiryInPast, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "expires_at"},
+	{err: controller.ErrInvalidSchedule, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "not_before"},
+	{err: domain.ErrInvalidRule, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "rules"},
+	{err: domain.ErrInvalidVariant, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "variants"},
+	{err: domain.ErrInvalidQueryOptions, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "query_options"},
+	{err: domain.ErrQueryConflict, code: codes.InvalidArgument, reason: "QUERY_CONFLICT", field: "query"},
+	{err: domain.ErrInvalidWebhookURL, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "url"},
+	{err: domain.ErrPrivateWebhookURL, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "url"},
+	{err: domain.ErrInvalidWebhookEvents, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "events"},
+	{err: qr.ErrInvalidOptions, code: codes.InvalidArgument, reason: "INVALID_ARGUMENT", field: "options"},
+
+	{err: context.Canceled, code: codes.Canceled, reason: "CANCELED", message: "request canceled"},
+	{err: context.DeadlineExceeded, code: codes.DeadlineExceeded, reason: "DEADLINE_EXCEEDED", message: "deadline exceeded", retry: retryDelay},
+	{err: redis.ErrClosed, code: codes.Unavailable, reason: "STORAGE_UNAVAILABLE", message: "storage unavailable", retry: retryDelay},
+	{err: redis.ErrPoolTimeout, code: codes.Unavailable, reason: "STORAGE_UNAVAILABLE", message: "storage unavailable", retry: retryDelay},
+}
+
+// StatusFromError translates err into a gRPC status error with error
+// details: an ErrorInfo with the reason, a BadRequest naming the offending
+// field for invalid arguments and a RetryInfo for errors worth retrying.
+// Unknown errors become Internal without their message, which may expose
+// internals; callers log them.
+func StatusFromError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var blocked *blocklist.BlockedError
+	if errors.As(err, &blocked) {
+		return newStatus(codes.InvalidArgument, "destination is blocked: "+string(blocked.Reason),
+			errorInfo(string(blocked.Reason)),
+			fieldViolation("original_url", blocked.Error()))
+	}
+
+	for _, m := range errorMappings {
+		if !errors.Is(err, m.err) {
+			continue
+		}
+		message := m.message
+		details := []protoadapt.MessageV1{errorInfo(m.reason)}
+		if m.field != "" {
+			message = err.Error()
+			details = append(details, fieldViolation(m.field, message))
+		}
+		if m.retry > 0 {
+			details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(m.retry)})
+		}
+		return newStatus(m.code, message, details...)
+	}
+
+	// Network errors come from Redis, which is usually back shortly.
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return newStatus(codes.Unavailable, "storage unavailable",
+			errorInfo("STORAGE_UNAVAILABLE"),
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)})
+	}
+	return newStatus(codes.Internal, "internal error", errorInfo("INTERNAL"))
+}
+
+// invalidArgument reports a problem with a request field.
+func invalidArgument(field, description string) error {
+	return newStatus(codes.InvalidArgument, description,
+		errorInfo("INVALID_ARGUMENT"),
+		fieldViolation(field, description))
+}
+
+// fail reports err to the client, logging errors the client is not told
+// about.
+func (h *Handler) fail(err error, msg string, fields ...zap.Field) error {
+	st := StatusFromError(err)
+	switch status.Code(st) {
+	case codes.Internal, codes.Unknown, codes.Unavailable:
+		h.logger.Error(msg, append(fields, zap.Error(err))...)
+	}
+	return st
+}
+
+func newStatus(code codes.Code, message string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, message)
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+func errorInfo(reason string) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+}
+
+func fieldViolation(field, description string) *errdetails.BadRequest {
+	return &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+	}
+}
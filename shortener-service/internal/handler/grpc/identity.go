@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"crypto/x509"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type callerKey struct{}
+
+// CallerFromContext returns the caller authenticated by its client
+// certificate.
+func CallerFromContext(ctx context.Context) (string, bool) {
+	caller, ok := ctx.Value(callerKey{}).(string)
+	return caller, ok
+}
+
+// IdentityInterceptor authenticates callers by their verified client
+// certificate. identities maps certificate identities to caller names; when
+// it is empty every verified certificate is accepted under its own identity,
+// otherwise calls from unlisted identities are rejected.
+func IdentityInterceptor(identities map[string]string, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ids := peerIdentities(ctx)
+		caller, ok := resolveCaller(ids, identities)
+		if !ok {
+			if len(identities) > 0 {
+				logger.Warn("caller rejected",
+					zap.Strings("identities", ids),
+					zap.String("method", info.FullMethod))
+				return nil, status.Error(codes.Unauthenticated, "client certificate required")
+			}
+			return handler(ctx, req)
+		}
+
+		logger.Debug("caller authenticated",
+			zap.String("caller", caller),
+			zap.String("method", info.FullMethod))
+		return handler(context.WithValue(ctx, callerKey{}, caller), req)
+	}
+}
+
+func resolveCaller(ids []string, identities map[string]string) (string, bool) {
+	if len(identities) == 0 {
+		if len(ids) == 0 {
+			return "", false
+		}
+		return ids[0], true
+	}
+	for _, id := range ids {
+		if caller, ok := identities[id]; ok {
+			return caller, true
+		}
+	}
+	return "", false
+}
+
+// peerIdentities lists the identities of the peer's verified certificate,
+// most specific first: SPIFFE IDs, then DNS names, then the common name.
+func peerIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return certIdentities(info.State.VerifiedChains[0][0])
+}
+
+func certIdentities(cert *x509.Certificate) []string {
+	var ids []string
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			ids = append(ids, uri.String())
+		}
+	}
+	ids = append(ids, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	return ids
+}
@@ -2,73 +2,539 @@ package grpc
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	neturl "net/url"
+	"strings"
+	"time"
 
 	"github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/blocklist"
 	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
 	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
-	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/logging"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/qr"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/api/httpbody"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// passwordMetadataKey carries the password of a protected short URL.
+const passwordMetadataKey = "x-link-password"
+
+// idempotencyMetadataKey carries the idempotency key of GenerateShortURL.
+const idempotencyMetadataKey = "idempotency-key"
+
+// maxIdempotencyKeyLen bounds idempotency keys; UUIDs and hashes fit easily.
+const maxIdempotencyKeyLen = 255
+
+// visitorMetadataKey identifies the visitor for sticky variants.
+const visitorMetadataKey = "x-visitor-id"
+
+var platforms = map[url.Platform]domain.Platform{
+	url.Platform_PLATFORM_UNSPECIFIED: "",
+	url.Platform_PLATFORM_IOS:         domain.PlatformIOS,
+	url.Platform_PLATFORM_ANDROID:     domain.PlatformAndroid,
+	url.Platform_PLATFORM_DESKTOP:     domain.PlatformDesktop,
+}
+
+var splitModes = map[url.SplitMode]domain.SplitMode{
+	url.SplitMode_SPLIT_MODE_UNSPECIFIED: "",
+	url.SplitMode_SPLIT_MODE_RANDOM:      domain.SplitRandom,
+	url.SplitMode_SPLIT_MODE_STICKY:      domain.SplitSticky,
+}
+
+var mergeModes = map[url.MergeMode]domain.MergeMode{
+	url.MergeMode_MERGE_MODE_UNSPECIFIED:      "",
+	url.MergeMode_MERGE_MODE_OVERRIDE:         domain.MergeOverride,
+	url.MergeMode_MERGE_MODE_KEEP_ORIGINAL:    domain.MergeKeepOriginal,
+	url.MergeMode_MERGE_MODE_REJECT_CONFLICTS: domain.MergeRejectConflicts,
+}
+
+var qrFormats = map[url.QRFormat]qr.Format{
+	url.QRFormat_QR_FORMAT_UNSPECIFIED: qr.FormatPNG,
+	url.QRFormat_QR_FORMAT_PNG:         qr.FormatPNG,
+	url.QRFormat_QR_FORMAT_SVG:         qr.FormatSVG,
+}
+
+var qrLevels = map[url.ErrorCorrection]qr.Level{
+	url.ErrorCorrection_ERROR_CORRECTION_UNSPECIFIED: qr.LevelMedium,
+	url.ErrorCorrection_ERROR_CORRECTION_LOW:         qr.LevelLow,
+	url.ErrorCorrection_ERROR_CORRECTION_MEDIUM:      qr.LevelMedium,
+	url.ErrorCorrection_ERROR_CORRECTION_QUARTILE:    qr.LevelQuartile,
+	url.ErrorCorrection_ERROR_CORRECTION_HIGH:        qr.LevelHigh,
+}
+
+var linkStatuses = map[domain.Status]url.LinkStatus{
+	domain.StatusActive:   url.LinkStatus_LINK_STATUS_ACTIVE,
+	domain.StatusDisabled: url.LinkStatus_LINK_STATUS_DISABLED,
+	domain.StatusDeleted:  url.LinkStatus_LINK_STATUS_DELETED,
+}
+
+// IdempotencyStore remembers responses by idempotency key.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key, fingerprint string) ([]byte, error)
+	Complete(ctx context.Context, key string, response []byte) error
+	Release(ctx context.Context, key string) error
+}
+
 type Handler struct {
 	url.UnimplementedShortenerServiceServer
-	ctrl   *controller.Controller
-	logger *zap.Logger
+	ctrl           *controller.Controller
+	idempotency    IdempotencyStore
+	webhooks       WebhookService
+	trustedProxies []netip.Prefix
+	logger         *zap.Logger
 }
 
-func New(ctrl *controller.Controller, logger *zap.Logger) *Handler {
-	return &Handler{
+type Option func(*Handler)
+
+// WithIdempotency makes GenerateShortURL replay the first response to
+// retries carrying the same idempotency key. Without it keys are ignored.
+func WithIdempotency(store IdempotencyStore) Option {
+	return func(h *Handler) {
+		h.idempotency = store
+	}
+}
+
+// WithTrustedProxies makes the handler take the client address from
+// x-forwarded-for when the call comes through one of proxies, such as
+// grpc-gateway. Without it the header is ignored.
+func WithTrustedProxies(proxies ...netip.Prefix) Option {
+	return func(h *Handler) {
+		h.trustedProxies = proxies
+	}
+}
+
+func New(ctrl *controller.Controller, logger *zap.Logger, opts ...Option) *Handler {
+	h := &Handler{
 		ctrl:   ctrl,
 		logger: logger,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func (h *Handler) GetOriginalURL(ctx context.Context, req *url.ShortURL) (*url.OriginalURL, error) {
 	if req == nil {
 		return nil, status.Error(codes.InvalidArgument, "Nil req")
 	}
-	h.logger.Info("got request", zap.Any("req", req))
+	h.logger.Info("got request", logging.URL("short_url", req.Url))
+
+	visit := h.visitFromContext(ctx)
+	host, code := domain.ParseShortLink(req.Url)
+	if host != "" {
+		visit.Host = host
+	}
+	if req.Query != "" {
+		query, err := neturl.ParseQuery(req.Query)
+		if err != nil {
+			return nil, invalidArgument("query", "invalid query")
+		}
+		visit.Query = query
+	}
 
-	urls, err := h.ctrl.Get(ctx, req.Url)
-	if errors.Is(err, repository.ErrURLNotFound) {
-		return nil, status.Error(codes.NotFound, "URL not found")
-	} else if err != nil {
-		h.logger.Error("failed to get url", zap.Error(err), zap.String("short_url", req.Url))
-		return nil, status.Error(codes.Internal, err.Error())
+	redirect, err := h.ctrl.Get(ctx, code, visit)
+	if err != nil {
+		return nil, h.fail(err, "failed to get url", logging.URL("short_url", req.Url))
 	}
 
-	return &url.OriginalURL{Url: urls.OriginalURL}, nil
+	return &url.OriginalURL{Url: redirect.Location, Variant: redirect.Variant}, nil
 }
 
 func (h *Handler) GenerateShortURL(ctx context.Context, req *url.GenerateShortURLRequest) (*url.URL, error) {
 	if req == nil {
 		return nil, status.Error(codes.InvalidArgument, "Nil req")
 	}
-	h.logger.Info("got request", zap.Any("req", req))
+	key, err := idempotencyKeyFromRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" || h.idempotency == nil {
+		return h.generateShortURL(ctx, req)
+	}
+
+	fingerprint, err := requestFingerprint(req)
+	if err != nil {
+		return nil, h.fail(err, "failed to fingerprint request")
+	}
+	stored, err := h.idempotency.Reserve(ctx, key, fingerprint)
+	if err != nil {
+		return nil, h.fail(err, "failed to reserve idempotency key")
+	}
+	if stored != nil {
+		replayed := &url.URL{}
+		if err := proto.Unmarshal(stored, replayed); err != nil {
+			return nil, h.fail(err, "failed to decode idempotent response")
+		}
+		h.logger.Info("replayed idempotent request", zap.String("code", replayed.Code))
+		return replayed, nil
+	}
+
+	// The outcome has to be recorded even when the caller gave up waiting,
+	// or its retry would find the key in progress.
+	recordCtx := context.WithoutCancel(ctx)
+	created, err := h.generateShortURL(ctx, req)
+	if err != nil {
+		_ = h.idempotency.Release(recordCtx, key)
+		return nil, err
+	}
+	response, err := proto.Marshal(created)
+	if err == nil {
+		err = h.idempotency.Complete(recordCtx, key, response)
+	}
+	if err != nil {
+		// The link exists, so it is returned; a retry would create another.
+		h.logger.Error("failed to record idempotent response", zap.Error(err), zap.String("code", created.Code))
+		_ = h.idempotency.Release(recordCtx, key)
+	}
+	return created, nil
+}
+
+func (h *Handler) generateShortURL(ctx context.Context, req *url.GenerateShortURLRequest) (*url.URL, error) {
+	h.logger.Info("got request",
+		logging.URL("original_url", req.OriginalUrl),
+		zap.Duration("ttl", req.Ttl.AsDuration()),
+		zap.Bool("password", req.Password != ""),
+		zap.Int64("max_clicks", req.MaxClicks),
+	)
+	if req.MaxClicks < 0 {
+		return nil, invalidArgument("max_clicks", "max_clicks cannot be negative")
+	}
 	domainURL := domain.NewURL(req.OriginalUrl)
+	domainURL.MaxClicks = req.MaxClicks
+	domainURL.Domain = req.Domain
+	domainURL.ShortURL = req.Alias
+	if req.NotBefore != nil {
+		if err := req.NotBefore.CheckValid(); err != nil {
+			return nil, invalidArgument("not_before", "invalid not_before")
+		}
+		domainURL.NotBefore = req.NotBefore.AsTime()
+	}
+	if req.ExpiresAt != nil {
+		if err := req.ExpiresAt.CheckValid(); err != nil {
+			return nil, invalidArgument("expires_at", "invalid expires_at")
+		}
+		domainURL.ExpiresAt = req.ExpiresAt.AsTime()
+	}
+	for _, rule := range req.Rules {
+		platform, ok := platforms[rule.Platform]
+		if !ok {
+			return nil, invalidArgument("rules", "unknown platform")
+		}
+		domainURL.Rules = append(domainURL.Rules, domain.Rule{
+			Platform:    platform,
+			Language:    rule.Language,
+			Destination: rule.Destination,
+		})
+	}
+	for _, variant := range req.Variants {
+		domainURL.Variants = append(domainURL.Variants, domain.Variant{
+			Name:        variant.Name,
+			Destination: variant.Destination,
+			Weight:      int(variant.Weight),
+		})
+	}
+	splitMode, ok := splitModes[req.SplitMode]
+	if !ok {
+		return nil, invalidArgument("split_mode", "unknown split mode")
+	}
+	domainURL.Split = splitMode
+	if opts := req.QueryOptions; opts != nil {
+		mergeMode, ok := mergeModes[opts.MergeMode]
+		if !ok {
+			return nil, invalidArgument("query_options.merge_mode", "unknown merge mode")
+		}
+		domainURL.Query = domain.QueryOptions{
+			Passthrough: opts.Passthrough,
+			Merge:       mergeMode,
+			UTM:         opts.Utm,
+		}
+	}
+	if req.Password != "" {
+		if err := domainURL.SetPassword(req.Password); err != nil {
+			return nil, invalidArgument("password", err.Error())
+		}
+	}
+	if caller, ok := CallerFromContext(ctx); ok {
+		domainURL.Owner = caller
+	}
 	err := h.ctrl.Save(ctx, domainURL, req.Ttl.AsDuration())
+	var blocked *blocklist.BlockedError
+	if errors.As(err, &blocked) {
+		h.logger.Warn("destination blocked",
+			logging.URL("original_url", req.OriginalUrl),
+			zap.String("reason", string(blocked.Reason)),
+			zap.String("rule", blocked.Rule))
+	}
 	if err != nil {
-		h.logger.Error("failed to get url", zap.Error(err), zap.String("original_url", req.OriginalUrl))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, h.fail(err, "failed to save url", logging.URL("original_url", req.OriginalUrl))
 	}
 
-	return &url.URL{OriginalUrl: domainURL.OriginalURL, ShortUrl: domainURL.ShortURL}, nil
+	return &url.URL{
+		ShortUrl:    h.ctrl.ShortLink(domainURL),
+		OriginalUrl: domainURL.OriginalURL,
+		Code:        domainURL.ShortURL,
+		Domain:      domainURL.Domain,
+	}, nil
+}
+
+func (h *Handler) DeleteShortURL(ctx context.Context, req *url.DeleteShortURLRequest) (*emptypb.Empty, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	h.logger.Info("got request", logging.URL("short_url", req.Url))
+
+	if req.ExpectedVersion < 0 {
+		return nil, invalidArgument("expected_version", "expected_version cannot be negative")
+	}
+	err := h.ctrl.Delete(ctx, shortCode(req.Url), domain.Precondition{
+		OriginalURL: req.ExpectedOriginalUrl,
+		Version:     req.ExpectedVersion,
+	})
+	if err != nil {
+		return nil, h.fail(err, "failed to delete url", logging.URL("short_url", req.Url))
+	}
+	return &emptypb.Empty{}, nil
 }
 
-func (h *Handler) DeleteShortURL(ctx context.Context, req *url.ShortURL) (*emptypb.Empty, error) {
+func (h *Handler) RestoreShortURL(ctx context.Context, req *url.ShortURL) (*emptypb.Empty, error) {
 	if req == nil {
 		return nil, status.Error(codes.InvalidArgument, "Nil req")
 	}
-	h.logger.Info("got request", zap.Any("req", req))
+	h.logger.Info("got request", logging.URL("short_url", req.Url))
 
-	err := h.ctrl.Delete(ctx, req.Url)
+	err := h.ctrl.Restore(ctx, shortCode(req.Url))
 	if err != nil {
-		h.logger.Error("failed to delete url", zap.Error(err), zap.String("short_url", req.Url))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, h.fail(err, "failed to restore url", logging.URL("short_url", req.Url))
 	}
 	return &emptypb.Empty{}, nil
 }
+
+func (h *Handler) DisableShortURL(ctx context.Context, req *url.ShortURL) (*emptypb.Empty, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	h.logger.Info("got request", logging.URL("short_url", req.Url))
+
+	return h.setStatus(ctx, shortCode(req.Url), h.ctrl.Disable)
+}
+
+func (h *Handler) EnableShortURL(ctx context.Context, req *url.ShortURL) (*emptypb.Empty, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	h.logger.Info("got request", logging.URL("short_url", req.Url))
+
+	return h.setStatus(ctx, shortCode(req.Url), h.ctrl.Enable)
+}
+
+func (h *Handler) setStatus(ctx context.Context, shortURL string, set func(context.Context, string) error) (*emptypb.Empty, error) {
+	err := set(ctx, shortURL)
+	if err != nil {
+		return nil, h.fail(err, "failed to change url status", zap.String("short_url", shortURL))
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *Handler) GetQRCode(ctx context.Context, req *url.GetQRCodeRequest) (*httpbody.HttpBody, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	h.logger.Info("got request", logging.URL("short_url", req.Url))
+
+	opts := qr.DefaultOptions()
+	var ok bool
+	if opts.Format, ok = qrFormats[req.Format]; !ok {
+		return nil, invalidArgument("format", "unknown format")
+	}
+	if opts.Level, ok = qrLevels[req.ErrorCorrection]; !ok {
+		return nil, invalidArgument("error_correction", "unknown error correction level")
+	}
+	if req.Size != 0 {
+		opts.Size = int(req.Size)
+	}
+	if req.Margin != nil {
+		opts.Margin = int(*req.Margin)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, h.fail(err, "invalid qr code options")
+	}
+
+	host, code := domain.ParseShortLink(req.Url)
+	if host == "" {
+		host = h.visitFromContext(ctx).Host
+	}
+	shortURL, err := h.ctrl.Lookup(ctx, code, host)
+	if err != nil {
+		return nil, h.fail(err, "failed to get url", logging.URL("short_url", req.Url))
+	}
+
+	image, err := qr.Render(h.ctrl.ShortLink(shortURL), opts)
+	if err != nil {
+		return nil, h.fail(err, "failed to render qr code", logging.URL("short_url", req.Url))
+	}
+	return &httpbody.HttpBody{ContentType: opts.Format.ContentType(), Data: image}, nil
+}
+
+func (h *Handler) GetURLStats(ctx context.Context, req *url.ShortURL) (*url.URLStats, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	h.logger.Info("got request", logging.URL("short_url", req.Url))
+
+	shortURL, err := h.ctrl.Inspect(ctx, shortCode(req.Url))
+	if err != nil {
+		return nil, h.fail(err, "failed to get url", logging.URL("short_url", req.Url))
+	}
+
+	stats := &url.URLStats{
+		ShortUrl:          h.ctrl.ShortLink(shortURL),
+		Code:              shortURL.ShortURL,
+		Domain:            shortURL.Domain,
+		Status:            linkStatuses[shortURL.Status],
+		MaxClicks:         shortURL.MaxClicks,
+		ClicksLeft:        shortURL.ClicksLeft,
+		PasswordProtected: shortURL.HasPassword(),
+		Rules:             int32(len(shortURL.Rules)),
+		Variants:          int32(len(shortURL.Variants)),
+		Version:           shortURL.Version,
+	}
+	if destinationVisible(ctx, shortURL, time.Now()) {
+		stats.OriginalUrl = shortURL.OriginalURL
+	}
+	if !shortURL.NotBefore.IsZero() {
+		stats.NotBefore = timestamppb.New(shortURL.NotBefore)
+	}
+	if !shortURL.ExpiresAt.IsZero() {
+		stats.ExpiresAt = timestamppb.New(shortURL.ExpiresAt)
+	}
+	return stats, nil
+}
+
+// destinationVisible reports whether GetURLStats may tell where u goes: to
+// its owner always, to anyone else only while u resolves without a password,
+// when GetOriginalURL would tell them anyway.
+func destinationVisible(ctx context.Context, u *domain.URL, now time.Time) bool {
+	if caller, ok := CallerFromContext(ctx); ok && caller == u.Owner {
+		return true
+	}
+	return u.Status == domain.StatusActive && !u.HasPassword() && u.IsActive(now)
+}
+
+// visitFromContext reads the visit from request metadata. Headers forwarded
+// by grpc-gateway take precedence over the caller's own gRPC headers; the
+// host is only known when forwarded.
+func (h *Handler) visitFromContext(ctx context.Context) domain.Visit {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return domain.Visit{
+		Password:       firstValue(md, passwordMetadataKey),
+		UserAgent:      firstValue(md, "grpcgateway-user-agent", "user-agent"),
+		AcceptLanguage: firstValue(md, "grpcgateway-accept-language", "accept-language"),
+		VisitorID:      firstValue(md, visitorMetadataKey),
+		Host:           firstValue(md, "x-forwarded-host"),
+		ClientIP:       h.clientIP(ctx, md),
+	}
+}
+
+// clientIP returns the address the call came from. That is the peer, unless
+// the peer is a trusted proxy: then it is the right-most hop in
+// x-forwarded-for that is not a trusted proxy, since hops to its left are
+// whatever the client chose to send.
+func (h *Handler) clientIP(ctx context.Context, md metadata.MD) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	hops := strings.Split(strings.Join(md.Get("x-forwarded-for"), ","), ",")
+	for i := len(hops) - 1; i >= 0 && h.trustedProxy(ip); i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			break
+		}
+		ip = hop
+	}
+	return ip
+}
+
+// trustedProxy reports whether ip is the address of a trusted proxy.
+func (h *Handler) trustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, proxy := range h.trustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// idempotencyKeyFromRequest reads the idempotency key from the request or its
+// metadata. Keys are scoped to the authenticated caller, if any.
+func idempotencyKeyFromRequest(ctx context.Context, req *url.GenerateShortURLRequest) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := req.IdempotencyKey
+	if fromMetadata := firstValue(md, idempotencyMetadataKey); fromMetadata != "" {
+		if key != "" && key != fromMetadata {
+			return "", invalidArgument("idempotency_key", "idempotency_key and idempotency-key metadata differ")
+		}
+		key = fromMetadata
+	}
+	if key == "" {
+		return "", nil
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		return "", invalidArgument("idempotency_key", fmt.Sprintf("idempotency key is longer than %d bytes", maxIdempotencyKeyLen))
+	}
+	if caller, ok := CallerFromContext(ctx); ok {
+		key = caller + ":" + key
+	}
+	return key, nil
+}
+
+// requestFingerprint identifies the request a key was used for.
+func requestFingerprint(req *url.GenerateShortURLRequest) (string, error) {
+	unkeyed := proto.Clone(req).(*url.GenerateShortURLRequest)
+	unkeyed.IdempotencyKey = ""
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(unkeyed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// shortCode accepts a bare code or a full short link and returns the code.
+func shortCode(link string) string {
+	_, code := domain.ParseShortLink(link)
+	return code
+}
+
+func firstValue(md metadata.MD, keys ...string) string {
+	for _, key := range keys {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/blocklist"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxDeliveries bounds ListWebhookDeliveries responses.
+const maxDeliveries = 100
+
+var webhookEvents = map[url.WebhookEvent]domain.WebhookEvent{
+	url.WebhookEvent_WEBHOOK_EVENT_CREATED: domain.WebhookCreated,
+	url.WebhookEvent_WEBHOOK_EVENT_DELETED: domain.WebhookDeleted,
+	url.WebhookEvent_WEBHOOK_EVENT_VISITED: domain.WebhookVisited,
+	url.WebhookEvent_WEBHOOK_EVENT_EXPIRED: domain.WebhookExpired,
+}
+
+var deliveryStatuses = map[domain.DeliveryStatus]url.DeliveryStatus{
+	domain.DeliveryPending:   url.DeliveryStatus_DELIVERY_STATUS_PENDING,
+	domain.DeliveryDelivered: url.DeliveryStatus_DELIVERY_STATUS_DELIVERED,
+	domain.DeliveryDead:      url.DeliveryStatus_DELIVERY_STATUS_DEAD,
+}
+
+// WebhookService manages the webhooks of link owners.
+type WebhookService interface {
+	Register(ctx context.Context, owner, endpoint string, events []domain.WebhookEvent) (*domain.Webhook, error)
+	Webhooks(ctx context.Context, owner string) ([]*domain.Webhook, error)
+	Delete(ctx context.Context, owner, id string) error
+	Deliveries(ctx context.Context, owner, id string, deadOnly bool, limit int64) ([]*domain.Delivery, error)
+}
+
+// WithWebhooks enables the webhook RPCs. Webhooks belong to the caller, so
+// the RPCs are refused to callers without a client certificate.
+func WithWebhooks(webhooks WebhookService) Option {
+	return func(h *Handler) {
+		h.webhooks = webhooks
+	}
+}
+
+func (h *Handler) RegisterWebhook(ctx context.Context, req *url.RegisterWebhookRequest) (*url.Webhook, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	owner, err := h.webhookOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.logger.Info("got request", logging.URL("url", req.Url))
+
+	events := make([]domain.WebhookEvent, 0, len(req.Events))
+	for _, event := range req.Events {
+		webhookEvent, ok := webhookEvents[event]
+		if !ok {
+			return nil, invalidArgument("events", "unknown event")
+		}
+		events = append(events, webhookEvent)
+	}
+
+	webhook, err := h.webhooks.Register(ctx, owner, req.Url, events)
+	var blocked *blocklist.BlockedError
+	if errors.As(err, &blocked) {
+		return nil, newStatus(codes.InvalidArgument, "endpoint is blocked: "+string(blocked.Reason),
+			errorInfo(string(blocked.Reason)),
+			fieldViolation("url", blocked.Error()))
+	}
+	if err != nil {
+		return nil, h.fail(err, "failed to register webhook", logging.URL("url", req.Url))
+	}
+
+	resp := webhookToProto(webhook)
+	resp.Secret = webhook.Secret
+	return resp, nil
+}
+
+func (h *Handler) ListWebhooks(ctx context.Context, _ *url.ListWebhooksRequest) (*url.ListWebhooksResponse, error) {
+	owner, err := h.webhookOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := h.webhooks.Webhooks(ctx, owner)
+	if err != nil {
+		return nil, h.fail(err, "failed to list webhooks")
+	}
+
+	resp := &url.ListWebhooksResponse{}
+	for _, webhook := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, webhookToProto(webhook))
+	}
+	return resp, nil
+}
+
+func (h *Handler) DeleteWebhook(ctx context.Context, req *url.DeleteWebhookRequest) (*emptypb.Empty, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	owner, err := h.webhookOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.logger.Info("got request", zap.String("webhook_id", req.Id))
+
+	if err := h.webhooks.Delete(ctx, owner, req.Id); err != nil {
+		return nil, h.fail(err, "failed to delete webhook", zap.String("webhook_id", req.Id))
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *Handler) ListWebhookDeliveries(ctx context.Context, req *url.ListWebhookDeliveriesRequest) (*url.ListWebhookDeliveriesResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "Nil req")
+	}
+	owner, err := h.webhookOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit < 0 {
+		return nil, invalidArgument("limit", "limit cannot be negative")
+	}
+	limit := int64(req.Limit)
+	if limit == 0 || limit > maxDeliveries {
+		limit = maxDeliveries
+	}
+
+	deliveries, err := h.webhooks.Deliveries(ctx, owner, req.WebhookId, req.DeadLettersOnly, limit)
+	if err != nil {
+		return nil, h.fail(err, "failed to list webhook deliveries", zap.String("webhook_id", req.WebhookId))
+	}
+
+	resp := &url.ListWebhookDeliveriesResponse{}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, deliveryToProto(delivery))
+	}
+	return resp, nil
+}
+
+var (
+	errWebhooksDisabled = status.Error(codes.Unimplemented, "webhooks are disabled")
+	errNoCaller         = status.Error(codes.Unauthenticated, "client certificate required")
+)
+
+// webhookOwner returns the caller, who owns the webhooks the RPCs manage.
+func (h *Handler) webhookOwner(ctx context.Context) (string, error) {
+	if h.webhooks == nil {
+		return "", errWebhooksDisabled
+	}
+	owner, ok := CallerFromContext(ctx)
+	if !ok {
+		return "", errNoCaller
+	}
+	return owner, nil
+}
+
+func webhookToProto(webhook *domain.Webhook) *url.Webhook {
+	resp := &url.Webhook{
+		Id:        webhook.ID,
+		Url:       webhook.URL,
+		CreatedAt: timestamppb.New(webhook.CreatedAt),
+	}
+	for _, event := range webhook.Events {
+		for protoEvent, domainEvent := range webhookEvents {
+			if domainEvent == event {
+				resp.Events = append(resp.Events, protoEvent)
+			}
+		}
+	}
+	return resp
+}
+
+func deliveryToProto(delivery *domain.Delivery) *url.WebhookDelivery {
+	resp := &url.WebhookDelivery{
+		Id:             delivery.ID,
+		ShortUrl:       delivery.ShortURL,
+		Status:         deliveryStatuses[delivery.Status],
+		Attempts:       int32(delivery.Attempts),
+		LastStatusCode: int32(delivery.LastStatusCode),
+		LastError:      delivery.LastError,
+		CreatedAt:      timestamppb.New(delivery.CreatedAt),
+		UpdatedAt:      timestamppb.New(delivery.UpdatedAt),
+	}
+	for protoEvent, domainEvent := range webhookEvents {
+		if domainEvent == delivery.Event {
+			resp.Event = protoEvent
+		}
+	}
+	if delivery.Status == domain.DeliveryPending {
+		resp.NextAttemptAt = timestamppb.New(delivery.NextAttemptAt)
+	}
+	return resp
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/qr"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Password required</title></head>
+<body>
+<form method="post">
+<p>This link is password protected.</p>
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+<input type="password" name="password" autofocus>
+<button type="submit">Open</button>
+</form>
+</body>
+</html>
+`))
+
+var notActivePage = template.Must(template.New("not_active").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Link not active yet</title></head>
+<body>
+<p>This link is not active yet. Please try again later.</p>
+</body>
+</html>
+`))
+
+var gonePage = template.Must(template.New("gone").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Link unavailable</title></head>
+<body>
+<p>This link is no longer available.</p>
+</body>
+</html>
+`))
+
+// visitorCookie keeps the visitor ID sticky variants are chosen by.
+const (
+	visitorCookie       = "visitor_id"
+	visitorCookieMaxAge = 365 * 24 * time.Hour
+)
+
+// Handler redirects browsers hitting a short URL to its original URL.
+type Handler struct {
+	ctrl   *controller.Controller
+	logger *zap.Logger
+	mux    *http.ServeMux
+}
+
+func New(ctrl *controller.Controller, logger *zap.Logger) *Handler {
+	h := &Handler{
+		ctrl:   ctrl,
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /{url}", h.redirect)
+	h.mux.HandleFunc("POST /{url}", h.redirect)
+	h.mux.HandleFunc("GET /v1/{url}/qr", h.qrCode)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) redirect(w http.ResponseWriter, r *http.Request) {
+	shortURL := r.PathValue("url")
+	visitorID, newVisitor := visitorIDFromRequest(r)
+	visit := domain.Visit{
+		UserAgent:      r.UserAgent(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		VisitorID:      visitorID,
+		Query:          r.URL.Query(),
+		Host:           r.Host,
+		ClientIP:       clientIP(r),
+	}
+	if r.Method == http.MethodPost {
+		visit.Password = r.PostFormValue("password")
+	}
+
+	redirect, err := h.ctrl.Get(r.Context(), shortURL, visit)
+	switch {
+	case errors.Is(err, repository.ErrURLNotFound), errors.Is(err, domain.ErrInvalidCode):
+		http.NotFound(w, r)
+	case errors.Is(err, controller.ErrURLDisabled), errors.Is(err, controller.ErrURLDeleted):
+		h.render(w, gonePage, http.StatusGone, nil)
+	case errors.Is(err, domain.ErrQueryConflict):
+		http.Error(w, "conflicting query parameters", http.StatusBadRequest)
+	case errors.Is(err, controller.ErrNotYetActive):
+		h.render(w, notActivePage, http.StatusForbidden, nil)
+	case errors.Is(err, controller.ErrPasswordRequired):
+		h.render(w, passwordPage, http.StatusOK, passwordPageData{})
+	case errors.Is(err, controller.ErrInvalidPassword):
+		h.render(w, passwordPage, http.StatusForbidden, passwordPageData{Error: "Invalid password."})
+	case errors.Is(err, controller.ErrTooManyAttempts):
+		http.Error(w, "too many failed password attempts", http.StatusTooManyRequests)
+	case err != nil:
+		h.logger.Error("failed to get url", zap.Error(err), zap.String("short_url", shortURL))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	default:
+		if len(redirect.URL.Rules) > 0 {
+			w.Header().Set("Vary", "User-Agent, Accept-Language")
+		}
+		if len(redirect.URL.Variants) > 0 {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		if newVisitor && redirect.URL.IsSticky() {
+			http.SetCookie(w, &http.Cookie{
+				Name:     visitorCookie,
+				Value:    visitorID,
+				Path:     "/",
+				MaxAge:   int(visitorCookieMaxAge.Seconds()),
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		http.Redirect(w, r, redirect.Location, http.StatusFound)
+	}
+}
+
+// qrCode renders the short link as a QR code. The format, size, level and
+// margin query parameters override the defaults.
+func (h *Handler) qrCode(w http.ResponseWriter, r *http.Request) {
+	shortURL := r.PathValue("url")
+	opts, err := qrOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	url, err := h.ctrl.Lookup(r.Context(), shortURL, r.Host)
+	switch {
+	case errors.Is(err, repository.ErrURLNotFound), errors.Is(err, domain.ErrInvalidCode),
+		errors.Is(err, controller.ErrURLDeleted):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		h.logger.Error("failed to get url", zap.Error(err), zap.String("short_url", shortURL))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	image, err := qr.Render(h.ctrl.ShortLink(url), opts)
+	if err != nil {
+		h.logger.Error("failed to render qr code", zap.Error(err), zap.String("short_url", shortURL))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	_, _ = w.Write(image)
+}
+
+func qrOptionsFromQuery(query url.Values) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+	if format := query.Get("format"); format != "" {
+		opts.Format = qr.Format(format)
+	}
+	if level := query.Get("level"); level != "" {
+		opts.Level = qr.Level(level)
+	}
+	var err error
+	if size := query.Get("size"); size != "" {
+		if opts.Size, err = strconv.Atoi(size); err != nil {
+			return opts, fmt.Errorf("%w: invalid size", qr.ErrInvalidOptions)
+		}
+	}
+	if margin := query.Get("margin"); margin != "" {
+		if opts.Margin, err = strconv.Atoi(margin); err != nil {
+			return opts, fmt.Errorf("%w: invalid margin", qr.ErrInvalidOptions)
+		}
+	}
+	return opts, opts.Validate()
+}
+
+// visitorIDFromRequest returns the visitor ID from the cookie, or a new one.
+func visitorIDFromRequest(r *http.Request) (string, bool) {
+	if cookie, err := r.Cookie(visitorCookie); err == nil && cookie.Value != "" {
+		return cookie.Value, false
+	}
+	return rand.Text(), true
+}
+
+type passwordPageData struct {
+	Error string
+}
+
+func (h *Handler) render(w http.ResponseWriter, page *template.Template, code int, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := page.Execute(w, data); err != nil {
+		h.logger.Error("failed to render page", zap.Error(err), zap.String("page", page.Name()))
+	}
+}
+
+// clientIP returns the address of the peer that sent r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const retryDelay = time.Second
+
+type Invalidator interface {
+	Invalidate(shortURL string)
+}
+
+// CacheInvalidator consumes URL lifecycle events and drops the affected
+// entries from the local cache, so that replicas do not keep serving links
+// removed elsewhere.
+type CacheInvalidator struct {
+	reader *kafka.Reader
+	cache  Invalidator
+	logger *zap.Logger
+}
+
+func NewCacheInvalidator(reader *kafka.Reader, cache Invalidator, logger *zap.Logger) *CacheInvalidator {
+	return &CacheInvalidator{
+		reader: reader,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// Run reads events until ctx is cancelled.
+func (c *CacheInvalidator) Run(ctx context.Context) {
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("failed to read event", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		var shortURL string
+		switch string(msg.Key) {
+		case "url_deleted":
+			shortURL = string(msg.Value)
+		case "url_exhausted", "url_disabled", "url_enabled", "url_restored":
+			var event struct {
+				ShortURL string `json:"short_url"`
+			}
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				c.logger.Warn("invalid event", zap.Error(err), zap.ByteString("key", msg.Key))
+				continue
+			}
+			shortURL = event.ShortURL
+		default:
+			continue
+		}
+
+		c.cache.Invalidate(shortURL)
+		c.logger.Debug("cache entry invalidated",
+			zap.String("short_url", shortURL))
+	}
+}
@@ -0,0 +1,34 @@
+// Package logging keeps sensitive data out of logs.
+package logging
+
+import (
+	"net/url"
+
+	"go.uber.org/zap"
+)
+
+// invalidURL replaces values that do not parse as URLs.
+const invalidURL = "<invalid url>"
+
+// URL logs raw with RedactURL applied.
+func URL(key, raw string) zap.Field {
+	return zap.String(key, RedactURL(raw))
+}
+
+// RedactURL strips the query string, fragment and password from raw, where
+// tokens and other secrets usually end up.
+func RedactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return invalidURL
+	}
+	u.RawQuery, u.ForceQuery = "", false
+	u.Fragment, u.RawFragment = "", ""
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
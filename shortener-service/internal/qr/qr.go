@@ -0,0 +1,154 @@
+// Package qr renders QR codes for short links.
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var ErrInvalidOptions = errors.New("invalid qr code options")
+
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// Level is the error correction level; higher levels survive more damage at
+// the cost of denser codes.
+type Level string
+
+const (
+	LevelLow      Level = "low"
+	LevelMedium   Level = "medium"
+	LevelQuartile Level = "quartile"
+	LevelHigh     Level = "high"
+)
+
+var levels = map[Level]qrcode.RecoveryLevel{
+	LevelLow:      qrcode.Low,
+	LevelMedium:   qrcode.Medium,
+	LevelQuartile: qrcode.High,
+	LevelHigh:     qrcode.Highest,
+}
+
+const (
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+type Options struct {
+	Format Format
+	// Size is the width and height of the image in pixels. PNG images are
+	// rounded down to a whole number of pixels per module.
+	Size  int
+	Level Level
+	// Margin is the width of the quiet zone around the code, in modules.
+	Margin int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Format: FormatPNG,
+		Size:   256,
+		Level:  LevelMedium,
+		Margin: 4,
+	}
+}
+
+func (o Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidOptions, o.Format)
+	}
+	if _, ok := levels[o.Level]; !ok {
+		return fmt.Errorf("%w: unknown error correction level %q", ErrInvalidOptions, o.Level)
+	}
+	if o.Size <= 0 || o.Size > MaxSize {
+		return fmt.Errorf("%w: size must be between 1 and %d", ErrInvalidOptions, MaxSize)
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return fmt.Errorf("%w: margin must be between 0 and %d", ErrInvalidOptions, MaxMargin)
+	}
+	return nil
+}
+
+// ContentType returns the media type of images in the format.
+func (f Format) ContentType() string {
+	if f == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Render encodes content as a QR code image.
+func Render(content string, opts Options) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, levels[opts.Level])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	if opts.Format == FormatSVG {
+		return renderSVG(modules, opts), nil
+	}
+	return renderPNG(modules, opts)
+}
+
+func renderPNG(modules [][]bool, opts Options) ([]byte, error) {
+	total := len(modules) + 2*opts.Margin
+	scale := max(opts.Size/total, 1)
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, total*scale, total*scale),
+		color.Palette{color.White, color.Black},
+	)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			px, py := (x+opts.Margin)*scale, (y+opts.Margin)*scale
+			for dy := range scale {
+				for dx := range scale {
+					img.SetColorIndex(px+dx, py+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderSVG(modules [][]bool, opts Options) []byte {
+	total := len(modules) + 2*opts.Margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, total, total)
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// Backend is the storage CachedURLRepo reads through to.
+type Backend interface {
+	Save(ctx context.Context, url *domain.URL, expTime time.Duration) error
+	Get(ctx context.Context, shortURL string) (*domain.URL, error)
+	Delete(ctx context.Context, shortURL string, retention time.Duration, cond domain.Precondition) error
+	Restore(ctx context.Context, shortURL string) error
+	SetStatus(ctx context.Context, shortURL string, status domain.Status) error
+	TTL(ctx context.Context, shortURL string) (time.Duration, error)
+	ConsumeClick(ctx context.Context, shortURL string, retention time.Duration) (int64, error)
+}
+
+// loadTimeout bounds a backend load shared by concurrent misses.
+const loadTimeout = 5 * time.Second
+
+type cacheEntry struct {
+	url       domain.URL
+	expiresAt time.Time
+}
+
+// CachedURLRepo keeps recently resolved URLs in a bounded in-process LRU in
+// front of a Backend. Entries never outlive the URL's remaining TTL, and
+// concurrent misses on the same short URL share a single backend call.
+type CachedURLRepo struct {
+	backend Backend
+	entries *lru.Cache[string, cacheEntry]
+	group   singleflight.Group
+	maxTTL  time.Duration
+	// generation is bumped on every invalidation so that loads started before
+	// it do not put stale entries back into the cache.
+	generation atomic.Uint64
+	logger     *zap.Logger
+}
+
+func NewCachedURLRepo(backend Backend, size int, maxTTL time.Duration, logger *zap.Logger) (*CachedURLRepo, error) {
+	entries, err := lru.New[string, cacheEntry](size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lru cache: %w", err)
+	}
+
+	return &CachedURLRepo{
+		backend: backend,
+		entries: entries,
+		maxTTL:  maxTTL,
+		logger:  logger,
+	}, nil
+}
+
+func (r *CachedURLRepo) Save(ctx context.Context, url *domain.URL, expTime time.Duration) error {
+	if err := r.backend.Save(ctx, url, expTime); err != nil {
+		return err
+	}
+	r.Invalidate(url.ShortURL)
+	return nil
+}
+
+func (r *CachedURLRepo) Get(ctx context.Context, shortURL string) (*domain.URL, error) {
+	if shortURL == "" {
+		return nil, ErrShortURLEmpty
+	}
+
+	if entry, ok := r.entries.Get(shortURL); ok {
+		if time.Now().Before(entry.expiresAt) {
+			url := entry.url
+			return &url, nil
+		}
+		r.entries.Remove(shortURL)
+	}
+
+	results := r.group.DoChan(shortURL, func() (any, error) {
+		// The load is shared, so the caller that started it must not cancel
+		// it for everyone else.
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return r.load(loadCtx, shortURL)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-results:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		url := res.Val.(domain.URL)
+		return &url, nil
+	}
+}
+
+// GetUncached reads shortURL from the backend, for callers that need current
+// values such as the clicks left.
+func (r *CachedURLRepo) GetUncached(ctx context.Context, shortURL string) (*domain.URL, error) {
+	return r.backend.Get(ctx, shortURL)
+}
+
+func (r *CachedURLRepo) Delete(ctx context.Context, shortURL string, retention time.Duration, cond domain.Precondition) error {
+	err := r.backend.Delete(ctx, shortURL, retention, cond)
+	r.Invalidate(shortURL)
+	return err
+}
+
+func (r *CachedURLRepo) Restore(ctx context.Context, shortURL string) error {
+	err := r.backend.Restore(ctx, shortURL)
+	r.Invalidate(shortURL)
+	return err
+}
+
+func (r *CachedURLRepo) SetStatus(ctx context.Context, shortURL string, status domain.Status) error {
+	err := r.backend.SetStatus(ctx, shortURL, status)
+	r.Invalidate(shortURL)
+	return err
+}
+
+func (r *CachedURLRepo) TTL(ctx context.Context, shortURL string) (time.Duration, error) {
+	return r.backend.TTL(ctx, shortURL)
+}
+
+// ConsumeClick always goes to the backend, since click counts must be exact;
+// the cached entry is dropped once the URL is used up.
+func (r *CachedURLRepo) ConsumeClick(ctx context.Context, shortURL string, retention time.Duration) (int64, error) {
+	left, err := r.backend.ConsumeClick(ctx, shortURL, retention)
+	if (err == nil && left == 0) || errors.Is(err, ErrURLNotFound) || errors.Is(err, ErrURLExhausted) {
+		r.Invalidate(shortURL)
+	}
+	return left, err
+}
+
+// Invalidate drops the cached entry for shortURL, if any.
+func (r *CachedURLRepo) Invalidate(shortURL string) {
+	r.generation.Add(1)
+	r.group.Forget(shortURL)
+	r.entries.Remove(shortURL)
+}
+
+func (r *CachedURLRepo) load(ctx context.Context, shortURL string) (domain.URL, error) {
+	generation := r.generation.Load()
+
+	url, err := r.backend.Get(ctx, shortURL)
+	if err != nil {
+		return domain.URL{}, err
+	}
+
+	ttl, err := r.backend.TTL(ctx, shortURL)
+	if errors.Is(err, ErrURLNotFound) {
+		return *url, nil
+	} else if err != nil {
+		r.logger.Warn("failed to get ttl, skipping cache",
+			zap.String("short_url", shortURL),
+			zap.Error(err))
+		return *url, nil
+	}
+
+	if ttl == 0 || ttl > r.maxTTL {
+		ttl = r.maxTTL
+	}
+
+	if r.generation.Load() == generation {
+		r.entries.Add(shortURL, cacheEntry{
+			url:       *url,
+			expiresAt: time.Now().Add(ttl),
+		})
+	}
+	return *url, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrIdempotencyMismatch   = errors.New("idempotency key was used for a different request")
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+const (
+	fieldFingerprint = "fingerprint"
+	fieldResponse    = "response"
+)
+
+// RedisIdempotencyStore remembers the responses of requests by idempotency
+// key, so retries of a request get the first response back.
+type RedisIdempotencyStore struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+func NewRedisIdempotencyStore(client redis.UniversalClient, ttl time.Duration, logger *zap.Logger) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{
+		client: client,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Reserve claims key for the request identified by fingerprint. It returns
+// nil when the caller holds the key and should run the request, and the
+// stored response when the same request already finished.
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string) ([]byte, error) {
+	redisKey := idempotencyKey(key)
+	var claimed *redis.BoolCmd
+	var stored *redis.SliceCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		claimed = pipe.HSetNX(ctx, redisKey, fieldFingerprint, fingerprint)
+		pipe.ExpireNX(ctx, redisKey, s.ttl)
+		stored = pipe.HMGet(ctx, redisKey, fieldFingerprint, fieldResponse)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to reserve idempotency key", zap.Error(err))
+		return nil, err
+	}
+	if claimed.Val() {
+		return nil, nil
+	}
+
+	values := stored.Val()
+	if values[0] != fingerprint {
+		return nil, ErrIdempotencyMismatch
+	}
+	response, _ := values[1].(string)
+	if response == "" {
+		return nil, ErrIdempotencyInProgress
+	}
+	return []byte(response), nil
+}
+
+// Complete stores the response of the request holding key for replays, for
+// the full TTL from now.
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, response []byte) error {
+	redisKey := idempotencyKey(key)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisKey, fieldResponse, response)
+		pipe.Expire(ctx, redisKey, s.ttl)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to store idempotent response", zap.Error(err))
+	}
+	return err
+}
+
+// Release gives up key after a failed request, so it can be retried.
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	err := s.client.Del(ctx, idempotencyKey(key)).Err()
+	if err != nil {
+		s.logger.Error("failed to release idempotency key", zap.Error(err))
+	}
+	return err
+}
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// RedisFailureLimiter counts password attempts per short URL and client in
+// fixed windows and stops allowing attempts once the limit is reached. An
+// attempt is counted before the password is checked, so concurrent guesses
+// cannot get past the limit.
+type RedisFailureLimiter struct {
+	client redis.UniversalClient
+	limit  int64
+	window time.Duration
+	logger *zap.Logger
+}
+
+func NewRedisFailureLimiter(client redis.UniversalClient, limit int64, window time.Duration, logger *zap.Logger) *RedisFailureLimiter {
+	return &RedisFailureLimiter{
+		client: client,
+		limit:  limit,
+		window: window,
+		logger: logger,
+	}
+}
+
+func (l *RedisFailureLimiter) Allow(ctx context.Context, shortURL, client string) (bool, error) {
+	attempts, err := attemptScript.Run(ctx, l.client,
+		[]string{attemptsKey(shortURL, client)}, l.window.Milliseconds()).Int64()
+	if err != nil {
+		l.logger.Error("failed to count password attempt",
+			zap.String("short_url", shortURL),
+			zap.Error(err))
+		return false, err
+	}
+	return attempts <= l.limit, nil
+}
+
+func (l *RedisFailureLimiter) Reset(ctx context.Context, shortURL, client string) error {
+	err := l.client.Del(ctx, attemptsKey(shortURL, client)).Err()
+	if err != nil {
+		l.logger.Error("failed to reset password attempts",
+			zap.String("short_url", shortURL),
+			zap.Error(err))
+	}
+	return err
+}
+
+func attemptsKey(shortURL, client string) string {
+	return "{" + shortURL + "}:password_attempts:" + client
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
@@ -12,17 +15,49 @@ import (
 
 var (
 	ErrURLNotFound      = errors.New("url not found")
+	ErrURLExists        = errors.New("url already exists")
+	ErrURLNotDeleted    = errors.New("url is not deleted")
+	ErrURLExpired       = errors.New("url has expired")
+	ErrURLExhausted     = errors.New("url has no clicks left")
+	ErrURLChanged       = errors.New("url does not match the precondition")
 	ErrURLNil           = errors.New("url cannot be nil")
 	ErrShortURLEmpty    = errors.New("shortURL cannot be empty")
 	ErrOriginalURLEmpty = errors.New("originalURL cannot be empty")
 )
 
+const (
+	fieldOriginalURL  = "original_url"
+	fieldPasswordHash = "password_hash"
+	fieldMaxClicks    = "max_clicks"
+	fieldClicksLeft   = "clicks_left"
+	fieldNotBefore    = "not_before"
+	fieldExpiresAt    = "expires_at"
+	fieldStatus       = "status"
+	fieldDeletedAt    = "deleted_at"
+	fieldRules        = "rules"
+	fieldVariants     = "variants"
+	fieldSplit        = "split"
+	fieldQuery        = "query"
+	fieldDomain       = "domain"
+	fieldVersion      = "version"
+	fieldOwner        = "owner"
+)
+
+// RedisURLRepo stores every URL as a hash under "url:{abc123}", so that no
+// code names a key the service keeps for anything else. It works with
+// standalone, Sentinel and Cluster clients alike; any key derived from a short
+// URL must wrap it in a hash tag ("{abc123}:suffix") so that it lands in the
+// same cluster slot and can be used together with it in one operation.
+//
+// URLs used to be kept under their bare code. Such URLs are still read, and
+// are moved to their own key the first time they are changed; bare keys that
+// do not hold a URL are never touched.
 type RedisURLRepo struct {
-	client *redis.Client
+	client redis.UniversalClient
 	logger *zap.Logger
 }
 
-func NewRedisURLRepo(client *redis.Client, logger *zap.Logger) *RedisURLRepo {
+func NewRedisURLRepo(client redis.UniversalClient, logger *zap.Logger) *RedisURLRepo {
 	return &RedisURLRepo{
 		client: client,
 		logger: logger,
@@ -41,12 +76,18 @@ func (r *RedisURLRepo) Save(ctx context.Context, url *domain.URL, expTime time.D
 		return ErrOriginalURLEmpty
 	}
 
-	if err := r.client.Set(ctx, url.ShortURL, url.OriginalURL, expTime).Err(); err != nil {
+	args := append([]any{expTime.Milliseconds()}, urlFields(url)...)
+	keys := []string{urlKey(url.ShortURL), url.ShortURL}
+	created, err := saveScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
 		r.logger.Error("failed to save url",
 			zap.String("short_url", url.ShortURL),
 			zap.Error(err))
 		return err
 	}
+	if created == 0 {
+		return ErrURLExists
+	}
 
 	r.logger.Debug("url saved successfully",
 		zap.String("short_url", url.ShortURL))
@@ -58,41 +99,345 @@ func (r *RedisURLRepo) Get(ctx context.Context, shortURL string) (*domain.URL, e
 		return nil, ErrShortURLEmpty
 	}
 
-	originalURL, err := r.client.Get(ctx, shortURL).Result()
+	fields, err := r.client.HGetAll(ctx, urlKey(shortURL)).Result()
+	if err == nil && len(fields) == 0 {
+		fields, err = r.getLegacy(ctx, shortURL)
+	}
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			r.logger.Warn("url not found",
-				zap.String("short_url", shortURL))
-			return nil, ErrURLNotFound
-		}
-
 		r.logger.Error("failed to get url",
 			zap.String("short_url", shortURL),
 			zap.Error(err))
 		return nil, err
 	}
+	if len(fields) == 0 {
+		r.logger.Warn("url not found",
+			zap.String("short_url", shortURL))
+		return nil, ErrURLNotFound
+	}
 
 	r.logger.Debug("url retrieved successfully",
 		zap.String("short_url", shortURL))
-	return &domain.URL{
-		OriginalURL: originalURL,
-		ShortURL:    shortURL,
-	}, nil
+	return urlFromFields(shortURL, fields)
 }
 
-func (r *RedisURLRepo) Delete(ctx context.Context, shortURL string) error {
+// getLegacy reads the fields of a URL saved under its bare code. Codes that
+// could not have been issued are not looked up.
+func (r *RedisURLRepo) getLegacy(ctx context.Context, shortURL string) (map[string]string, error) {
+	if domain.ValidateCode(shortURL) != nil {
+		return nil, nil
+	}
+	values, err := getLegacyScript.Run(ctx, r.client, []string{shortURL}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		fields[values[i]] = values[i+1]
+	}
+	return fields, nil
+}
+
+// run runs one of the URL scripts and returns its result. If the script
+// reports missing, which the URL is when it is still kept under its bare
+// code, the URL is migrated to its own key and the script run again.
+func (r *RedisURLRepo) run(ctx context.Context, script *redis.Script, shortURL string, missing int64, args ...any) (int64, error) {
+	keys := []string{urlKey(shortURL)}
+	res, err := script.Run(ctx, r.client, keys, args...).Int64()
+	if err != nil || res != missing {
+		return res, err
+	}
+	migrated, err := r.migrateLegacy(ctx, shortURL)
+	if err != nil || !migrated {
+		return res, err
+	}
+	return script.Run(ctx, r.client, keys, args...).Int64()
+}
+
+// migrateLegacy moves a URL saved under its bare code to its own key and
+// reports whether there was one.
+func (r *RedisURLRepo) migrateLegacy(ctx context.Context, shortURL string) (bool, error) {
+	if domain.ValidateCode(shortURL) != nil {
+		return false, nil
+	}
+	keys := []string{urlKey(shortURL), shortURL}
+	migrated, err := migrateLegacyScript.Run(ctx, r.client, keys, time.Now().UnixMilli()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to migrate legacy url: %w", err)
+	}
+	if migrated == 0 {
+		return false, nil
+	}
+
+	r.logger.Info("legacy url migrated",
+		zap.String("short_url", shortURL))
+	return true, nil
+}
+
+// Delete turns a URL into a tombstone that is kept for the retention period,
+// so that it can be restored and its short URL is not reissued meanwhile. A
+// zero retention removes the URL right away. The URL is only deleted if it
+// still meets cond.
+func (r *RedisURLRepo) Delete(ctx context.Context, shortURL string, retention time.Duration, cond domain.Precondition) error {
 	if shortURL == "" {
 		return ErrShortURLEmpty
 	}
 
-	if err := r.client.Del(ctx, shortURL).Err(); err != nil {
+	res, err := r.run(ctx, deleteScript, shortURL, 0,
+		time.Now().UnixMilli(), retention.Milliseconds(), cond.OriginalURL, cond.Version)
+	if err != nil {
 		r.logger.Error("failed to delete url",
 			zap.String("short_url", shortURL),
 			zap.Error(err))
 		return err
 	}
 
+	switch res {
+	case 0:
+		return ErrURLNotFound
+	case -1:
+		return ErrURLChanged
+	}
+
 	r.logger.Debug("url deleted successfully",
 		zap.String("short_url", shortURL))
 	return nil
 }
+
+// Restore brings a deleted URL back with the expiration it had before.
+func (r *RedisURLRepo) Restore(ctx context.Context, shortURL string) error {
+	if shortURL == "" {
+		return ErrShortURLEmpty
+	}
+
+	res, err := r.run(ctx, restoreScript, shortURL, -1, time.Now().UnixMilli())
+	if err != nil {
+		r.logger.Error("failed to restore url",
+			zap.String("short_url", shortURL),
+			zap.Error(err))
+		return err
+	}
+
+	switch res {
+	case -1:
+		return ErrURLNotFound
+	case -2:
+		return ErrURLNotDeleted
+	case -3:
+		return ErrURLExpired
+	}
+
+	r.logger.Debug("url restored successfully",
+		zap.String("short_url", shortURL))
+	return nil
+}
+
+// SetStatus disables or enables a URL. Deleted URLs have to be restored first.
+func (r *RedisURLRepo) SetStatus(ctx context.Context, shortURL string, status domain.Status) error {
+	if shortURL == "" {
+		return ErrShortURLEmpty
+	}
+
+	res, err := r.run(ctx, setStatusScript, shortURL, 0, string(status))
+	if err != nil {
+		r.logger.Error("failed to set url status",
+			zap.String("short_url", shortURL),
+			zap.Error(err))
+		return err
+	}
+	if res == 0 {
+		return ErrURLNotFound
+	}
+
+	r.logger.Debug("url status changed",
+		zap.String("short_url", shortURL),
+		zap.String("status", string(status)))
+	return nil
+}
+
+// TTL returns the remaining lifetime of a short URL. A zero duration means the
+// URL never expires.
+func (r *RedisURLRepo) TTL(ctx context.Context, shortURL string) (time.Duration, error) {
+	if shortURL == "" {
+		return 0, ErrShortURLEmpty
+	}
+
+	ttl, err := r.client.PTTL(ctx, urlKey(shortURL)).Result()
+	if err == nil && ttl == -2 {
+		ttl, err = r.legacyTTL(ctx, shortURL)
+	}
+	if err != nil {
+		r.logger.Error("failed to get url ttl",
+			zap.String("short_url", shortURL),
+			zap.Error(err))
+		return 0, err
+	}
+
+	switch {
+	case ttl == -2:
+		return 0, ErrURLNotFound
+	case ttl < 0:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// legacyTTL returns the TTL of a URL saved under its bare code, or -2 if the
+// key holds no URL.
+func (r *RedisURLRepo) legacyTTL(ctx context.Context, shortURL string) (time.Duration, error) {
+	fields, err := r.getLegacy(ctx, shortURL)
+	if err != nil || len(fields) == 0 {
+		return -2, err
+	}
+	return r.client.PTTL(ctx, shortURL).Result()
+}
+
+// ConsumeClick uses up one click of a URL with a click limit and returns how
+// many are left. Once none are left the URL is deleted and kept for the
+// retention period, as Delete does. For URLs without a limit it returns -1
+// and changes nothing; for deleted URLs, which includes used up ones, it
+// fails with ErrURLExhausted.
+func (r *RedisURLRepo) ConsumeClick(ctx context.Context, shortURL string, retention time.Duration) (int64, error) {
+	if shortURL == "" {
+		return 0, ErrShortURLEmpty
+	}
+
+	left, err := r.run(ctx, consumeClickScript, shortURL, -1,
+		time.Now().UnixMilli(), retention.Milliseconds())
+	if err != nil {
+		r.logger.Error("failed to consume click",
+			zap.String("short_url", shortURL),
+			zap.Error(err))
+		return 0, err
+	}
+
+	switch left {
+	case -1:
+		return 0, ErrURLNotFound
+	case -2:
+		return -1, nil
+	case -3:
+		return 0, ErrURLExhausted
+	}
+
+	r.logger.Debug("click consumed",
+		zap.String("short_url", shortURL),
+		zap.Int64("clicks_left", left))
+	return left, nil
+}
+
+func urlKey(shortURL string) string {
+	return "url:{" + shortURL + "}"
+}
+
+func urlFields(url *domain.URL) []any {
+	fields := []any{fieldOriginalURL, url.OriginalURL}
+	if url.PasswordHash != "" {
+		fields = append(fields, fieldPasswordHash, url.PasswordHash)
+	}
+	if url.MaxClicks > 0 {
+		fields = append(fields, fieldMaxClicks, url.MaxClicks, fieldClicksLeft, url.MaxClicks)
+	}
+	if !url.NotBefore.IsZero() {
+		fields = append(fields, fieldNotBefore, url.NotBefore.UnixMilli())
+	}
+	if !url.ExpiresAt.IsZero() {
+		fields = append(fields, fieldExpiresAt, url.ExpiresAt.UnixMilli())
+	}
+	if url.Status != "" && url.Status != domain.StatusActive {
+		fields = append(fields, fieldStatus, string(url.Status))
+	}
+	if len(url.Rules) > 0 {
+		rules, _ := json.Marshal(url.Rules)
+		fields = append(fields, fieldRules, string(rules))
+	}
+	if len(url.Variants) > 0 {
+		variants, _ := json.Marshal(url.Variants)
+		fields = append(fields, fieldVariants, string(variants))
+	}
+	if url.Split != "" {
+		fields = append(fields, fieldSplit, string(url.Split))
+	}
+	if !url.Query.IsZero() {
+		query, _ := json.Marshal(url.Query)
+		fields = append(fields, fieldQuery, string(query))
+	}
+	if url.Domain != "" {
+		fields = append(fields, fieldDomain, url.Domain)
+	}
+	if url.Owner != "" {
+		fields = append(fields, fieldOwner, url.Owner)
+	}
+	return fields
+}
+
+func urlFromFields(shortURL string, fields map[string]string) (*domain.URL, error) {
+	url := &domain.URL{
+		ShortURL:     shortURL,
+		OriginalURL:  fields[fieldOriginalURL],
+		PasswordHash: fields[fieldPasswordHash],
+		Status:       domain.StatusActive,
+		Domain:       fields[fieldDomain],
+		Owner:        fields[fieldOwner],
+		// The version is only stored once the URL is changed.
+		Version: 1,
+	}
+	if status, ok := fields[fieldStatus]; ok {
+		url.Status = domain.Status(status)
+	}
+	if rules, ok := fields[fieldRules]; ok {
+		if err := json.Unmarshal([]byte(rules), &url.Rules); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", fieldRules, err)
+		}
+	}
+	if variants, ok := fields[fieldVariants]; ok {
+		if err := json.Unmarshal([]byte(variants), &url.Variants); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", fieldVariants, err)
+		}
+	}
+	url.Split = domain.SplitMode(fields[fieldSplit])
+	if query, ok := fields[fieldQuery]; ok {
+		if err := json.Unmarshal([]byte(query), &url.Query); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", fieldQuery, err)
+		}
+	}
+
+	ints := []struct {
+		field string
+		dst   *int64
+	}{
+		{fieldMaxClicks, &url.MaxClicks},
+		{fieldClicksLeft, &url.ClicksLeft},
+		{fieldVersion, &url.Version},
+	}
+	for _, i := range ints {
+		v, ok := fields[i.field]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", i.field, err)
+		}
+		*i.dst = n
+	}
+
+	times := []struct {
+		field string
+		dst   *time.Time
+	}{
+		{fieldNotBefore, &url.NotBefore},
+		{fieldExpiresAt, &url.ExpiresAt},
+	}
+	for _, t := range times {
+		v, ok := fields[t.field]
+		if !ok {
+			continue
+		}
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", t.field, err)
+		}
+		*t.dst = time.UnixMilli(ms)
+	}
+	return url, nil
+}
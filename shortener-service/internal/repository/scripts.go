@@ -0,0 +1,216 @@
+package repository
+
+import "github.com/redis/go-redis/v9"
+
+// saveScript creates the URL KEYS[1] unless its short URL is taken, including
+// by a deleted URL that is still retained or by anything under the bare code
+// KEYS[2], where URLs used to be kept. ARGV[1] is the TTL in milliseconds
+// (zero for none), the rest are hash fields and values. It returns 1 if the
+// URL was created and 0 otherwise.
+var saveScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 or redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], unpack(ARGV, 2))
+local ttl = tonumber(ARGV[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// getLegacyScript reads a URL saved under its bare code KEYS[1], before URL
+// keys were namespaced: a hash with an original URL, or a plain string
+// holding an http(s) URL from before URLs became hashes. It returns the hash
+// fields and values, or nothing if the key holds no URL.
+var getLegacyScript = redis.NewScript(`
+local kind = redis.call('TYPE', KEYS[1]).ok
+if kind == 'hash' and redis.call('HEXISTS', KEYS[1], 'original_url') == 1 then
+	return redis.call('HGETALL', KEYS[1])
+end
+if kind == 'string' then
+	local original_url = redis.call('GET', KEYS[1])
+	if string.find(string.lower(original_url), '^https?://') then
+		return {'original_url', original_url}
+	end
+end
+return {}
+`)
+
+// migrateLegacyScript moves a URL saved under its bare code KEYS[2] to its
+// key KEYS[1], keeping its expiration. Plain strings are turned into hashes
+// that expire at the same time, so that they can be deleted and restored
+// like any other URL. Keys that do not hold a URL, as getLegacyScript tells,
+// are left alone. ARGV[1] is the current time in milliseconds. It returns 1
+// if a URL was migrated and 0 otherwise.
+var migrateLegacyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+local kind = redis.call('TYPE', KEYS[2]).ok
+if kind == 'hash' and redis.call('HEXISTS', KEYS[2], 'original_url') == 1 then
+	redis.call('RENAME', KEYS[2], KEYS[1])
+	return 1
+end
+if kind ~= 'string' then
+	return 0
+end
+local original_url = redis.call('GET', KEYS[2])
+if not string.find(string.lower(original_url), '^https?://') then
+	return 0
+end
+local ttl = redis.call('PTTL', KEYS[2])
+redis.call('DEL', KEYS[2])
+redis.call('HSET', KEYS[1], 'original_url', original_url)
+if ttl > 0 then
+	redis.call('HSET', KEYS[1], 'expires_at', tonumber(ARGV[1]) + ttl)
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// deleteScript marks a URL as deleted and keeps it for the retention period.
+// ARGV[1] is the current time and ARGV[2] the retention, both in
+// milliseconds. ARGV[3] and ARGV[4] are the original URL and version the URL
+// must still have, empty and zero to skip the check. It returns 1 if the URL
+// was deleted, 0 if there was nothing to delete and -1 if the URL does not
+// match.
+var deleteScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 or redis.call('HGET', KEYS[1], 'status') == 'deleted' then
+	return 0
+end
+if ARGV[3] ~= '' and redis.call('HGET', KEYS[1], 'original_url') ~= ARGV[3] then
+	return -1
+end
+local version = tonumber(redis.call('HGET', KEYS[1], 'version') or '1')
+if tonumber(ARGV[4]) > 0 and version ~= tonumber(ARGV[4]) then
+	return -1
+end
+local retention = tonumber(ARGV[2])
+if retention <= 0 then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+redis.call('HSET', KEYS[1], 'status', 'deleted', 'deleted_at', ARGV[1])
+redis.call('PEXPIRE', KEYS[1], retention)
+return 1
+`)
+
+// restoreScript brings a deleted URL back and puts its original expiration
+// back in place, bumping its version. URLs deleted with their last click get
+// their clicks back. ARGV[1] is the current time in
+// milliseconds. It returns 1 on success, -1 if the URL does not exist, -2 if
+// it is not deleted and -3 if it would already have expired.
+var restoreScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+if redis.call('HGET', KEYS[1], 'status') ~= 'deleted' then
+	return -2
+end
+local expires_at = redis.call('HGET', KEYS[1], 'expires_at')
+if expires_at then
+	local ttl = tonumber(expires_at) - tonumber(ARGV[1])
+	if ttl <= 0 then
+		return -3
+	end
+	redis.call('PEXPIRE', KEYS[1], ttl)
+else
+	redis.call('PERSIST', KEYS[1])
+end
+redis.call('HDEL', KEYS[1], 'status', 'deleted_at')
+local max_clicks = redis.call('HGET', KEYS[1], 'max_clicks')
+if max_clicks and tonumber(redis.call('HGET', KEYS[1], 'clicks_left')) <= 0 then
+	redis.call('HSET', KEYS[1], 'clicks_left', max_clicks)
+end
+redis.call('HSET', KEYS[1], 'version', tonumber(redis.call('HGET', KEYS[1], 'version') or '1') + 1)
+return 1
+`)
+
+// setStatusScript sets the status of a URL that is not deleted and bumps its
+// version. ARGV[1] is the new status. It returns 1 on success and 0 if there
+// is no such URL.
+var setStatusScript = redis.NewScript(`
+local status = redis.call('HGET', KEYS[1], 'status')
+if redis.call('EXISTS', KEYS[1]) == 0 or status == 'deleted' then
+	return 0
+end
+if ARGV[1] == 'active' then
+	redis.call('HDEL', KEYS[1], 'status')
+else
+	redis.call('HSET', KEYS[1], 'status', ARGV[1])
+end
+redis.call('HSET', KEYS[1], 'version', tonumber(redis.call('HGET', KEYS[1], 'version') or '1') + 1)
+return 1
+`)
+
+// consumeClickScript decrements the clicks left on an active URL. Once none
+// are left the URL is marked as deleted like deleteScript does, with ARGV[1]
+// the current time and ARGV[2] the retention in milliseconds. It returns the
+// clicks left, -1 if there is no such active URL, -2 if it has no click
+// limit and -3 if it is deleted or has no clicks left.
+var consumeClickScript = redis.NewScript(`
+local status = redis.call('HGET', KEYS[1], 'status')
+if status == 'deleted' then
+	return -3
+end
+if redis.call('EXISTS', KEYS[1]) == 0 or status then
+	return -1
+end
+if redis.call('HEXISTS', KEYS[1], 'clicks_left') == 0 then
+	return -2
+end
+local left = redis.call('HINCRBY', KEYS[1], 'clicks_left', -1)
+if left <= 0 then
+	local retention = tonumber(ARGV[2])
+	if retention > 0 then
+		redis.call('HSET', KEYS[1], 'status', 'deleted', 'deleted_at', ARGV[1])
+		redis.call('PEXPIRE', KEYS[1], retention)
+	else
+		redis.call('DEL', KEYS[1])
+	end
+end
+if left < 0 then
+	return -3
+end
+return left
+`)
+
+// attemptScript counts an attempt in the fixed window KEYS[1], which lasts
+// ARGV[1] milliseconds from the first attempt, and returns the attempts made
+// in it so far.
+var attemptScript = redis.NewScript(`
+local attempts = redis.call('INCR', KEYS[1])
+if attempts == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return attempts
+`)
+
+// deleteWebhookScript deletes a webhook with its history lists if it belongs
+// to ARGV[1]. KEYS are the webhook, its deliveries and its dead letters. It
+// returns the ids in the lists, whose deliveries are left to the caller, or
+// nil if the webhook was not deleted.
+var deleteWebhookScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], 'owner')
+if owner == false or owner ~= ARGV[1] then
+	return false
+end
+local ids = redis.call('LRANGE', KEYS[2], 0, -1)
+for _, id in ipairs(redis.call('LRANGE', KEYS[3], 0, -1)) do
+	table.insert(ids, id)
+end
+redis.call('DEL', KEYS[1], KEYS[2], KEYS[3])
+return ids
+`)
+
+// claimScript hands out members of the sorted set KEYS[1] scored at most
+// ARGV[1], up to ARGV[3] of them, and rescores them to ARGV[2] so nobody
+// else claims them until then.
+var claimScript = redis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, member in ipairs(members) do
+	redis.call('ZADD', KEYS[1], ARGV[2], member)
+end
+return members
+`)
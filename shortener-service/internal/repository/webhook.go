@@ -0,0 +1,457 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+const (
+	// webhookQueueKey orders pending deliveries by their next attempt.
+	webhookQueueKey = "webhooks:queue"
+	// webhookExpiringKey orders URLs by the time their expiry is announced.
+	webhookExpiringKey = "webhooks:expiring"
+)
+
+const (
+	fieldURL            = "url"
+	fieldSecret         = "secret"
+	fieldEvents         = "events"
+	fieldCreatedAt      = "created_at"
+	fieldWebhookID      = "webhook_id"
+	fieldEvent          = "event"
+	fieldShortURL       = "short_url"
+	fieldPayload        = "payload"
+	fieldAttempts       = "attempts"
+	fieldLastStatusCode = "last_status_code"
+	fieldLastError      = "last_error"
+	fieldUpdatedAt      = "updated_at"
+	fieldNextAttemptAt  = "next_attempt_at"
+)
+
+// RedisWebhookStore keeps webhooks and their deliveries. Keys of one webhook
+// share a hash tag so they live on the same cluster slot. The newest
+// historyLimit deliveries and dead letters of every webhook are listed, and
+// deliveries and the lists are kept for historyTTL after the last change.
+type RedisWebhookStore struct {
+	client       redis.UniversalClient
+	historyLimit int64
+	historyTTL   time.Duration
+	logger       *zap.Logger
+}
+
+func NewRedisWebhookStore(client redis.UniversalClient, historyLimit int64, historyTTL time.Duration, logger *zap.Logger) *RedisWebhookStore {
+	return &RedisWebhookStore{
+		client:       client,
+		historyLimit: historyLimit,
+		historyTTL:   historyTTL,
+		logger:       logger,
+	}
+}
+
+func (s *RedisWebhookStore) SaveWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	events := make([]string, len(webhook.Events))
+	for i, event := range webhook.Events {
+		events[i] = string(event)
+	}
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, webhookKey(webhook.ID),
+			fieldOwner, webhook.Owner,
+			fieldURL, webhook.URL,
+			fieldSecret, webhook.Secret,
+			fieldEvents, strings.Join(events, ","),
+			fieldCreatedAt, webhook.CreatedAt.UnixMilli(),
+		)
+		pipe.SAdd(ctx, ownerWebhooksKey(webhook.Owner), webhook.ID)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to save webhook", zap.Error(err), zap.String("webhook_id", webhook.ID))
+	}
+	return err
+}
+
+func (s *RedisWebhookStore) GetWebhook(ctx context.Context, id string) (*domain.Webhook, error) {
+	fields, err := s.client.HGetAll(ctx, webhookKey(id)).Result()
+	if err != nil {
+		s.logger.Error("failed to get webhook", zap.Error(err), zap.String("webhook_id", id))
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrWebhookNotFound
+	}
+	return webhookFromFields(id, fields)
+}
+
+// Webhooks returns the webhooks of owner.
+func (s *RedisWebhookStore) Webhooks(ctx context.Context, owner string) ([]*domain.Webhook, error) {
+	ids, err := s.client.SMembers(ctx, ownerWebhooksKey(owner)).Result()
+	if err != nil {
+		s.logger.Error("failed to list webhooks", zap.Error(err))
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, webhookKey(id))
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to get webhooks", zap.Error(err))
+		return nil, err
+	}
+
+	webhooks := make([]*domain.Webhook, 0, len(ids))
+	for i, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			continue
+		}
+		webhook, err := webhookFromFields(ids[i], cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes the webhook with its history, failing with
+// ErrWebhookNotFound unless owner has a webhook with id. Its listed
+// deliveries are deleted and taken off the queue; ones already trimmed from
+// the history are dropped when they come up, see RemoveDelivery.
+func (s *RedisWebhookStore) DeleteWebhook(ctx context.Context, owner, id string) error {
+	keys := []string{webhookKey(id), deliveriesKey(id), deadLettersKey(id)}
+	ids, err := deleteWebhookScript.Run(ctx, s.client, keys, owner).StringSlice()
+	if errors.Is(err, redis.Nil) {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to delete webhook", zap.Error(err), zap.String("webhook_id", id))
+		return err
+	}
+
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(ids) > 0 {
+			deliveries := make([]string, len(ids))
+			members := make([]any, len(ids))
+			for i, deliveryID := range ids {
+				delivery := &domain.Delivery{ID: deliveryID, WebhookID: id}
+				deliveries[i] = deliveryKey(id, deliveryID)
+				members[i] = queueMember(delivery)
+			}
+			pipe.Del(ctx, deliveries...)
+			pipe.ZRem(ctx, webhookQueueKey, members...)
+		}
+		pipe.SRem(ctx, ownerWebhooksKey(owner), id)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to delete webhook deliveries", zap.Error(err), zap.String("webhook_id", id))
+	}
+	return err
+}
+
+// AddDelivery saves a new delivery, lists it in the webhook's history and
+// queues it for its next attempt.
+func (s *RedisWebhookStore) AddDelivery(ctx context.Context, delivery *domain.Delivery) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		s.saveDelivery(ctx, pipe, delivery)
+		history := deliveriesKey(delivery.WebhookID)
+		pipe.LPush(ctx, history, delivery.ID)
+		pipe.LTrim(ctx, history, 0, s.historyLimit-1)
+		pipe.Expire(ctx, history, s.historyTTL)
+		pipe.ZAdd(ctx, webhookQueueKey, redis.Z{
+			Score:  float64(delivery.NextAttemptAt.UnixMilli()),
+			Member: queueMember(delivery),
+		})
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to add webhook delivery", zap.Error(err), zap.String("webhook_id", delivery.WebhookID))
+	}
+	return err
+}
+
+// UpdateDelivery saves the outcome of an attempt. Pending deliveries are
+// queued for their next attempt, dead ones move to the dead letters.
+func (s *RedisWebhookStore) UpdateDelivery(ctx context.Context, delivery *domain.Delivery) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		s.saveDelivery(ctx, pipe, delivery)
+		switch delivery.Status {
+		case domain.DeliveryPending:
+			pipe.ZAdd(ctx, webhookQueueKey, redis.Z{
+				Score:  float64(delivery.NextAttemptAt.UnixMilli()),
+				Member: queueMember(delivery),
+			})
+		case domain.DeliveryDead:
+			dead := deadLettersKey(delivery.WebhookID)
+			pipe.LPush(ctx, dead, delivery.ID)
+			pipe.LTrim(ctx, dead, 0, s.historyLimit-1)
+			pipe.Expire(ctx, dead, s.historyTTL)
+			pipe.ZRem(ctx, webhookQueueKey, queueMember(delivery))
+		default:
+			pipe.ZRem(ctx, webhookQueueKey, queueMember(delivery))
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to update webhook delivery", zap.Error(err), zap.String("delivery_id", delivery.ID))
+	}
+	return err
+}
+
+// RemoveDelivery deletes a delivery and takes it off the queue.
+func (s *RedisWebhookStore) RemoveDelivery(ctx context.Context, delivery *domain.Delivery) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, deliveryKey(delivery.WebhookID, delivery.ID))
+		pipe.ZRem(ctx, webhookQueueKey, queueMember(delivery))
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to remove webhook delivery", zap.Error(err), zap.String("delivery_id", delivery.ID))
+	}
+	return err
+}
+
+// ClaimDeliveries returns up to limit deliveries due by now. They are not
+// handed out again until lease passes, so a delivery whose worker died is
+// retried.
+func (s *RedisWebhookStore) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]*domain.Delivery, error) {
+	members, err := s.claim(ctx, webhookQueueKey, now, lease, limit)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(members))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, member := range members {
+			webhookID, id, _ := strings.Cut(member, ":")
+			cmds[i] = pipe.HGetAll(ctx, deliveryKey(webhookID, id))
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to get webhook deliveries", zap.Error(err))
+		return nil, err
+	}
+
+	deliveries := make([]*domain.Delivery, 0, len(members))
+	for i, cmd := range cmds {
+		_, id, _ := strings.Cut(members[i], ":")
+		if len(cmd.Val()) == 0 {
+			// The delivery outlived its history; there is nothing to send.
+			s.client.ZRem(ctx, webhookQueueKey, members[i])
+			continue
+		}
+		delivery, err := deliveryFromFields(id, cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// Deliveries returns up to limit of the latest deliveries of a webhook,
+// newest first, or only its dead letters.
+func (s *RedisWebhookStore) Deliveries(ctx context.Context, webhookID string, deadOnly bool, limit int64) ([]*domain.Delivery, error) {
+	key := deliveriesKey(webhookID)
+	if deadOnly {
+		key = deadLettersKey(webhookID)
+	}
+	ids, err := s.client.LRange(ctx, key, 0, limit-1).Result()
+	if err != nil {
+		s.logger.Error("failed to list webhook deliveries", zap.Error(err), zap.String("webhook_id", webhookID))
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, deliveryKey(webhookID, id))
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to get webhook deliveries", zap.Error(err), zap.String("webhook_id", webhookID))
+		return nil, err
+	}
+
+	deliveries := make([]*domain.Delivery, 0, len(ids))
+	for i, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			continue
+		}
+		delivery, err := deliveryFromFields(ids[i], cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// ScheduleExpiry announces the expiry of url at the given time.
+func (s *RedisWebhookStore) ScheduleExpiry(ctx context.Context, url domain.ExpiringURL, at time.Time) error {
+	return s.client.ZAdd(ctx, webhookExpiringKey, redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: expiringMember(url),
+	}).Err()
+}
+
+func (s *RedisWebhookStore) CancelExpiry(ctx context.Context, url domain.ExpiringURL) error {
+	return s.client.ZRem(ctx, webhookExpiringKey, expiringMember(url)).Err()
+}
+
+// ClaimExpiries returns up to limit URLs expired by now, with the same
+// lease as ClaimDeliveries. Announced expiries are removed with
+// CancelExpiry.
+func (s *RedisWebhookStore) ClaimExpiries(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]domain.ExpiringURL, error) {
+	members, err := s.claim(ctx, webhookExpiringKey, now, lease, limit)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]domain.ExpiringURL, len(members))
+	for i, member := range members {
+		urls[i].ShortURL, urls[i].Owner, _ = strings.Cut(member, ":")
+	}
+	return urls, nil
+}
+
+func (s *RedisWebhookStore) claim(ctx context.Context, key string, now time.Time, lease time.Duration, limit int64) ([]string, error) {
+	members, err := claimScript.Run(ctx, s.client, []string{key},
+		now.UnixMilli(), now.Add(lease).UnixMilli(), limit).StringSlice()
+	if err != nil {
+		s.logger.Error("failed to claim due entries", zap.Error(err), zap.String("key", key))
+		return nil, err
+	}
+	return members, nil
+}
+
+func (s *RedisWebhookStore) saveDelivery(ctx context.Context, pipe redis.Pipeliner, delivery *domain.Delivery) {
+	key := deliveryKey(delivery.WebhookID, delivery.ID)
+	pipe.HSet(ctx, key,
+		fieldWebhookID, delivery.WebhookID,
+		fieldEvent, string(delivery.Event),
+		fieldShortURL, delivery.ShortURL,
+		fieldPayload, delivery.Payload,
+		fieldStatus, string(delivery.Status),
+		fieldAttempts, delivery.Attempts,
+		fieldLastStatusCode, delivery.LastStatusCode,
+		fieldLastError, delivery.LastError,
+		fieldCreatedAt, delivery.CreatedAt.UnixMilli(),
+		fieldUpdatedAt, delivery.UpdatedAt.UnixMilli(),
+		fieldNextAttemptAt, delivery.NextAttemptAt.UnixMilli(),
+	)
+	pipe.Expire(ctx, key, s.historyTTL)
+}
+
+func webhookFromFields(id string, fields map[string]string) (*domain.Webhook, error) {
+	createdAt, err := strconv.ParseInt(fields[fieldCreatedAt], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", fieldCreatedAt, err)
+	}
+	webhook := &domain.Webhook{
+		ID:        id,
+		Owner:     fields[fieldOwner],
+		URL:       fields[fieldURL],
+		Secret:    fields[fieldSecret],
+		CreatedAt: time.UnixMilli(createdAt),
+	}
+	for event := range strings.SplitSeq(fields[fieldEvents], ",") {
+		webhook.Events = append(webhook.Events, domain.WebhookEvent(event))
+	}
+	return webhook, nil
+}
+
+func deliveryFromFields(id string, fields map[string]string) (*domain.Delivery, error) {
+	delivery := &domain.Delivery{
+		ID:        id,
+		WebhookID: fields[fieldWebhookID],
+		Event:     domain.WebhookEvent(fields[fieldEvent]),
+		ShortURL:  fields[fieldShortURL],
+		Payload:   []byte(fields[fieldPayload]),
+		Status:    domain.DeliveryStatus(fields[fieldStatus]),
+		LastError: fields[fieldLastError],
+	}
+
+	ints := []struct {
+		field string
+		dst   *int
+	}{
+		{fieldAttempts, &delivery.Attempts},
+		{fieldLastStatusCode, &delivery.LastStatusCode},
+	}
+	for _, i := range ints {
+		n, err := strconv.Atoi(fields[i.field])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", i.field, err)
+		}
+		*i.dst = n
+	}
+
+	times := []struct {
+		field string
+		dst   *time.Time
+	}{
+		{fieldCreatedAt, &delivery.CreatedAt},
+		{fieldUpdatedAt, &delivery.UpdatedAt},
+		{fieldNextAttemptAt, &delivery.NextAttemptAt},
+	}
+	for _, t := range times {
+		ms, err := strconv.ParseInt(fields[t.field], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", t.field, err)
+		}
+		*t.dst = time.UnixMilli(ms)
+	}
+	return delivery, nil
+}
+
+func webhookKey(id string) string {
+	return "webhook:{" + id + "}"
+}
+
+func deliveriesKey(webhookID string) string {
+	return "webhook:{" + webhookID + "}:deliveries"
+}
+
+func deadLettersKey(webhookID string) string {
+	return "webhook:{" + webhookID + "}:dead"
+}
+
+func deliveryKey(webhookID, id string) string {
+	return "webhook:{" + webhookID + "}:delivery:" + id
+}
+
+func ownerWebhooksKey(owner string) string {
+	return "webhooks:owner:" + owner
+}
+
+// queueMember names a delivery in the queue; ids never contain ':'.
+func queueMember(delivery *domain.Delivery) string {
+	return delivery.WebhookID + ":" + delivery.ID
+}
+
+// expiringMember names a URL in the expiry schedule; short URLs never
+// contain ':'.
+func expiringMember(url domain.ExpiringURL) string {
+	return url.ShortURL + ":" + url.Owner
+}
@@ -0,0 +1,504 @@
+// Package webhook delivers link events to endpoints registered by the links'
+// owners.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/logging"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	EventHeader    = "X-Shortener-Event"
+	DeliveryHeader = "X-Shortener-Delivery"
+	// SignatureHeader carries "t=<unix seconds>,v1=<signature>", see Sign.
+	SignatureHeader = "X-Shortener-Signature"
+)
+
+const (
+	defaultMaxAttempts    = 8
+	defaultInitialBackoff = 10 * time.Second
+	defaultMaxBackoff     = time.Hour
+	defaultTimeout        = 10 * time.Second
+	defaultPollInterval   = time.Second
+
+	// batchSize bounds the deliveries and expiries handled per poll.
+	batchSize = 100
+	// maxResponseBytes of a response body are read so the connection can be
+	// reused; the body is not stored.
+	maxResponseBytes = 64 << 10
+)
+
+// eventTypes maps the link events webhooks can subscribe to.
+var eventTypes = map[string]domain.WebhookEvent{
+	"url_created":   domain.WebhookCreated,
+	"url_deleted":   domain.WebhookDeleted,
+	"url_visited":   domain.WebhookVisited,
+	"url_exhausted": domain.WebhookExpired,
+}
+
+// Store keeps webhooks, their deliveries and the URLs whose expiry is
+// announced.
+type Store interface {
+	SaveWebhook(ctx context.Context, webhook *domain.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*domain.Webhook, error)
+	Webhooks(ctx context.Context, owner string) ([]*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, owner, id string) error
+	AddDelivery(ctx context.Context, delivery *domain.Delivery) error
+	UpdateDelivery(ctx context.Context, delivery *domain.Delivery) error
+	RemoveDelivery(ctx context.Context, delivery *domain.Delivery) error
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]*domain.Delivery, error)
+	Deliveries(ctx context.Context, webhookID string, deadOnly bool, limit int64) ([]*domain.Delivery, error)
+	ScheduleExpiry(ctx context.Context, url domain.ExpiringURL, at time.Time) error
+	CancelExpiry(ctx context.Context, url domain.ExpiringURL) error
+	ClaimExpiries(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]domain.ExpiringURL, error)
+}
+
+// URLChecker vets webhook endpoints.
+type URLChecker interface {
+	Check(rawURL string) error
+}
+
+// payload is the body of a delivery.
+type payload struct {
+	ID         string              `json:"id"`
+	Event      domain.WebhookEvent `json:"event"`
+	ShortURL   string              `json:"short_url"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Data       json.RawMessage     `json:"data,omitempty"`
+}
+
+// Service registers webhooks and delivers events to them. As a
+// events.Publisher it queues a delivery for every webhook of the URL's owner
+// subscribed to the event; Run sends them.
+type Service struct {
+	store          Store
+	client         *http.Client
+	checker        URLChecker
+	allowPrivate   bool
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	pollInterval   time.Duration
+	logger         *zap.Logger
+}
+
+type Option func(*Service)
+
+// WithRetries makes deliveries try up to maxAttempts times, waiting
+// initialBackoff after the first failure and twice as long after every
+// further one, up to maxBackoff. Deliveries failing every attempt become
+// dead letters.
+func WithRetries(maxAttempts int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(s *Service) {
+		s.maxAttempts = maxAttempts
+		s.initialBackoff = initialBackoff
+		s.maxBackoff = maxBackoff
+	}
+}
+
+// WithTimeout bounds each delivery attempt.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.client.Timeout = timeout
+	}
+}
+
+// WithPollInterval sets how often Run looks for due deliveries.
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.pollInterval = interval
+	}
+}
+
+// WithURLChecker rejects endpoints the checker refuses, e.g. internal hosts
+// on the destination blocklist.
+func WithURLChecker(checker URLChecker) Option {
+	return func(s *Service) {
+		s.checker = checker
+	}
+}
+
+// WithAllowPrivateNetworks lets webhooks reach loopback, private and
+// link-local addresses, which are refused by default so owners cannot probe
+// the network the service runs in.
+func WithAllowPrivateNetworks() Option {
+	return func(s *Service) {
+		s.allowPrivate = true
+	}
+}
+
+func NewService(store Store, logger *zap.Logger, opts ...Option) *Service {
+	s := &Service{
+		store: store,
+		client: &http.Client{
+			Timeout: defaultTimeout,
+			// Endpoints are vetted at registration; redirects could lead
+			// anywhere.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		pollInterval:   defaultPollInterval,
+		logger:         logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.client.Transport = s.transport()
+	return s
+}
+
+// transport dials endpoints directly, not through a proxy, so the addresses
+// host names resolve to are checked on every connection. This also covers
+// names that resolve to a public address at registration and to a private
+// one later.
+func (s *Service) transport() http.RoundTripper {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !s.allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if private(addr.Addr()) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// Register adds a webhook for owner. The returned webhook carries the
+// secret deliveries are signed with.
+func (s *Service) Register(ctx context.Context, owner, endpoint string, subscribed []domain.WebhookEvent) (*domain.Webhook, error) {
+	webhook := &domain.Webhook{
+		ID:        newToken(16),
+		Owner:     owner,
+		URL:       endpoint,
+		Secret:    newToken(32),
+		Events:    subscribed,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := webhook.Validate(); err != nil {
+		return nil, err
+	}
+	if !s.allowPrivate && privateHost(endpoint) {
+		return nil, domain.ErrPrivateWebhookURL
+	}
+	if s.checker != nil {
+		if err := s.checker.Check(endpoint); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.SaveWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Webhooks returns the webhooks of owner.
+func (s *Service) Webhooks(ctx context.Context, owner string) ([]*domain.Webhook, error) {
+	return s.store.Webhooks(ctx, owner)
+}
+
+func (s *Service) Delete(ctx context.Context, owner, id string) error {
+	return s.store.DeleteWebhook(ctx, owner, id)
+}
+
+// Deliveries returns up to limit of the latest deliveries of owner's
+// webhook, newest first, or only the dead letters.
+func (s *Service) Deliveries(ctx context.Context, owner, id string, deadOnly bool, limit int64) ([]*domain.Delivery, error) {
+	webhook, err := s.store.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Owner != owner {
+		return nil, repository.ErrWebhookNotFound
+	}
+	return s.store.Deliveries(ctx, id, deadOnly, limit)
+}
+
+// Publish queues event for the webhooks of the URL's owner subscribed to it.
+func (s *Service) Publish(ctx context.Context, event events.Event) error {
+	webhookEvent, ok := eventTypes[event.Name]
+	if !ok {
+		return nil
+	}
+
+	url := domain.ExpiringURL{ShortURL: event.ShortURL, Owner: event.Owner}
+	switch webhookEvent {
+	case domain.WebhookCreated:
+		var created struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		if err := json.Unmarshal(event.Payload, &created); err == nil && !created.ExpiresAt.IsZero() {
+			if err := s.store.ScheduleExpiry(ctx, url, created.ExpiresAt); err != nil {
+				return err
+			}
+		}
+	case domain.WebhookDeleted, domain.WebhookExpired:
+		if err := s.store.CancelExpiry(ctx, url); err != nil {
+			return err
+		}
+	}
+
+	return s.enqueue(ctx, event.Owner, webhookEvent, event.ShortURL, event.Payload)
+}
+
+// Run delivers due events every poll interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Poll(ctx); err != nil {
+				s.logger.Error("webhook poll failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Poll announces URLs that expired and attempts the deliveries that are due.
+func (s *Service) Poll(ctx context.Context) error {
+	now := time.Now()
+	lease := s.client.Timeout + time.Minute
+
+	expired, err := s.store.ClaimExpiries(ctx, now, lease, batchSize)
+	if err != nil {
+		return err
+	}
+	for _, url := range expired {
+		data, _ := json.Marshal(map[string]string{"short_url": url.ShortURL})
+		if err := s.enqueue(ctx, url.Owner, domain.WebhookExpired, url.ShortURL, data); err != nil {
+			return err
+		}
+		if err := s.store.CancelExpiry(ctx, url); err != nil {
+			return err
+		}
+	}
+
+	deliveries, err := s.store.ClaimDeliveries(ctx, now, lease, batchSize)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// enqueue adds a delivery of event for every webhook of owner subscribed to
+// it.
+func (s *Service) enqueue(ctx context.Context, owner string, event domain.WebhookEvent, shortURL string, data []byte) error {
+	webhooks, err := s.store.Webhooks(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var errs []error
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event) {
+			continue
+		}
+		body := payload{
+			ID:         newToken(16),
+			Event:      event,
+			ShortURL:   shortURL,
+			OccurredAt: now,
+		}
+		if json.Valid(data) {
+			body.Data = data
+		}
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+		errs = append(errs, s.store.AddDelivery(ctx, &domain.Delivery{
+			ID:            body.ID,
+			WebhookID:     webhook.ID,
+			Event:         event,
+			ShortURL:      shortURL,
+			Payload:       encoded,
+			Status:        domain.DeliveryPending,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			NextAttemptAt: now,
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// attempt sends delivery once and records the outcome.
+func (s *Service) attempt(ctx context.Context, delivery *domain.Delivery) {
+	webhook, err := s.store.GetWebhook(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, repository.ErrWebhookNotFound):
+		// The webhook was deleted with deliveries still queued; there is
+		// nobody left to send them to or to look at them.
+		if err := s.store.RemoveDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+			s.logger.Error("failed to remove webhook delivery", zap.Error(err), zap.String("delivery_id", delivery.ID))
+		}
+		return
+	case err != nil:
+		s.logger.Error("failed to get webhook", zap.Error(err), zap.String("webhook_id", delivery.WebhookID))
+		return
+	default:
+		delivery.Attempts++
+		delivery.LastStatusCode, err = s.send(ctx, webhook, delivery)
+		delivery.LastError = ""
+		switch {
+		case err == nil:
+			delivery.Status = domain.DeliveryDelivered
+		case delivery.Attempts >= s.maxAttempts:
+			delivery.Status = domain.DeliveryDead
+			delivery.LastError = err.Error()
+			s.logger.Warn("webhook delivery failed for good",
+				zap.Error(err),
+				zap.String("webhook_id", webhook.ID),
+				zap.String("delivery_id", delivery.ID),
+				zap.Int("attempts", delivery.Attempts),
+			)
+		default:
+			delivery.Status = domain.DeliveryPending
+			delivery.LastError = err.Error()
+			delivery.NextAttemptAt = time.Now().Add(s.backoff(delivery.Attempts))
+		}
+	}
+
+	delivery.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+		s.logger.Error("failed to record webhook delivery", zap.Error(err), zap.String("delivery_id", delivery.ID))
+	}
+}
+
+// send posts the delivery and returns the response status code.
+func (s *Service) send(ctx context.Context, webhook *domain.Webhook, delivery *domain.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "shortener-webhooks")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(webhook.Secret, timestamp, delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// Owners only learn what kind of failure it was, not details of the
+		// network the service runs in.
+		s.logger.Debug("webhook request failed", zap.Error(err), logging.URL("url", webhook.URL))
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return 0, errors.New("request timed out")
+		}
+		if errors.Is(err, errPrivateAddress) {
+			return 0, errPrivateAddress
+		}
+		return 0, errors.New("request failed")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns how long to wait after the given number of failed attempts.
+func (s *Service) backoff(attempts int) time.Duration {
+	wait := s.initialBackoff
+	for range attempts - 1 {
+		if wait >= s.maxBackoff/2 {
+			return s.maxBackoff
+		}
+		wait *= 2
+	}
+	return min(wait, s.maxBackoff)
+}
+
+// errPrivateAddress is returned for endpoints that resolve to an address
+// webhooks may not reach.
+var errPrivateAddress = errors.New("endpoint resolves to a private address")
+
+// private reports whether addr is a loopback, private, link-local or
+// unspecified address.
+func private(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsUnspecified()
+}
+
+// privateHost reports whether the endpoint names localhost or a private
+// address literally. Other host names are checked when they are dialled.
+func privateHost(endpoint string) bool {
+	u, err := neturl.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && private(addr)
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// webhook secret. Receivers recompute it to check that a delivery is
+// genuine, and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newToken returns n random bytes in hex.
+func newToken(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
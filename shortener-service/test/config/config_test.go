@@ -0,0 +1,164 @@
+package config_test
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validConfig = `
+app:
+  port: 8080
+  http_port: 8081
+redis:
+  host: "localhost"
+kafka:
+  brokers: ["kafka-0:9092"]
+  topic: "url-events"
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shortener.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := config.LoadConfig(writeConfig(t, validConfig))
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, cfg.App.Port)
+	assert.Equal(t, []string{"kafka-0:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, 5*time.Second, cfg.Kafka.WriteTimeout, "defaults apply")
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	_, err := config.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_EnvOverride(t *testing.T) {
+	t.Setenv("APP_KAFKA_TOPIC", "other-events")
+
+	cfg, err := config.LoadConfig(writeConfig(t, validConfig))
+	require.NoError(t, err)
+	assert.Equal(t, "other-events", cfg.Kafka.Topic)
+}
+
+func TestLoadConfig_ListsAllErrors(t *testing.T) {
+	_, err := config.LoadConfig(writeConfig(t, `
+app:
+  port: 70000
+  http_port: 8081
+redis:
+  host: "localhost"
+kafka:
+  write_timeout: "-1s"
+`))
+	require.Error(t, err)
+
+	for _, key := range []string{"app.port", "kafka.brokers", "kafka.topic", "kafka.write_timeout"} {
+		assert.Contains(t, err.Error(), key)
+	}
+}
+
+func TestLoadConfig_WithoutKafka(t *testing.T) {
+	t.Setenv("APP_EVENTS_SINKS", "redis,log")
+
+	cfg, err := config.LoadConfig(writeConfig(t, `
+app:
+  port: 8080
+  http_port: 8081
+redis:
+  host: "localhost"
+`))
+	require.NoError(t, err, "kafka settings are only needed with the kafka sink")
+	assert.Equal(t, []string{"redis", "log"}, cfg.Events.Sinks)
+	assert.Equal(t, "url-events", cfg.Events.Stream)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*config.Config)
+		expectedKey string
+	}{
+		{name: "zero port", modify: func(c *config.Config) { c.App.HTTPPort = 0 }, expectedKey: "app.http_port"},
+		{name: "shared ports", modify: func(c *config.Config) { c.App.AdminPort = c.App.Port }, expectedKey: "must differ"},
+		{name: "empty broker", modify: func(c *config.Config) { c.Kafka.Brokers = []string{""} }, expectedKey: "kafka.brokers[0]"},
+		{name: "unknown redis mode", modify: func(c *config.Config) { c.Redis.Mode = "ring" }, expectedKey: "redis.mode"},
+		{name: "sentinel without master", modify: func(c *config.Config) {
+			c.Redis.Mode = "sentinel"
+			c.Redis.Addrs = []string{"sentinel:26379"}
+		}, expectedKey: "redis.master_name"},
+		{name: "zero idempotency ttl", modify: func(c *config.Config) { c.App.IdempotencyTTL = 0 }, expectedKey: "app.idempotency_ttl"},
+		{name: "unknown event sink", modify: func(c *config.Config) { c.Events.Sinks = []string{"nats"} }, expectedKey: "events.sinks"},
+		{name: "duplicate event sink", modify: func(c *config.Config) { c.Events.Sinks = []string{"kafka", "log", "kafka"} }, expectedKey: "events.sinks"},
+		{name: "none with other sinks", modify: func(c *config.Config) { c.Events.Sinks = []string{"none", "log"} }, expectedKey: "events.sinks"},
+		{name: "redis sink without stream", modify: func(c *config.Config) {
+			c.Events.Sinks = []string{"redis"}
+			c.Events.Stream = ""
+		}, expectedKey: "events.stream"},
+		{name: "spool without kafka", modify: func(c *config.Config) {
+			c.Events.Sinks = []string{"log"}
+			c.Events.Spool.Dir = "/var/spool/shortener"
+		}, expectedKey: "events.spool.dir"},
+		{name: "spool segments over max bytes", modify: func(c *config.Config) {
+			c.Events.Spool.Dir = "/var/spool/shortener"
+			c.Events.Spool.SegmentBytes = c.Events.Spool.MaxBytes + 1
+		}, expectedKey: "events.spool.segment_bytes"},
+		{name: "webhook backoff cap below initial", modify: func(c *config.Config) {
+			c.Webhooks.Enabled = true
+			c.Webhooks.MaxBackoff = time.Second
+		}, expectedKey: "webhooks.max_backoff"},
+		{name: "cache without ttl", modify: func(c *config.Config) { c.Cache.MaxTTL = 0 }, expectedKey: "cache.max_ttl"},
+		{name: "unknown strategy", modify: func(c *config.Config) { c.CodeGen.Strategy = "uuid" }, expectedKey: "codegen.strategy"},
+		{name: "code too long", modify: func(c *config.Config) { c.CodeGen.Length = 65 }, expectedKey: "codegen.length"},
+		{name: "unknown sasl mechanism", modify: func(c *config.Config) { c.Kafka.SASL.Mechanism = "gssapi" }, expectedKey: "kafka.sasl.mechanism"},
+		{name: "sasl without username", modify: func(c *config.Config) { c.Kafka.SASL.Mechanism = "plain" }, expectedKey: "kafka.sasl.username"},
+		{name: "tls options while disabled", modify: func(c *config.Config) { c.Redis.TLS.CAFile = "/ca.crt" }, expectedKey: "redis.tls"},
+		{name: "client cert without key", modify: func(c *config.Config) {
+			c.Kafka.TLS.Enabled = true
+			c.Kafka.TLS.CertFile = "/client.crt"
+		}, expectedKey: "kafka.tls"},
+		{name: "unknown log level", modify: func(c *config.Config) { c.Log.Level = "verbose" }, expectedKey: "log.level"},
+		{name: "unknown log encoding", modify: func(c *config.Config) { c.Log.Encoding = "logfmt" }, expectedKey: "log.encoding"},
+		{name: "malformed trusted proxy", modify: func(c *config.Config) {
+			c.App.TrustedProxies = []string{"10.0.0.0/8", "gateway"}
+		}, expectedKey: "app.trusted_proxies[1]"},
+		{name: "client ca without server cert", modify: func(c *config.Config) { c.App.TLS.CAFile = "/ca.crt" }, expectedKey: "app.tls.ca_file"},
+	}
+
+	base, err := config.LoadConfig(writeConfig(t, validConfig))
+	require.NoError(t, err)
+	require.NoError(t, base.Validate())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *base
+			cfg.Kafka.Brokers = append([]string(nil), base.Kafka.Brokers...)
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedKey)
+		})
+	}
+}
+
+func TestAppConfig_TrustedProxyPrefixes(t *testing.T) {
+	cfg := config.AppConfig{TrustedProxies: []string{"10.1.2.3/8", "127.0.0.1", "::1"}}
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("127.0.0.1/32"),
+		netip.MustParsePrefix("::1/128"),
+	}, cfg.TrustedProxyPrefixes())
+}
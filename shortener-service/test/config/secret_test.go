@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+const password = "hunter2"
+
+func TestLoadConfig_SecretFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "redis_password")
+	require.NoError(t, os.WriteFile(secret, []byte(password+"\n"), 0o600))
+	t.Setenv("APP_REDIS_PASSWORD_FILE", secret)
+
+	cfg, err := config.LoadConfig(writeConfig(t, validConfig))
+	require.NoError(t, err)
+	assert.Equal(t, password, string(cfg.Redis.Password))
+}
+
+func TestLoadConfig_SecretFileConflict(t *testing.T) {
+	t.Setenv("APP_REDIS_PASSWORD", password)
+	t.Setenv("APP_REDIS_PASSWORD_FILE", "/run/secrets/redis_password")
+
+	_, err := config.LoadConfig(writeConfig(t, validConfig))
+	assert.ErrorContains(t, err, "mutually exclusive")
+}
+
+func TestLoadConfig_SecretFileMissing(t *testing.T) {
+	t.Setenv("APP_CODEGEN_SALT_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := config.LoadConfig(writeConfig(t, validConfig))
+	assert.Error(t, err)
+}
+
+func TestSecret_Redacted(t *testing.T) {
+	t.Setenv("APP_REDIS_PASSWORD", password)
+	cfg, err := config.LoadConfig(writeConfig(t, validConfig))
+	require.NoError(t, err)
+	require.Equal(t, password, string(cfg.Redis.Password))
+
+	var logged bytes.Buffer
+	logger := zap.New(zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		zapcore.AddSync(&logged),
+		zap.DebugLevel,
+	))
+	logger.Info("service started", zap.Any("config", cfg))
+
+	asJSON, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	asYAML, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+
+	dumps := map[string]string{
+		"log":  logged.String(),
+		"json": string(asJSON),
+		"yaml": string(asYAML),
+		"fmt":  fmt.Sprintf("%v %+v %#v", *cfg, *cfg, *cfg),
+	}
+	for name, dump := range dumps {
+		assert.NotContains(t, dump, password, name)
+		assert.Contains(t, dump, "[REDACTED]", name)
+	}
+}
+
+func TestLoadConfig_NestedSecretFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "kafka_password")
+	require.NoError(t, os.WriteFile(secret, []byte(password), 0o600))
+	t.Setenv("APP_KAFKA_SASL_PASSWORD_FILE", secret)
+
+	cfg, err := config.LoadConfig(writeConfig(t, validConfig+`
+  sasl:
+    mechanism: "scram-sha-512"
+    username: "shortener"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, password, string(cfg.Kafka.SASL.Password))
+	assert.Equal(t, "[REDACTED]", cfg.Kafka.SASL.Password.String())
+}
@@ -0,0 +1,184 @@
+package blocklist_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/blocklist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const rules = `
+# phishing
+evil.com
+*.phish.example
+203.0.113.7
+198.51.100.0/24
+2001:db8::/32
+docs.example.com/forms/*
+`
+
+func TestRules_Match(t *testing.T) {
+	parsed, err := blocklist.Parse(strings.NewReader(rules))
+	require.NoError(t, err)
+	assert.Equal(t, 6, parsed.Len())
+
+	tests := []struct {
+		name           string
+		rawURL         string
+		expectedReason blocklist.Reason
+	}{
+		{name: "allowed", rawURL: "https://example.com/page"},
+		{name: "exact domain", rawURL: "https://evil.com/login", expectedReason: blocklist.ReasonDomain},
+		{name: "exact domain is case insensitive", rawURL: "https://EVIL.com.", expectedReason: blocklist.ReasonDomain},
+		{name: "exact domain does not cover subdomains", rawURL: "https://www.evil.com"},
+		{name: "wildcard subdomain", rawURL: "https://login.phish.example", expectedReason: blocklist.ReasonDomain},
+		{name: "nested wildcard subdomain", rawURL: "https://a.b.phish.example:8443/", expectedReason: blocklist.ReasonDomain},
+		{name: "wildcard does not cover apex", rawURL: "https://phish.example"},
+		{name: "wildcard needs a label boundary", rawURL: "https://notphish.example"},
+		{name: "ip literal", rawURL: "http://203.0.113.7/", expectedReason: blocklist.ReasonIP},
+		{name: "ip in cidr", rawURL: "http://198.51.100.42:8080/x", expectedReason: blocklist.ReasonIP},
+		{name: "ipv4-mapped ipv6 in cidr", rawURL: "http://[::ffff:198.51.100.42]/", expectedReason: blocklist.ReasonIP},
+		{name: "ipv6 in cidr", rawURL: "http://[2001:db8::1]/", expectedReason: blocklist.ReasonIP},
+		{name: "ip outside cidr", rawURL: "http://198.51.101.1/"},
+		{name: "decimal ip", rawURL: "http://3405803783/", expectedReason: blocklist.ReasonIP},
+		{name: "hexadecimal ip", rawURL: "http://0xCB007107/", expectedReason: blocklist.ReasonIP},
+		{name: "octal ip", rawURL: "http://0313.0.0161.07/", expectedReason: blocklist.ReasonIP},
+		{name: "short ip", rawURL: "http://203.0.28935/", expectedReason: blocklist.ReasonIP},
+		{name: "short ip in cidr", rawURL: "http://198.51.25642/", expectedReason: blocklist.ReasonIP},
+		{name: "numeric labels in a domain", rawURL: "http://203.0.113.7.example/"},
+		{name: "url pattern", rawURL: "https://docs.example.com/forms/d/abc", expectedReason: blocklist.ReasonURLPattern},
+		{name: "url pattern other path", rawURL: "https://docs.example.com/document/d/abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.rawURL)
+			require.NoError(t, err)
+
+			blocked := parsed.Match(u)
+
+			if tt.expectedReason == "" {
+				assert.Nil(t, blocked)
+			} else {
+				require.NotNil(t, blocked)
+				assert.Equal(t, tt.expectedReason, blocked.Reason)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		"evil.*.com",
+		"*.",
+		"*.*.evil.com",
+	}
+
+	for _, rule := range tests {
+		t.Run(rule, func(t *testing.T) {
+			_, err := blocklist.Parse(strings.NewReader(rule))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBlocklist_Check(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0o600))
+
+	list, err := blocklist.New(path, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	var blocked *blocklist.BlockedError
+	assert.ErrorAs(t, list.Check("https://evil.com"), &blocked)
+	assert.NoError(t, list.Check("https://example.com"))
+	assert.ErrorIs(t, list.Check("not a url"), blocklist.ErrInvalidURL)
+	assert.ErrorAs(t, list.Check("http://0xcb.0.0x71.7/"), &blocked, "numeric hosts are normalized")
+
+	for _, rawURL := range []string{"http://256.0.0.1/", "http://1.2.3.4.5/", "http://4294967296/", "http://1.0x1g.3.4/", "http://example.09/"} {
+		assert.ErrorIs(t, list.Check(rawURL), blocklist.ErrInvalidURL, rawURL)
+	}
+}
+
+func TestBlocklist_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("evil.com\n"), 0o600))
+
+	list, err := blocklist.New(path, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, list.Watch(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool {
+		// Keep rewriting until the watcher is set up and picks it up.
+		require.NoError(t, os.WriteFile(path, []byte("evil.com\nworse.com\n"), 0o600))
+		return list.Check("https://worse.com") != nil
+	}, 5*time.Second, 250*time.Millisecond)
+
+	// A broken file keeps the previous rules.
+	require.NoError(t, os.WriteFile(path, []byte("bad.*.com\n"), 0o600))
+	time.Sleep(300 * time.Millisecond)
+	assert.Error(t, list.Check("https://worse.com"))
+	assert.Error(t, list.Check("https://evil.com"))
+}
+
+func TestBlocklist_WatchSymlinkSwap(t *testing.T) {
+	// Lay the file out like a Kubernetes ConfigMap volume: the path goes
+	// through the ..data symlink, which is swapped atomically on updates.
+	dir := t.TempDir()
+	writeVersion := func(version, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version, "blocklist.txt"), []byte(content), 0o600))
+	}
+	writeVersion("..v1", "evil.com\n")
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	path := filepath.Join(dir, "blocklist.txt")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "blocklist.txt"), path))
+
+	list, err := blocklist.New(path, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, list.Check("https://worse.com"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, list.Watch(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	version := 1
+	require.Eventually(t, func() bool {
+		// Keep swapping until the watcher is set up and picks it up.
+		old := fmt.Sprintf("..v%d", version)
+		version++
+		current := fmt.Sprintf("..v%d", version)
+		writeVersion(current, "evil.com\nworse.com\n")
+		require.NoError(t, os.Symlink(current, filepath.Join(dir, "..data_tmp")))
+		require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, old)))
+		return list.Check("https://worse.com") != nil
+	}, 5*time.Second, 250*time.Millisecond)
+	assert.Error(t, list.Check("https://evil.com"))
+}
@@ -0,0 +1,179 @@
+package certs_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/certs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for commonName, valid for
+// localhost.
+func (a *authority) issue(t *testing.T, commonName string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// serverName performs a handshake against config and returns the common
+// name of the certificate the server presented.
+func serverName(t *testing.T, config *tls.Config, roots *x509.CertPool, client *tls.Certificate) (string, error) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := tls.Server(serverConn, config)
+	go func() { _ = server.Handshake() }()
+
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost", NextProtos: []string{"h2"}}
+	if client != nil {
+		clientConfig.Certificates = []tls.Certificate{*client}
+	}
+	conn := tls.Client(clientConn, clientConfig)
+	if err := conn.Handshake(); err != nil {
+		return "", err
+	}
+	// The server checks the client certificate after the client finishes.
+	if err := conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		return "", err
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			return "", err
+		}
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestReloader_Rotation(t *testing.T) {
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writePair := func(commonName string) {
+		certPEM, keyPEM := ca.issue(t, commonName)
+		require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+		require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	}
+	writePair("first")
+
+	reloader, err := certs.NewReloader(certFile, keyFile, "", zap.NewNop())
+	require.NoError(t, err)
+	config := reloader.ServerConfig(tls.NoClientCert)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	name, err := serverName(t, config, roots, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first", name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = reloader.Watch(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	writePair("second")
+	assert.Eventually(t, func() bool {
+		name, err := serverName(t, config, roots, nil)
+		return err == nil && name == "second"
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestReloader_ClientCertificates(t *testing.T) {
+	ca, other := newAuthority(t), newAuthority(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	certPEM, keyPEM := ca.issue(t, "server")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+
+	reloader, err := certs.NewReloader(certFile, keyFile, caFile, zap.NewNop())
+	require.NoError(t, err)
+	config := reloader.ServerConfig(tls.RequireAndVerifyClientCert)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert := func(a *authority) *tls.Certificate {
+		certPEM, keyPEM := a.issue(t, "client")
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		return &cert
+	}
+
+	_, err = serverName(t, config, roots, clientCert(ca))
+	assert.NoError(t, err)
+	_, err = serverName(t, config, roots, clientCert(other))
+	assert.Error(t, err, "client certificate from an unknown ca")
+	_, err = serverName(t, config, roots, nil)
+	assert.Error(t, err, "missing client certificate")
+}
+
+func TestNewReloader_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	_, err := certs.NewReloader(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), "", zap.NewNop())
+	assert.Error(t, err)
+
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+	_, err = certs.LoadCAs(empty)
+	assert.Error(t, err)
+}
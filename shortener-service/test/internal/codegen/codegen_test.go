@@ -0,0 +1,160 @@
+package codegen_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/codegen"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomGenerator(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		length int
+	}{
+		{name: "short code", length: 4},
+		{name: "default length", length: 8},
+		{name: "long code", length: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := codegen.NewRandomGenerator(tt.length)
+			require.NoError(t, err)
+
+			seen := make(map[string]bool)
+			for range 100 {
+				code, err := gen.Generate(ctx, domain.NewURL("https://example.com"))
+				require.NoError(t, err)
+				assert.Len(t, code, tt.length)
+				assert.Regexp(t, `^[0-9A-Za-z]+$`, code)
+				seen[code] = true
+			}
+			assert.Greater(t, len(seen), 90, "codes must be random")
+		})
+	}
+
+	_, err := codegen.NewRandomGenerator(0)
+	assert.ErrorIs(t, err, codegen.ErrInvalidLength)
+}
+
+func TestCounterGenerator(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+
+	gen, err := codegen.NewCounterGenerator(db, "codegen:counter", 6, "salt")
+	require.NoError(t, err)
+
+	mock.ExpectIncr("codegen:counter").SetVal(1)
+	mock.ExpectIncr("codegen:counter").SetVal(2)
+
+	first, err := gen.Generate(ctx, domain.NewURL("https://example.com"))
+	require.NoError(t, err)
+	second, err := gen.Generate(ctx, domain.NewURL("https://example.com"))
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Len(t, first, 6)
+	assert.Len(t, second, 6)
+	assert.NotEqual(t, first, second)
+
+	for _, tt := range []struct {
+		code     string
+		expected int64
+	}{
+		{code: first, expected: 1},
+		{code: second, expected: 2},
+	} {
+		n, err := gen.Decode(tt.code)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, n, "decode must invert encode")
+	}
+
+	for _, n := range []int64{0, 42, 1 << 20, 56800235583} {
+		code, err := gen.Encode(n)
+		require.NoError(t, err)
+		decoded, err := gen.Decode(code)
+		require.NoError(t, err)
+		assert.Equal(t, n, decoded)
+	}
+
+	_, err = gen.Encode(56800235584)
+	assert.ErrorIs(t, err, codegen.ErrKeyspaceExhausted)
+
+	_, err = gen.Decode("abc")
+	assert.ErrorIs(t, err, codegen.ErrInvalidCode)
+
+	other, err := codegen.NewCounterGenerator(db, "codegen:counter", 6, "pepper")
+	require.NoError(t, err)
+	code, err := other.Encode(1)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, code, "salt must change the permutation")
+}
+
+func TestHashGenerator(t *testing.T) {
+	ctx := context.Background()
+	gen, err := codegen.NewHashGenerator(8)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{name: "same url", a: "https://example.com/path", b: "https://example.com/path", equal: true},
+		{name: "case of scheme and host", a: "HTTPS://Example.COM/path", b: "https://example.com/path", equal: true},
+		{name: "default port", a: "https://example.com:443/path", b: "https://example.com/path", equal: true},
+		{name: "empty path", a: "https://example.com", b: "https://example.com/", equal: true},
+		{name: "query order", a: "https://example.com/?b=2&a=1", b: "https://example.com/?a=1&b=2", equal: true},
+		{name: "fragment", a: "https://example.com/#top", b: "https://example.com/", equal: false},
+		{name: "different path", a: "https://example.com/a", b: "https://example.com/b", equal: false},
+		{name: "case of path", a: "https://example.com/A", b: "https://example.com/a", equal: false},
+		{name: "non-default port", a: "https://example.com:8443/", b: "https://example.com/", equal: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := gen.Generate(ctx, domain.NewURL(tt.a))
+			require.NoError(t, err)
+			b, err := gen.Generate(ctx, domain.NewURL(tt.b))
+			require.NoError(t, err)
+
+			assert.Len(t, a, 8)
+			if tt.equal {
+				assert.Equal(t, a, b)
+			} else {
+				assert.NotEqual(t, a, b)
+			}
+		})
+	}
+}
+
+func TestHashGenerator_Salted(t *testing.T) {
+	ctx := context.Background()
+	gen, err := codegen.NewHashGenerator(8)
+	require.NoError(t, err)
+	url := domain.NewURL("https://example.com/path")
+
+	plain, err := gen.Generate(ctx, url)
+	require.NoError(t, err)
+	unsalted, err := gen.GenerateSalted(ctx, url, "")
+	require.NoError(t, err)
+	first, err := gen.GenerateSalted(ctx, url, "1")
+	require.NoError(t, err)
+	again, err := gen.GenerateSalted(ctx, url, "1")
+	require.NoError(t, err)
+	second, err := gen.GenerateSalted(ctx, url, "2")
+	require.NoError(t, err)
+
+	assert.Equal(t, plain, unsalted)
+	assert.Equal(t, first, again)
+	assert.Len(t, first, 8)
+	assert.NotEqual(t, plain, first)
+	assert.NotEqual(t, first, second)
+}
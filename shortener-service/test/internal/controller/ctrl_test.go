@@ -0,0 +1,746 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/blocklist"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/codegen"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRepo struct {
+	urls map[string]*domain.URL
+}
+
+func newFakeRepo(urls ...*domain.URL) *fakeRepo {
+	repo := &fakeRepo{urls: make(map[string]*domain.URL)}
+	for _, u := range urls {
+		repo.urls[u.ShortURL] = u
+	}
+	return repo
+}
+
+func (r *fakeRepo) Save(_ context.Context, url *domain.URL, _ time.Duration) error {
+	if _, ok := r.urls[url.ShortURL]; ok {
+		return repository.ErrURLExists
+	}
+	r.urls[url.ShortURL] = url
+	return nil
+}
+
+func (r *fakeRepo) Get(_ context.Context, shortURL string) (*domain.URL, error) {
+	url, ok := r.urls[shortURL]
+	if !ok {
+		return nil, repository.ErrURLNotFound
+	}
+	return url, nil
+}
+
+func (r *fakeRepo) Delete(_ context.Context, shortURL string, retention time.Duration, cond domain.Precondition) error {
+	url, ok := r.urls[shortURL]
+	if !ok || url.Status == domain.StatusDeleted {
+		return repository.ErrURLNotFound
+	}
+	if (cond.OriginalURL != "" && cond.OriginalURL != url.OriginalURL) ||
+		(cond.Version != 0 && cond.Version != url.Version) {
+		return repository.ErrURLChanged
+	}
+	if retention <= 0 {
+		delete(r.urls, shortURL)
+		return nil
+	}
+	url.Status = domain.StatusDeleted
+	return nil
+}
+
+func (r *fakeRepo) Restore(_ context.Context, shortURL string) error {
+	url, ok := r.urls[shortURL]
+	if !ok {
+		return repository.ErrURLNotFound
+	}
+	if url.Status != domain.StatusDeleted {
+		return repository.ErrURLNotDeleted
+	}
+	url.Status = domain.StatusActive
+	if url.MaxClicks > 0 && url.ClicksLeft <= 0 {
+		url.ClicksLeft = url.MaxClicks
+	}
+	return nil
+}
+
+func (r *fakeRepo) SetStatus(_ context.Context, shortURL string, status domain.Status) error {
+	url, ok := r.urls[shortURL]
+	if !ok || url.Status == domain.StatusDeleted {
+		return repository.ErrURLNotFound
+	}
+	url.Status = status
+	return nil
+}
+
+func (r *fakeRepo) ConsumeClick(_ context.Context, shortURL string, retention time.Duration) (int64, error) {
+	url, ok := r.urls[shortURL]
+	if !ok {
+		return 0, repository.ErrURLNotFound
+	}
+	if url.Status == domain.StatusDeleted || url.ClicksLeft <= 0 {
+		return 0, repository.ErrURLExhausted
+	}
+	url.ClicksLeft--
+	if url.ClicksLeft == 0 {
+		if retention <= 0 {
+			delete(r.urls, shortURL)
+		} else {
+			url.Status = domain.StatusDeleted
+		}
+	}
+	return url.ClicksLeft, nil
+}
+
+// sequenceGenerator hands out codes in order.
+type sequenceGenerator struct {
+	codes []string
+}
+
+func (g *sequenceGenerator) Generate(_ context.Context, _ *domain.URL) (string, error) {
+	code := g.codes[0]
+	g.codes = g.codes[1:]
+	return code, nil
+}
+
+type fakeLimiter struct {
+	limit    int
+	attempts map[string]int
+}
+
+func (l *fakeLimiter) Allow(_ context.Context, shortURL, client string) (bool, error) {
+	l.attempts[shortURL+"/"+client]++
+	return l.attempts[shortURL+"/"+client] <= l.limit, nil
+}
+
+func (l *fakeLimiter) Reset(_ context.Context, shortURL, client string) error {
+	delete(l.attempts, shortURL+"/"+client)
+	return nil
+}
+
+func TestController_GetPasswordProtected(t *testing.T) {
+	ctx := context.Background()
+
+	protected := &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}
+	require.NoError(t, protected.SetPassword("secret"))
+
+	tests := []struct {
+		name          string
+		attempts      []string
+		expectedError error
+	}{
+		{
+			name:     "correct password",
+			attempts: []string{"secret"},
+		},
+		{
+			name:          "missing password",
+			attempts:      []string{""},
+			expectedError: controller.ErrPasswordRequired,
+		},
+		{
+			name:          "wrong password",
+			attempts:      []string{"guess"},
+			expectedError: controller.ErrInvalidPassword,
+		},
+		{
+			name:     "correct password after failures",
+			attempts: []string{"guess", "guess", "secret"},
+		},
+		{
+			name:          "throttled after too many failures",
+			attempts:      []string{"guess", "guess", "guess", "secret"},
+			expectedError: controller.ErrTooManyAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeLimiter{limit: 3, attempts: make(map[string]int)}
+			ctrl := controller.NewController(
+				newFakeRepo(protected),
+				events.NopPublisher{},
+				nil,
+				zap.NewNop(),
+				controller.WithFailureLimiter(limiter),
+			)
+
+			var (
+				redirect *domain.Redirect
+				err      error
+			)
+			for _, password := range tt.attempts {
+				redirect, err = ctrl.Get(ctx, "abc123", domain.Visit{Password: password})
+			}
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "https://example.com", redirect.Location)
+			}
+		})
+	}
+}
+
+func TestController_GetPasswordThrottledPerClient(t *testing.T) {
+	ctx := context.Background()
+
+	protected := &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}
+	require.NoError(t, protected.SetPassword("secret"))
+	ctrl := controller.NewController(
+		newFakeRepo(protected),
+		events.NopPublisher{},
+		nil,
+		zap.NewNop(),
+		controller.WithFailureLimiter(&fakeLimiter{limit: 3, attempts: make(map[string]int)}),
+	)
+
+	attacker := domain.Visit{Password: "guess", ClientIP: "203.0.113.7", VisitorID: "rotating"}
+	for range 3 {
+		_, err := ctrl.Get(ctx, "abc123", attacker)
+		require.ErrorIs(t, err, controller.ErrInvalidPassword)
+	}
+	attacker.VisitorID = "another"
+	_, err := ctrl.Get(ctx, "abc123", attacker)
+	assert.ErrorIs(t, err, controller.ErrTooManyAttempts, "the client IP wins over the visitor ID")
+
+	_, err = ctrl.Get(ctx, "abc123", domain.Visit{Password: "secret", ClientIP: "198.51.100.1"})
+	assert.NoError(t, err, "other clients are not locked out")
+}
+
+func TestController_GetClickLimited(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		maxClicks int64
+		resolves  int
+		succeeded int
+	}{
+		{name: "one-time link", maxClicks: 1, resolves: 3, succeeded: 1},
+		{name: "limit not reached", maxClicks: 5, resolves: 3, succeeded: 3},
+		{name: "limit reached", maxClicks: 3, resolves: 5, succeeded: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(&domain.URL{
+				ShortURL:    "abc123",
+				OriginalURL: "https://example.com",
+				MaxClicks:   tt.maxClicks,
+				ClicksLeft:  tt.maxClicks,
+			})
+			ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+
+			succeeded := 0
+			for range tt.resolves {
+				_, err := ctrl.Get(ctx, "abc123", domain.Visit{})
+				if err == nil {
+					succeeded++
+				} else {
+					assert.ErrorIs(t, err, repository.ErrURLNotFound)
+				}
+			}
+			assert.Equal(t, tt.succeeded, succeeded)
+		})
+	}
+}
+
+func TestController_ExhaustedURLIsRetained(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(&domain.URL{
+		ShortURL:    "abc123",
+		OriginalURL: "https://example.com",
+		MaxClicks:   1,
+		ClicksLeft:  1,
+	})
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop(),
+		controller.WithDeleteRetention(time.Hour))
+
+	_, err := ctrl.Get(ctx, "abc123", domain.Visit{})
+	require.NoError(t, err)
+	_, err = ctrl.Get(ctx, "abc123", domain.Visit{})
+	assert.ErrorIs(t, err, controller.ErrURLDeleted, "the used up URL is kept as deleted")
+
+	require.NoError(t, ctrl.Restore(ctx, "abc123"))
+	_, err = ctrl.Get(ctx, "abc123", domain.Visit{})
+	assert.NoError(t, err, "a restored URL gets its clicks back")
+}
+
+func TestController_ExhaustedURLInStaleCache(t *testing.T) {
+	// The repository still shows the URL as active, as a cache might after
+	// its last click elsewhere.
+	repo := newFakeRepo(&domain.URL{
+		ShortURL:    "abc123",
+		OriginalURL: "https://example.com",
+		MaxClicks:   1,
+		ClicksLeft:  0,
+	})
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+
+	_, err := ctrl.Get(context.Background(), "abc123", domain.Visit{})
+	assert.ErrorIs(t, err, controller.ErrURLDeleted)
+}
+
+func TestController_SaveSchedule(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		url           *domain.URL
+		ttl           time.Duration
+		expectedError error
+	}{
+		{
+			name: "ttl only",
+			url:  &domain.URL{OriginalURL: "https://example.com"},
+			ttl:  time.Hour,
+		},
+		{
+			name: "expires_at only",
+			url:  &domain.URL{OriginalURL: "https://example.com", ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name: "activation window",
+			url: &domain.URL{
+				OriginalURL: "https://example.com",
+				NotBefore:   now.Add(time.Hour),
+				ExpiresAt:   now.Add(2 * time.Hour),
+			},
+		},
+		{
+			name:          "ttl and expires_at",
+			url:           &domain.URL{OriginalURL: "https://example.com", ExpiresAt: now.Add(time.Hour)},
+			ttl:           time.Hour,
+			expectedError: controller.ErrConflictingExpiry,
+		},
+		{
+			name:          "expires_at in the past",
+			url:           &domain.URL{OriginalURL: "https://example.com", ExpiresAt: now.Add(-time.Hour)},
+			expectedError: controller.ErrExpiryInPast,
+		},
+		{
+			name:          "negative ttl",
+			url:           &domain.URL{OriginalURL: "https://example.com"},
+			ttl:           -time.Hour,
+			expectedError: controller.ErrNegativeTTL,
+		},
+		{
+			name: "not_before after expiry",
+			url: &domain.URL{
+				OriginalURL: "https://example.com",
+				NotBefore:   now.Add(2 * time.Hour),
+			},
+			ttl:           time.Hour,
+			expectedError: controller.ErrNotBeforeExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := codegen.NewRandomGenerator(8)
+			require.NoError(t, err)
+			ctrl := controller.NewController(newFakeRepo(), events.NopPublisher{}, gen, zap.NewNop())
+
+			err = ctrl.Save(ctx, tt.url, tt.ttl)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				if tt.ttl > 0 {
+					assert.WithinDuration(t, now.Add(tt.ttl), tt.url.ExpiresAt, time.Second)
+				}
+			}
+		})
+	}
+}
+
+func TestController_GetActivationWindow(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		notBefore     time.Time
+		expectedError error
+	}{
+		{name: "no activation time"},
+		{name: "active", notBefore: time.Now().Add(-time.Minute)},
+		{name: "not active yet", notBefore: time.Now().Add(time.Minute), expectedError: controller.ErrNotYetActive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(&domain.URL{
+				ShortURL:    "abc123",
+				OriginalURL: "https://example.com",
+				NotBefore:   tt.notBefore,
+			})
+			ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+
+			_, err := ctrl.Get(ctx, "abc123", domain.Visit{})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestController_SaveRetriesTakenCodes(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(
+		&domain.URL{ShortURL: "taken1", OriginalURL: "https://example.com/1"},
+		&domain.URL{ShortURL: "taken2", OriginalURL: "https://example.com/2"},
+	)
+	gen := &sequenceGenerator{codes: []string{"taken1", "taken2", "free"}}
+	ctrl := controller.NewController(repo, events.NopPublisher{}, gen, zap.NewNop())
+
+	url := &domain.URL{OriginalURL: "https://example.com"}
+	require.NoError(t, ctrl.Save(ctx, url, 0))
+
+	assert.Equal(t, "free", url.ShortURL)
+	assert.Equal(t, "https://example.com/1", repo.urls["taken1"].OriginalURL)
+}
+
+func TestController_SaveReusesHashedCodes(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo()
+	publisher := events.NewMemoryPublisher()
+	gen, err := codegen.NewHashGenerator(7)
+	require.NoError(t, err)
+	ctrl := controller.NewController(repo, publisher, gen, zap.NewNop())
+
+	first := &domain.URL{OriginalURL: "https://example.com/page?b=2&a=1"}
+	require.NoError(t, ctrl.Save(ctx, first, 0))
+
+	second := &domain.URL{OriginalURL: "HTTPS://Example.com:443/page?a=1&b=2"}
+	require.NoError(t, ctrl.Save(ctx, second, 0), "the same destination gets the same link")
+	assert.Equal(t, first.ShortURL, second.ShortURL)
+	assert.Equal(t, first.OriginalURL, second.OriginalURL)
+	assert.Len(t, repo.urls, 1)
+
+	protected := &domain.URL{OriginalURL: "https://example.com/page?a=1&b=2", PasswordHash: "hash"}
+	require.NoError(t, ctrl.Save(ctx, protected, 0), "links with settings get a code of their own")
+	assert.NotEqual(t, first.ShortURL, protected.ShortURL)
+	limited := &domain.URL{OriginalURL: "https://example.com/page?a=1&b=2", MaxClicks: 3}
+	require.NoError(t, ctrl.Save(ctx, limited, 0))
+	assert.NotEqual(t, protected.ShortURL, limited.ShortURL)
+
+	require.NoError(t, ctrl.Disable(ctx, first.ShortURL))
+	replacement := &domain.URL{OriginalURL: first.OriginalURL}
+	require.NoError(t, ctrl.Save(ctx, replacement, 0), "disabled links are not handed out")
+	assert.NotEqual(t, first.ShortURL, replacement.ShortURL)
+
+	again := &domain.URL{OriginalURL: first.OriginalURL}
+	require.NoError(t, ctrl.Save(ctx, again, 0))
+	assert.Equal(t, replacement.ShortURL, again.ShortURL, "the replacement is shared in turn")
+	assert.Len(t, repo.urls, 4)
+
+	withFragment := &domain.URL{OriginalURL: "https://example.com/page?a=1&b=2#reviews"}
+	require.NoError(t, ctrl.Save(ctx, withFragment, 0))
+	assert.NotEqual(t, replacement.ShortURL, withFragment.ShortURL, "fragments tell destinations apart")
+
+	assert.Eventually(t, func() bool { return len(publisher.Events()) == 6 }, time.Second, 10*time.Millisecond)
+	var names []string
+	for _, event := range publisher.Events() {
+		names = append(names, event.Name)
+	}
+	assert.ElementsMatch(t, []string{"url_created", "url_created", "url_created", "url_disabled", "url_created", "url_created"},
+		names, "reusing a link creates nothing")
+}
+
+func TestController_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		retention     time.Duration
+		actions       func(ctrl *controller.Controller) error
+		expectedError error
+	}{
+		{
+			name: "disabled",
+			actions: func(ctrl *controller.Controller) error {
+				return ctrl.Disable(ctx, "abc123")
+			},
+			expectedError: controller.ErrURLDisabled,
+		},
+		{
+			name: "enabled again",
+			actions: func(ctrl *controller.Controller) error {
+				if err := ctrl.Disable(ctx, "abc123"); err != nil {
+					return err
+				}
+				return ctrl.Enable(ctx, "abc123")
+			},
+		},
+		{
+			name:      "soft deleted",
+			retention: time.Hour,
+			actions: func(ctrl *controller.Controller) error {
+				return ctrl.Delete(ctx, "abc123", domain.Precondition{})
+			},
+			expectedError: controller.ErrURLDeleted,
+		},
+		{
+			name:      "restored",
+			retention: time.Hour,
+			actions: func(ctrl *controller.Controller) error {
+				if err := ctrl.Delete(ctx, "abc123", domain.Precondition{}); err != nil {
+					return err
+				}
+				return ctrl.Restore(ctx, "abc123")
+			},
+		},
+		{
+			name: "deleted without retention",
+			actions: func(ctrl *controller.Controller) error {
+				return ctrl.Delete(ctx, "abc123", domain.Precondition{})
+			},
+			expectedError: repository.ErrURLNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(&domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+			ctrl := controller.NewController(
+				repo,
+				events.NopPublisher{},
+				nil,
+				zap.NewNop(),
+				controller.WithDeleteRetention(tt.retention),
+			)
+
+			require.NoError(t, tt.actions(ctrl))
+			_, err := ctrl.Get(ctx, "abc123", domain.Visit{})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestController_RestoreNotDeleted(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(&domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+
+	assert.ErrorIs(t, ctrl.Restore(ctx, "abc123"), repository.ErrURLNotDeleted)
+}
+
+func TestController_InvalidCode(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(&domain.URL{ShortURL: "codegen:counter", OriginalURL: "https://example.com"})
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+	code := "codegen:counter"
+
+	assert.ErrorIs(t, ctrl.Delete(ctx, code, domain.Precondition{}), domain.ErrInvalidCode)
+	assert.ErrorIs(t, ctrl.Restore(ctx, code), domain.ErrInvalidCode)
+	assert.ErrorIs(t, ctrl.Disable(ctx, code), domain.ErrInvalidCode)
+	assert.ErrorIs(t, ctrl.Enable(ctx, code), domain.ErrInvalidCode)
+	_, err := ctrl.Inspect(ctx, code)
+	assert.ErrorIs(t, err, domain.ErrInvalidCode)
+	_, err = ctrl.Lookup(ctx, code, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidCode)
+	_, err = ctrl.Get(ctx, code, domain.Visit{})
+	assert.ErrorIs(t, err, domain.ErrInvalidCode)
+
+	assert.Equal(t, domain.Status(""), repo.urls[code].Status)
+}
+
+type fakeChecker struct {
+	blocked string
+}
+
+func (c fakeChecker) Check(rawURL string) error {
+	if rawURL == c.blocked {
+		return &blocklist.BlockedError{Reason: blocklist.ReasonDomain, Rule: "evil.com"}
+	}
+	return nil
+}
+
+func TestController_SaveBlockedDestination(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo()
+	gen, err := codegen.NewRandomGenerator(8)
+	require.NoError(t, err)
+	ctrl := controller.NewController(
+		repo,
+		events.NopPublisher{},
+		gen,
+		zap.NewNop(),
+		controller.WithDestinationChecker(fakeChecker{blocked: "https://evil.com"}),
+	)
+
+	var blocked *blocklist.BlockedError
+	assert.ErrorAs(t, ctrl.Save(ctx, &domain.URL{OriginalURL: "https://evil.com"}, 0), &blocked)
+	assert.Empty(t, repo.urls)
+
+	assert.NoError(t, ctrl.Save(ctx, &domain.URL{OriginalURL: "https://example.com"}, 0))
+	assert.Len(t, repo.urls, 1)
+}
+
+func TestController_GetRules(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(&domain.URL{
+		ShortURL:    "app",
+		OriginalURL: "https://example.com/app",
+		Rules: []domain.Rule{
+			{Platform: domain.PlatformIOS, Destination: "https://apps.apple.com/app/id1"},
+			{Platform: domain.PlatformAndroid, Destination: "https://play.google.com/store/apps/details?id=app"},
+		},
+	})
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+
+	redirect, err := ctrl.Get(ctx, "app", domain.Visit{
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://apps.apple.com/app/id1", redirect.Location)
+
+	redirect, err = ctrl.Get(ctx, "app", domain.Visit{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/app", redirect.Location)
+}
+
+func TestController_SaveInvalidRule(t *testing.T) {
+	ctx := context.Background()
+	gen, err := codegen.NewRandomGenerator(8)
+	require.NoError(t, err)
+	ctrl := controller.NewController(newFakeRepo(), events.NopPublisher{}, gen, zap.NewNop())
+
+	err = ctrl.Save(ctx, &domain.URL{
+		OriginalURL: "https://example.com",
+		Rules:       []domain.Rule{{Destination: "https://example.com/de"}},
+	}, 0)
+
+	assert.ErrorIs(t, err, domain.ErrInvalidRule)
+}
+
+func TestController_Domains(t *testing.T) {
+	ctx := context.Background()
+	domains, err := domain.NewDomains("https://sho.rt", []string{"go.example.com"})
+	require.NoError(t, err)
+
+	repo := newFakeRepo()
+	gen := &sequenceGenerator{codes: []string{"code1", "code2", "code3"}}
+	ctrl := controller.NewController(repo, events.NopPublisher{}, gen, zap.NewNop(), controller.WithDomains(domains))
+
+	plain := domain.NewURL("https://example.com")
+	require.NoError(t, ctrl.Save(ctx, plain, 0))
+	assert.Equal(t, "sho.rt", plain.Domain)
+	assert.Equal(t, "https://sho.rt/code1", ctrl.ShortLink(plain))
+
+	branded := domain.NewURL("https://example.com")
+	branded.Domain = "Go.Example.com"
+	require.NoError(t, ctrl.Save(ctx, branded, 0))
+	assert.Equal(t, "https://go.example.com/code2", ctrl.ShortLink(branded))
+
+	unknown := domain.NewURL("https://example.com")
+	unknown.Domain = "evil.example.com"
+	assert.ErrorIs(t, ctrl.Save(ctx, unknown, 0), domain.ErrUnknownDomain)
+
+	_, err = ctrl.Get(ctx, "code2", domain.Visit{Host: "go.example.com"})
+	assert.NoError(t, err)
+	_, err = ctrl.Get(ctx, "code2", domain.Visit{Host: "sho.rt"})
+	assert.ErrorIs(t, err, repository.ErrURLNotFound)
+	_, err = ctrl.Lookup(ctx, "code2", "sho.rt")
+	assert.ErrorIs(t, err, repository.ErrURLNotFound)
+	_, err = ctrl.Get(ctx, "code2", domain.Visit{})
+	assert.NoError(t, err, "visits without a host are not checked")
+}
+
+func TestController_SaveAlias(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(&domain.URL{ShortURL: "taken", OriginalURL: "https://example.com/taken"})
+	ctrl := controller.NewController(repo, events.NopPublisher{}, &sequenceGenerator{}, zap.NewNop())
+
+	url := &domain.URL{ShortURL: "spring-sale", OriginalURL: "https://example.com"}
+	require.NoError(t, ctrl.Save(ctx, url, 0))
+	assert.Equal(t, "https://example.com", repo.urls["spring-sale"].OriginalURL)
+
+	err := ctrl.Save(ctx, &domain.URL{ShortURL: "taken", OriginalURL: "https://example.com"}, 0)
+	assert.ErrorIs(t, err, repository.ErrURLExists, "aliases are never regenerated")
+
+	err = ctrl.Save(ctx, &domain.URL{ShortURL: "no/slashes", OriginalURL: "https://example.com"}, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidAlias)
+}
+
+func TestController_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		shortURL      string
+		cond          domain.Precondition
+		expectedError error
+	}{
+		{name: "unconditional", shortURL: "abc123"},
+		{name: "missing URL", shortURL: "missing", expectedError: repository.ErrURLNotFound},
+		{name: "matching original URL", shortURL: "abc123", cond: domain.Precondition{OriginalURL: "https://example.com"}},
+		{name: "retargeted", shortURL: "abc123", cond: domain.Precondition{OriginalURL: "https://example.org"}, expectedError: repository.ErrURLChanged},
+		{name: "matching version", shortURL: "abc123", cond: domain.Precondition{Version: 2}},
+		{name: "stale version", shortURL: "abc123", cond: domain.Precondition{Version: 1}, expectedError: repository.ErrURLChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(&domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com", Status: domain.StatusActive, Version: 2})
+			ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop(),
+				controller.WithDeleteRetention(time.Hour))
+
+			err := ctrl.Delete(ctx, tt.shortURL, tt.cond)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Equal(t, domain.StatusActive, repo.urls["abc123"].Status, "nothing is deleted")
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, domain.StatusDeleted, repo.urls["abc123"].Status)
+			}
+		})
+	}
+}
+
+func TestController_PublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	publisher := events.NewMemoryPublisher()
+	gen := &sequenceGenerator{codes: []string{"abc123"}}
+	ctrl := controller.NewController(newFakeRepo(), publisher, gen, zap.NewNop())
+
+	require.NoError(t, ctrl.Save(ctx, &domain.URL{OriginalURL: "https://example.com"}, 0))
+	require.NoError(t, ctrl.Delete(ctx, "abc123", domain.Precondition{}))
+	assert.Error(t, ctrl.Delete(ctx, "missing", domain.Precondition{}))
+
+	names := func() []string {
+		var names []string
+		for _, event := range publisher.Events() {
+			assert.Equal(t, "abc123", event.ShortURL)
+			names = append(names, event.Name)
+		}
+		return names
+	}
+	assert.Eventually(t, func() bool { return len(publisher.Events()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"url_created", "url_deleted"}, names())
+}
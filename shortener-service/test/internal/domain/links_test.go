@@ -0,0 +1,68 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomains(t *testing.T) {
+	domains, err := domain.NewDomains("https://Sho.rt/s/", []string{"go.example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "sho.rt", domains.Default())
+
+	host, err := domains.Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, "sho.rt", host)
+
+	host, err = domains.Resolve("Go.Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "go.example.com", host)
+
+	_, err = domains.Resolve("evil.example.com")
+	assert.ErrorIs(t, err, domain.ErrUnknownDomain)
+
+	assert.Equal(t, "https://sho.rt/s/abc123", domains.Link(&domain.URL{ShortURL: "abc123"}))
+	assert.Equal(t, "https://go.example.com/s/abc123", domains.Link(&domain.URL{ShortURL: "abc123", Domain: "go.example.com"}))
+}
+
+func TestNewDomains_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		baseURL string
+		domains []string
+	}{
+		{name: "relative base url", baseURL: "sho.rt"},
+		{name: "unsupported scheme", baseURL: "ftp://sho.rt"},
+		{name: "domain with path", baseURL: "https://sho.rt", domains: []string{"go.example.com/s"}},
+		{name: "empty domain", baseURL: "https://sho.rt", domains: []string{""}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.NewDomains(tt.baseURL, tt.domains)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseShortLink(t *testing.T) {
+	tests := []struct {
+		link         string
+		expectedHost string
+		expectedCode string
+	}{
+		{link: "abc123", expectedCode: "abc123"},
+		{link: "https://sho.rt/abc123", expectedHost: "sho.rt", expectedCode: "abc123"},
+		{link: "http://Go.Example.com:8081/s/abc123", expectedHost: "go.example.com:8081", expectedCode: "abc123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			host, code := domain.ParseShortLink(tt.link)
+			assert.Equal(t, tt.expectedHost, host)
+			assert.Equal(t, tt.expectedCode, code)
+		})
+	}
+}
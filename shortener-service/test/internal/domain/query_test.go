@@ -0,0 +1,187 @@
+package domain_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		dst           url.Values
+		src           url.Values
+		mode          domain.MergeMode
+		expected      url.Values
+		expectedError error
+	}{
+		{
+			name:     "disjoint parameters",
+			dst:      url.Values{"a": {"1"}},
+			src:      url.Values{"b": {"2"}},
+			mode:     domain.MergeOverride,
+			expected: url.Values{"a": {"1"}, "b": {"2"}},
+		},
+		{
+			name:     "override replaces",
+			dst:      url.Values{"a": {"1"}, "b": {"2"}},
+			src:      url.Values{"a": {"3", "4"}},
+			mode:     domain.MergeOverride,
+			expected: url.Values{"a": {"3", "4"}, "b": {"2"}},
+		},
+		{
+			name:     "keep original ignores",
+			dst:      url.Values{"a": {"1"}},
+			src:      url.Values{"a": {"3"}, "c": {"5"}},
+			mode:     domain.MergeKeepOriginal,
+			expected: url.Values{"a": {"1"}, "c": {"5"}},
+		},
+		{
+			name:          "reject conflicts",
+			dst:           url.Values{"a": {"1"}},
+			src:           url.Values{"a": {"3"}},
+			mode:          domain.MergeRejectConflicts,
+			expectedError: domain.ErrQueryConflict,
+		},
+		{
+			name:     "reject conflicts allows equal values",
+			dst:      url.Values{"a": {"1"}},
+			src:      url.Values{"a": {"1"}, "b": {"2"}},
+			mode:     domain.MergeRejectConflicts,
+			expected: url.Values{"a": {"1"}, "b": {"2"}},
+		},
+		{
+			name:     "empty source",
+			dst:      url.Values{"a": {"1"}},
+			mode:     domain.MergeRejectConflicts,
+			expected: url.Values{"a": {"1"}},
+		},
+		{
+			name:          "unknown mode",
+			dst:           url.Values{"a": {"1"}},
+			src:           url.Values{"a": {"2"}},
+			mode:          "append",
+			expectedError: domain.ErrInvalidQueryOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := cloneValues(tt.dst)
+
+			merged, err := domain.MergeQuery(tt.dst, tt.src, tt.mode)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, merged)
+			}
+			assert.Equal(t, dst, tt.dst, "dst must not be modified")
+		})
+	}
+}
+
+func TestQueryOptions_Apply(t *testing.T) {
+	utm := map[string]string{"utm_source": "newsletter", "utm_medium": "email"}
+
+	tests := []struct {
+		name          string
+		options       domain.QueryOptions
+		location      string
+		query         string
+		expected      string
+		expectedError error
+	}{
+		{
+			name:     "incoming query dropped by default",
+			location: "https://example.com/page?id=1",
+			query:    "utm_source=x",
+			expected: "https://example.com/page?id=1",
+		},
+		{
+			name:     "passthrough",
+			options:  domain.QueryOptions{Passthrough: true},
+			location: "https://example.com/page",
+			query:    "utm_source=x&ref=abc",
+			expected: "https://example.com/page?ref=abc&utm_source=x",
+		},
+		{
+			name:     "passthrough overrides by default",
+			options:  domain.QueryOptions{Passthrough: true},
+			location: "https://example.com/page?id=1",
+			query:    "id=2",
+			expected: "https://example.com/page?id=2",
+		},
+		{
+			name:     "passthrough keeps original",
+			options:  domain.QueryOptions{Passthrough: true, Merge: domain.MergeKeepOriginal},
+			location: "https://example.com/page?id=1",
+			query:    "id=2&x=y",
+			expected: "https://example.com/page?id=1&x=y",
+		},
+		{
+			name:          "passthrough rejects conflicts",
+			options:       domain.QueryOptions{Passthrough: true, Merge: domain.MergeRejectConflicts},
+			location:      "https://example.com/page?id=1",
+			query:         "id=2",
+			expectedError: domain.ErrQueryConflict,
+		},
+		{
+			name:     "utm appended",
+			options:  domain.QueryOptions{UTM: utm},
+			location: "https://example.com/page?id=1#top",
+			expected: "https://example.com/page?id=1&utm_medium=email&utm_source=newsletter#top",
+		},
+		{
+			name:     "incoming utm overrides stored utm",
+			options:  domain.QueryOptions{Passthrough: true, UTM: utm},
+			location: "https://example.com/page",
+			query:    "utm_source=x",
+			expected: "https://example.com/page?utm_medium=email&utm_source=x",
+		},
+		{
+			name:     "stored utm kept over incoming",
+			options:  domain.QueryOptions{Passthrough: true, Merge: domain.MergeKeepOriginal, UTM: utm},
+			location: "https://example.com/page",
+			query:    "utm_source=x",
+			expected: "https://example.com/page?utm_medium=email&utm_source=newsletter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			location, err := tt.options.Apply(tt.location, query)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, location)
+			}
+		})
+	}
+}
+
+func TestQueryOptions_Validate(t *testing.T) {
+	assert.NoError(t, domain.QueryOptions{UTM: map[string]string{"utm_campaign": "spring"}}.Validate())
+	assert.ErrorIs(t, domain.QueryOptions{UTM: map[string]string{"ref": "x"}}.Validate(), domain.ErrInvalidQueryOptions)
+	assert.ErrorIs(t, domain.QueryOptions{Merge: "append"}.Validate(), domain.ErrInvalidQueryOptions)
+}
+
+func cloneValues(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+	clone := make(url.Values, len(values))
+	for key, v := range values {
+		clone[key] = append([]string(nil), v...)
+	}
+	return clone
+}
@@ -0,0 +1,136 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile"
+	desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0"
+)
+
+func TestURL_RedirectRules(t *testing.T) {
+	url := &domain.URL{
+		OriginalURL: "https://example.com",
+		Rules: []domain.Rule{
+			{Platform: domain.PlatformIOS, Language: "de", Destination: "https://apps.apple.com/de/app"},
+			{Platform: domain.PlatformIOS, Destination: "https://apps.apple.com/app"},
+			{Platform: domain.PlatformAndroid, Destination: "https://play.google.com/app"},
+			{Language: "pt-br", Destination: "https://example.com/pt-br"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		visit    domain.Visit
+		expected string
+	}{
+		{
+			name:     "no metadata falls back",
+			expected: "https://example.com",
+		},
+		{
+			name:     "platform",
+			visit:    domain.Visit{UserAgent: androidUA},
+			expected: "https://play.google.com/app",
+		},
+		{
+			name:     "first matching rule wins",
+			visit:    domain.Visit{UserAgent: iPhoneUA, AcceptLanguage: "de-DE,de;q=0.9"},
+			expected: "https://apps.apple.com/de/app",
+		},
+		{
+			name:     "later rule when language does not match",
+			visit:    domain.Visit{UserAgent: iPhoneUA, AcceptLanguage: "fr"},
+			expected: "https://apps.apple.com/app",
+		},
+		{
+			name:     "language on subtag boundary",
+			visit:    domain.Visit{UserAgent: desktopUA, AcceptLanguage: "pt-BR-x-custom"},
+			expected: "https://example.com/pt-br",
+		},
+		{
+			name:     "language range is not a subtag of the visitor's",
+			visit:    domain.Visit{UserAgent: desktopUA, AcceptLanguage: "pt"},
+			expected: "https://example.com",
+		},
+		{
+			name:     "excluded language",
+			visit:    domain.Visit{UserAgent: desktopUA, AcceptLanguage: "en, pt-BR;q=0"},
+			expected: "https://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, url.Redirect(tt.visit).Location)
+		})
+	}
+}
+
+func TestPlatformFromUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  domain.Platform
+	}{
+		{userAgent: "", expected: ""},
+		{userAgent: iPhoneUA, expected: domain.PlatformIOS},
+		{userAgent: "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X)", expected: domain.PlatformIOS},
+		{userAgent: androidUA, expected: domain.PlatformAndroid},
+		{userAgent: desktopUA, expected: domain.PlatformDesktop},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.expected), func(t *testing.T) {
+			assert.Equal(t, tt.expected, domain.PlatformFromUserAgent(tt.userAgent))
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected []string
+	}{
+		{header: "", expected: []string{}},
+		{header: "de", expected: []string{"de"}},
+		{header: "fr;q=0.5, EN-us, de;q=0.8", expected: []string{"en-us", "de", "fr"}},
+		{header: "*, de;q=0, en;q=bad", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, domain.ParseAcceptLanguage(tt.header))
+		})
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    domain.Rule
+		wantErr bool
+	}{
+		{name: "platform", rule: domain.Rule{Platform: domain.PlatformIOS, Destination: "https://a"}},
+		{name: "language", rule: domain.Rule{Language: "pt-BR", Destination: "https://a"}},
+		{name: "no destination", rule: domain.Rule{Platform: domain.PlatformIOS}, wantErr: true},
+		{name: "no condition", rule: domain.Rule{Destination: "https://a"}, wantErr: true},
+		{name: "unknown platform", rule: domain.Rule{Platform: "tv", Destination: "https://a"}, wantErr: true},
+		{name: "invalid language", rule: domain.Rule{Language: "en_US", Destination: "https://a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidRule)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -8,147 +8,136 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestURL_GenerateShortURL(t *testing.T) {
+func TestNewURL(t *testing.T) {
 	tests := []struct {
 		name     string
-		url      string
-		setup    func()
-		validate func(t *testing.T, shortURL string, url *domain.URL)
+		longURL  string
+		validate func(t *testing.T, url *domain.URL)
 	}{
 		{
-			name: "correct length",
-			url:  "https://example.com",
-			validate: func(t *testing.T, shortURL string, _ *domain.URL) {
-				assert.Len(t, shortURL, 8, "length must be 8 characters")
-			},
-		},
-		{
-			name: "hex format",
-			url:  "https://example.com",
-			validate: func(t *testing.T, shortURL string, _ *domain.URL) {
-				assert.Regexp(t, `^[0-9a-f]{8}$`, shortURL, "must contain only hex characters")
-			},
-		},
-		{
-			name: "sets the ShortURL field",
-			url:  "https://example.com",
-			validate: func(t *testing.T, shortURL string, u *domain.URL) {
-				assert.Equal(t, shortURL, u.ShortURL, "the ShortURL field must be set")
-			},
-		},
-		{
-			name: "empty URL",
-			url:  "",
-			validate: func(t *testing.T, shortURL string, _ *domain.URL) {
-				assert.Len(t, shortURL, 8, "should work with an empty URL")
-			},
-		},
-		{
-			name: "very long URL",
-			url:  strings.Repeat("a", 10000),
-			validate: func(t *testing.T, shortURL string, _ *domain.URL) {
-				assert.Len(t, shortURL, 8, "should handle long URLs")
+			name:    "standard case",
+			longURL: "https://example.com",
+			validate: func(t *testing.T, url *domain.URL) {
+				assert.Equal(t, "https://example.com", url.OriginalURL)
+				assert.Empty(t, url.ShortURL)
 			},
 		},
 		{
-			name: "wildcards in URL",
-			url:  "https://пример.рф/путь/с/юникодом?параметр=значение#якорь",
-			validate: func(t *testing.T, shortURL string, _ *domain.URL) {
-				assert.Len(t, shortURL, 8, "must handle unicode characters")
+			name:    "empty URL",
+			longURL: "",
+			validate: func(t *testing.T, url *domain.URL) {
+				assert.Empty(t, url.OriginalURL)
+				assert.Empty(t, url.ShortURL)
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.setup != nil {
-				tt.setup()
-			}
+			url := domain.NewURL(tt.longURL)
+			tt.validate(t, url)
+		})
+	}
+}
+
+func TestURL_Password(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		attempt  string
+		expected bool
+	}{
+		{name: "matching password", password: "secret", attempt: "secret", expected: true},
+		{name: "wrong password", password: "secret", attempt: "guess", expected: false},
+		{name: "empty attempt", password: "secret", attempt: "", expected: false},
+	}
 
-			u := domain.NewURL(tt.url)
-			shortURL := u.GenerateShortURL()
-			tt.validate(t, shortURL, u)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := domain.NewURL("https://example.com")
+			assert.False(t, u.HasPassword())
+
+			assert.NoError(t, u.SetPassword(tt.password))
+			assert.True(t, u.HasPassword())
+			assert.NotContains(t, u.PasswordHash, tt.password, "password must not be stored in plain text")
+			assert.Equal(t, tt.expected, u.CheckPassword(tt.attempt))
 		})
 	}
 }
 
-func TestURL_Uniqueness(t *testing.T) {
+func TestValidateAlias(t *testing.T) {
 	tests := []struct {
-		name  string
-		urls  []string
-		check func(t *testing.T, results []string)
+		name    string
+		alias   string
+		wantErr bool
 	}{
-		{
-			name: "different URLs give different abbreviations",
-			urls: []string{
-				"https://example.com/1",
-				"https://example.com/2",
-				"https://example.com/3",
-			},
-			check: func(t *testing.T, results []string) {
-				seen := make(map[string]bool)
-				for _, s := range results {
-					assert.False(t, seen[s], "must have unique values")
-					seen[s] = true
-				}
-			},
-		},
-		{
-			name: "repeated calls produce different results",
-			urls: []string{
-				"https://example.com",
-				"https://example.com",
-				"https://example.com",
-			},
-			check: func(t *testing.T, results []string) {
-				assert.Len(t, results, 3, "3 results were expected")
-				assert.NotEqual(t, results[0], results[1], "results should be different")
-				assert.NotEqual(t, results[0], results[2], "results should be different")
-				assert.NotEqual(t, results[1], results[2], "results should be different")
-			},
-		},
+		{name: "letters and digits", alias: "Promo2025"},
+		{name: "dashes and underscores", alias: "spring-sale_eu"},
+		{name: "longest", alias: strings.Repeat("a", 64)},
+		{name: "empty", alias: "", wantErr: true},
+		{name: "too long", alias: strings.Repeat("a", 65), wantErr: true},
+		{name: "slash", alias: "a/b", wantErr: true},
+		{name: "space", alias: "a b", wantErr: true},
+		{name: "non ascii", alias: "café", wantErr: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results := make([]string, 0, len(tt.urls))
-			for _, url := range tt.urls {
-				u := domain.NewURL(url)
-				results = append(results, u.GenerateShortURL())
+			err := domain.ValidateAlias(tt.alias)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidAlias)
+			} else {
+				assert.NoError(t, err)
 			}
-			tt.check(t, results)
 		})
 	}
 }
 
-func TestNewURL(t *testing.T) {
+func TestValidateCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		wantErr bool
+	}{
+		{name: "generated code", code: "aB3dE9"},
+		{name: "alias", code: "spring-sale_eu"},
+		{name: "empty", code: "", wantErr: true},
+		{name: "internal key", code: "codegen:counter", wantErr: true},
+		{name: "hash tagged key", code: "{abc123}:password_attempts:1.2.3.4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateCode(tt.code)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidCode)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestURL_ServedOn(t *testing.T) {
 	tests := []struct {
 		name     string
-		longURL  string
-		validate func(t *testing.T, url *domain.URL)
+		domain   string
+		host     string
+		expected bool
 	}{
-		{
-			name:    "standard case",
-			longURL: "https://example.com",
-			validate: func(t *testing.T, url *domain.URL) {
-				assert.Equal(t, "https://example.com", url.OriginalURL)
-				assert.Empty(t, url.ShortURL)
-			},
-		},
-		{
-			name:    "empty URL",
-			longURL: "",
-			validate: func(t *testing.T, url *domain.URL) {
-				assert.Empty(t, url.OriginalURL)
-				assert.Empty(t, url.ShortURL)
-			},
-		},
+		{name: "default domain", host: "sho.rt", expected: true},
+		{name: "no host", domain: "go.example.com", expected: true},
+		{name: "same host", domain: "go.example.com", host: "Go.Example.com", expected: true},
+		{name: "custom port", domain: "go.example.com", host: "go.example.com:8081", expected: true},
+		{name: "domain with port", domain: "localhost:8081", host: "localhost:8081", expected: true},
+		{name: "ipv6 with port", domain: "::1", host: "[::1]:8081", expected: true},
+		{name: "other host", domain: "go.example.com", host: "sho.rt:8081", expected: false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			url := domain.NewURL(tt.longURL)
-			tt.validate(t, url)
+			url := &domain.URL{ShortURL: "abc123", Domain: tt.domain}
+			assert.Equal(t, tt.expected, url.ServedOn(tt.host))
 		})
 	}
 }
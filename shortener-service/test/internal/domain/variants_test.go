@@ -0,0 +1,119 @@
+package domain_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSplitURL(split domain.SplitMode) *domain.URL {
+	return &domain.URL{
+		ShortURL:    "abc123",
+		OriginalURL: "https://example.com",
+		Split:       split,
+		Variants: []domain.Variant{
+			{Name: "a", Destination: "https://example.com/a", Weight: 3},
+			{Name: "b", Destination: "https://example.com/b", Weight: 1},
+		},
+	}
+}
+
+func TestURL_RedirectVariants(t *testing.T) {
+	t.Run("random follows weights", func(t *testing.T) {
+		url := newSplitURL(domain.SplitRandom)
+
+		counts := map[string]int{}
+		for range 4000 {
+			redirect := url.Redirect(domain.Visit{})
+			counts[redirect.Variant]++
+			assert.Equal(t, "https://example.com/"+redirect.Variant, redirect.Location)
+		}
+
+		assert.InDelta(t, 3000, counts["a"], 200)
+		assert.InDelta(t, 1000, counts["b"], 200)
+	})
+
+	t.Run("sticky keeps the visitor's variant", func(t *testing.T) {
+		url := newSplitURL(domain.SplitSticky)
+
+		counts := map[string]int{}
+		for i := range 400 {
+			visit := domain.Visit{VisitorID: fmt.Sprintf("visitor-%d", i)}
+			first := url.Redirect(visit).Variant
+			for range 5 {
+				require.Equal(t, first, url.Redirect(visit).Variant)
+			}
+			counts[first]++
+		}
+
+		assert.InDelta(t, 300, counts["a"], 60)
+	})
+
+	t.Run("rules take precedence", func(t *testing.T) {
+		url := newSplitURL(domain.SplitRandom)
+		url.Rules = []domain.Rule{{Platform: domain.PlatformAndroid, Destination: "https://play.google.com/app"}}
+
+		redirect := url.Redirect(domain.Visit{UserAgent: androidUA})
+
+		assert.Equal(t, "https://play.google.com/app", redirect.Location)
+		assert.Empty(t, redirect.Variant)
+	})
+}
+
+func TestURL_ValidateVariants(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           domain.URL
+		expectedNames []string
+		wantErr       bool
+	}{
+		{
+			name: "unnamed variants are named by position",
+			url: domain.URL{Variants: []domain.Variant{
+				{Destination: "https://a", Weight: 1},
+				{Name: "b", Destination: "https://b", Weight: 1},
+			}},
+			expectedNames: []string{"0", "b"},
+		},
+		{
+			name:    "zero weight",
+			url:     domain.URL{Variants: []domain.Variant{{Destination: "https://a"}}},
+			wantErr: true,
+		},
+		{
+			name:    "no destination",
+			url:     domain.URL{Variants: []domain.Variant{{Weight: 1}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate names",
+			url: domain.URL{Variants: []domain.Variant{
+				{Name: "a", Destination: "https://a", Weight: 1},
+				{Name: "a", Destination: "https://b", Weight: 1},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "split mode without variants",
+			url:     domain.URL{Split: domain.SplitSticky},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.url.ValidateVariants()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidVariant)
+				return
+			}
+			require.NoError(t, err)
+			for i, name := range tt.expectedNames {
+				assert.Equal(t, name, tt.url.Variants[i].Name)
+			}
+		})
+	}
+}
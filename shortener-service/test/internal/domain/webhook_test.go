@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Validate(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		events         []domain.WebhookEvent
+		expectedEvents []domain.WebhookEvent
+		expectedErr    error
+	}{
+		{
+			name:           "valid",
+			url:            "https://example.com/hook",
+			events:         []domain.WebhookEvent{domain.WebhookCreated, domain.WebhookExpired, domain.WebhookCreated},
+			expectedEvents: []domain.WebhookEvent{domain.WebhookCreated, domain.WebhookExpired},
+		},
+		{name: "relative url", url: "/hook", events: []domain.WebhookEvent{domain.WebhookCreated}, expectedErr: domain.ErrInvalidWebhookURL},
+		{name: "unsupported scheme", url: "ftp://example.com", events: []domain.WebhookEvent{domain.WebhookCreated}, expectedErr: domain.ErrInvalidWebhookURL},
+		{name: "no events", url: "https://example.com/hook", expectedErr: domain.ErrInvalidWebhookEvents},
+		{name: "unknown event", url: "https://example.com/hook", events: []domain.WebhookEvent{"renamed"}, expectedErr: domain.ErrInvalidWebhookEvents},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := &domain.Webhook{URL: tt.url, Events: tt.events}
+
+			err := webhook.Validate()
+			assert.ErrorIs(t, err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				assert.Equal(t, tt.expectedEvents, webhook.Events)
+			}
+		})
+	}
+}
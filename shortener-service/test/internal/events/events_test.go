@@ -0,0 +1,99 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var event = events.Event{
+	Name:     "url_created",
+	ShortURL: "abc123",
+	Payload:  []byte(`{"short_url":"abc123","original_url":"https://example.com/?token=secret"}`),
+}
+
+type failingPublisher struct {
+	err error
+}
+
+func (p failingPublisher) Publish(context.Context, events.Event) error {
+	return p.err
+}
+
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+	errSink := errors.New("sink down")
+	first, second := events.NewMemoryPublisher(), events.NewMemoryPublisher()
+
+	err := events.FanOut(first, failingPublisher{err: errSink}, second).Publish(ctx, event)
+
+	assert.ErrorIs(t, err, errSink)
+	assert.Equal(t, []events.Event{event}, first.Events(), "a failing sink does not stop the others")
+	assert.Equal(t, []events.Event{event}, second.Events())
+	assert.NoError(t, events.FanOut().Publish(ctx, event))
+}
+
+func TestRedisStreamPublisher(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxLen        int64
+		err           error
+		expectedError error
+	}{
+		{name: "capped stream", maxLen: 1000},
+		{name: "unbounded stream"},
+		{name: "Redis error", maxLen: 1000, err: redis.ErrClosed, expectedError: redis.ErrClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			publisher := events.NewRedisStreamPublisher(db, "url-events", tt.maxLen)
+
+			expect := mock.ExpectXAdd(&redis.XAddArgs{
+				Stream: "url-events",
+				MaxLen: tt.maxLen,
+				Approx: tt.maxLen > 0,
+				Values: []any{"event", event.Name, "short_url", event.ShortURL, "payload", event.Payload},
+			})
+			if tt.err != nil {
+				expect.SetErr(tt.err)
+			} else {
+				expect.SetVal("1-0")
+			}
+
+			err := publisher.Publish(context.Background(), event)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLogPublisher(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	publisher := events.NewLogPublisher(zap.New(core))
+
+	require.NoError(t, publisher.Publish(context.Background(), event))
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "url_created", fields["event"])
+	assert.Equal(t, "abc123", fields["short_url"])
+	payload, ok := fields["payload"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "https://example.com/", payload["original_url"], "query strings are redacted")
+}
@@ -0,0 +1,157 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var errSinkDown = errors.New("sink down")
+
+// flakyPublisher fails while down and records what it delivers otherwise.
+type flakyPublisher struct {
+	events.MemoryPublisher
+	mu   sync.Mutex
+	down bool
+}
+
+func (p *flakyPublisher) setDown(down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down = down
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.mu.Lock()
+	down := p.down
+	p.mu.Unlock()
+	if down {
+		return errSinkDown
+	}
+	return p.MemoryPublisher.Publish(ctx, event)
+}
+
+func numbered(i int) events.Event {
+	return events.Event{Name: "url_visited", ShortURL: fmt.Sprintf("code%03d", i), Payload: []byte(`{}`)}
+}
+
+func segments(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestSpool_ReplaysInOrder(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sink := &flakyPublisher{down: true}
+	spool, err := events.NewSpool(dir, sink, 1<<20, zaptest.NewLogger(t), events.WithSegmentSize(256))
+	require.NoError(t, err)
+
+	for i := range 5 {
+		require.NoError(t, spool.Publish(ctx, numbered(i)), "failed events are spooled")
+	}
+	assert.EqualValues(t, 5, spool.Depth())
+	assert.Greater(t, len(segments(t, dir)), 1, "segments roll over")
+	assert.ErrorIs(t, spool.Replay(ctx), errSinkDown)
+
+	sink.setDown(false)
+	require.NoError(t, spool.Publish(ctx, numbered(5)))
+	assert.Empty(t, sink.Events(), "new events queue behind spooled ones")
+
+	require.NoError(t, spool.Replay(ctx))
+	assert.Equal(t, []events.Event{numbered(0), numbered(1), numbered(2), numbered(3), numbered(4), numbered(5)}, sink.Events())
+	assert.Zero(t, spool.Depth())
+	assert.Empty(t, segments(t, dir), "drained segments are deleted")
+
+	require.NoError(t, spool.Publish(ctx, numbered(6)))
+	assert.Len(t, sink.Events(), 7, "an empty spool publishes directly")
+	require.NoError(t, spool.Close())
+}
+
+func TestSpool_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sink := &flakyPublisher{down: true}
+	spool, err := events.NewSpool(dir, sink, 1<<20, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	for i := range 3 {
+		require.NoError(t, spool.Publish(ctx, numbered(i)))
+	}
+	require.NoError(t, spool.Close())
+
+	sink.setDown(false)
+	reopened, err := events.NewSpool(dir, sink, 1<<20, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, reopened.Depth())
+
+	require.NoError(t, reopened.Replay(ctx))
+	assert.Equal(t, []events.Event{numbered(0), numbered(1), numbered(2)}, sink.Events())
+}
+
+func TestSpool_DropsOldestWhenFull(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sink := &flakyPublisher{down: true}
+	spool, err := events.NewSpool(dir, sink, 300, zaptest.NewLogger(t), events.WithSegmentSize(100))
+	require.NoError(t, err)
+
+	for i := range 20 {
+		require.NoError(t, spool.Publish(ctx, numbered(i)))
+	}
+	depth := spool.Depth()
+	assert.Less(t, depth, int64(20))
+
+	var size int64
+	for _, path := range segments(t, dir) {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		size += info.Size()
+	}
+	assert.LessOrEqual(t, size, int64(300))
+
+	sink.setDown(false)
+	require.NoError(t, spool.Replay(ctx))
+	delivered := sink.Events()
+	require.Len(t, delivered, int(depth))
+	assert.Equal(t, numbered(19), delivered[len(delivered)-1], "the newest events are kept")
+	assert.Equal(t, numbered(20-int(depth)), delivered[0])
+}
+
+func TestSpool_SkipsCorruptRecords(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sink := &flakyPublisher{down: true}
+	spool, err := events.NewSpool(dir, sink, 1<<20, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	for i := range 3 {
+		require.NoError(t, spool.Publish(ctx, numbered(i)))
+	}
+	require.NoError(t, spool.Close())
+
+	files := segments(t, dir)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	data[len(data)-2] ^= 0xff
+	require.NoError(t, os.WriteFile(files[0], data, 0o600))
+
+	sink.setDown(false)
+	reopened, err := events.NewSpool(dir, sink, 1<<20, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, reopened.Depth(), "the damaged record fails its checksum")
+
+	require.NoError(t, reopened.Replay(ctx))
+	assert.Equal(t, []events.Event{numbered(0), numbered(1)}, sink.Events())
+	assert.Empty(t, segments(t, dir))
+}
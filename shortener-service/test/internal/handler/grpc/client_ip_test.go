@@ -0,0 +1,110 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	urlpb "github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// recordingLimiter remembers the client of the last failed password attempt.
+type recordingLimiter struct {
+	client string
+}
+
+func (l *recordingLimiter) Allow(_ context.Context, _, client string) (bool, error) {
+	l.client = client
+	return true, nil
+}
+
+func (l *recordingLimiter) Reset(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func TestHandler_ClientIP(t *testing.T) {
+	proxies := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("::1/128"),
+	}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		expected  string
+	}{
+		{name: "direct call", peer: "203.0.113.7:5000", expected: "203.0.113.7"},
+		{
+			name:      "forwarded by an untrusted peer",
+			peer:      "203.0.113.7:5000",
+			forwarded: []string{"198.51.100.1"},
+			expected:  "203.0.113.7",
+		},
+		{
+			name:      "forwarded by a trusted proxy",
+			peer:      "10.0.0.2:5000",
+			forwarded: []string{"198.51.100.1"},
+			expected:  "198.51.100.1",
+		},
+		{
+			name:      "spoofed hops left of the client",
+			peer:      "10.0.0.2:5000",
+			forwarded: []string{"1.2.3.4, 198.51.100.1, 10.0.0.3"},
+			expected:  "198.51.100.1",
+		},
+		{
+			name:      "hops across several headers",
+			peer:      "[::1]:5000",
+			forwarded: []string{"1.2.3.4", "198.51.100.1"},
+			expected:  "198.51.100.1",
+		},
+		{
+			name:     "trusted proxy without the header",
+			peer:     "10.0.0.2:5000",
+			expected: "10.0.0.2",
+		},
+		{
+			name:      "only trusted hops",
+			peer:      "10.0.0.2:5000",
+			forwarded: []string{"10.0.0.4, 10.0.0.3"},
+			expected:  "10.0.0.4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := &domain.URL{ShortURL: "secret", OriginalURL: "https://example.com", Status: domain.StatusActive}
+			require.NoError(t, url.SetPassword("pass"))
+			repo := newFakeRepo()
+			repo.urls[url.ShortURL] = url
+			limiter := &recordingLimiter{}
+			ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop(),
+				controller.WithFailureLimiter(limiter))
+			handler := grpcHandler.New(ctrl, zap.NewNop(), grpcHandler.WithTrustedProxies(proxies...))
+
+			addr, err := net.ResolveTCPAddr("tcp", tt.peer)
+			require.NoError(t, err)
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			md := metadata.Pairs("x-link-password", "guess")
+			for _, hops := range tt.forwarded {
+				md.Append("x-forwarded-for", hops)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			_, err = handler.GetOriginalURL(ctx, &urlpb.ShortURL{Url: "secret"})
+			require.Error(t, err)
+			assert.Equal(t, tt.expected, limiter.client)
+		})
+	}
+}
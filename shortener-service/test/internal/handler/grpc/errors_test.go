@@ -0,0 +1,189 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/blocklist"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedCode    codes.Code
+		expectedReason  string
+		expectedField   string
+		expectedMessage string
+		retryable       bool
+	}{
+		{
+			name:            "not found",
+			err:             fmt.Errorf("get: %w", repository.ErrURLNotFound),
+			expectedCode:    codes.NotFound,
+			expectedReason:  "URL_NOT_FOUND",
+			expectedMessage: "URL not found",
+		},
+		{
+			name:            "deleted url",
+			err:             controller.ErrURLDeleted,
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  "URL_DELETED",
+			expectedMessage: "URL is deleted",
+		},
+		{
+			name:            "empty short url",
+			err:             repository.ErrShortURLEmpty,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "INVALID_ARGUMENT",
+			expectedField:   "url",
+			expectedMessage: repository.ErrShortURLEmpty.Error(),
+		},
+		{
+			name:            "invalid short code",
+			err:             domain.ErrInvalidCode,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "INVALID_ARGUMENT",
+			expectedField:   "url",
+			expectedMessage: domain.ErrInvalidCode.Error(),
+		},
+		{
+			name:            "invalid rule keeps its message",
+			err:             fmt.Errorf("%w: destination is required", domain.ErrInvalidRule),
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "INVALID_ARGUMENT",
+			expectedField:   "rules",
+			expectedMessage: "invalid redirect rule: destination is required",
+		},
+		{
+			name:            "negative ttl",
+			err:             controller.ErrNegativeTTL,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "INVALID_ARGUMENT",
+			expectedField:   "ttl",
+			expectedMessage: "invalid activation window: ttl cannot be negative",
+		},
+		{
+			name:            "expiry in the past",
+			err:             controller.ErrExpiryInPast,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "INVALID_ARGUMENT",
+			expectedField:   "expires_at",
+			expectedMessage: "invalid activation window: expires_at is in the past",
+		},
+		{
+			name:            "activation after expiry",
+			err:             controller.ErrNotBeforeExpiry,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "INVALID_ARGUMENT",
+			expectedField:   "not_before",
+			expectedMessage: "invalid activation window: not_before must be before expiry",
+		},
+		{
+			name:            "webhook not found",
+			err:             repository.ErrWebhookNotFound,
+			expectedCode:    codes.NotFound,
+			expectedReason:  "WEBHOOK_NOT_FOUND",
+			expectedMessage: "webhook not found",
+		},
+		{
+			name:            "private webhook endpoint",
+			err:             domain.ErrPrivateWebhookURL,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "INVALID_ARGUMENT",
+			expectedField:   "url",
+			expectedMessage: "webhook url must not point to a private network",
+		},
+		{
+			name:            "blocked destination",
+			err:             &blocklist.BlockedError{Reason: blocklist.ReasonDomain, Rule: "evil.example.com"},
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "BLOCKED_DOMAIN",
+			expectedField:   "original_url",
+			expectedMessage: "destination is blocked: BLOCKED_DOMAIN",
+		},
+		{
+			name:            "too many attempts",
+			err:             controller.ErrTooManyAttempts,
+			expectedCode:    codes.ResourceExhausted,
+			expectedReason:  "TOO_MANY_ATTEMPTS",
+			expectedMessage: "too many failed password attempts",
+		},
+		{
+			name:            "idempotent request in progress",
+			err:             repository.ErrIdempotencyInProgress,
+			expectedCode:    codes.Aborted,
+			expectedReason:  "IDEMPOTENCY_KEY_IN_PROGRESS",
+			expectedMessage: "request with this idempotency key is in progress",
+			retryable:       true,
+		},
+		{
+			name:            "deadline",
+			err:             fmt.Errorf("redis: %w", context.DeadlineExceeded),
+			expectedCode:    codes.DeadlineExceeded,
+			expectedReason:  "DEADLINE_EXCEEDED",
+			expectedMessage: "deadline exceeded",
+			retryable:       true,
+		},
+		{
+			name:            "network error is sanitised",
+			err:             &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused to redis-0.internal:6379")},
+			expectedCode:    codes.Unavailable,
+			expectedReason:  "STORAGE_UNAVAILABLE",
+			expectedMessage: "storage unavailable",
+			retryable:       true,
+		},
+		{
+			name:            "unknown error is sanitised",
+			err:             errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"),
+			expectedCode:    codes.Internal,
+			expectedReason:  "INTERNAL",
+			expectedMessage: "internal error",
+		},
+		{
+			name:            "status errors pass through",
+			err:             status.Error(codes.Unauthenticated, "client certificate required"),
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "client certificate required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(grpcHandler.StatusFromError(tt.err))
+
+			assert.Equal(t, tt.expectedCode, st.Code())
+			assert.Equal(t, tt.expectedMessage, st.Message())
+
+			var reason, field string
+			var retryable bool
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					assert.Equal(t, "shortener-service", d.Domain)
+					reason = d.Reason
+				case *errdetails.BadRequest:
+					require.Len(t, d.FieldViolations, 1)
+					field = d.FieldViolations[0].Field
+				case *errdetails.RetryInfo:
+					retryable = d.RetryDelay.AsDuration() > 0
+				}
+			}
+			assert.Equal(t, tt.expectedReason, reason)
+			assert.Equal(t, tt.expectedField, field)
+			assert.Equal(t, tt.retryable, retryable)
+		})
+	}
+}
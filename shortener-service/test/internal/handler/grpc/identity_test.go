@@ -0,0 +1,103 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func peerContext(cert *x509.Certificate) context.Context {
+	state := tls.ConnectionState{}
+	if cert != nil {
+		state.PeerCertificates = []*x509.Certificate{cert}
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: state},
+	})
+}
+
+func TestIdentityInterceptor(t *testing.T) {
+	spiffeID, _ := url.Parse("spiffe://example.org/ns/analytics/sa/api")
+	workload := &x509.Certificate{
+		URIs:     []*url.URL{spiffeID},
+		DNSNames: []string{"analytics.internal"},
+		Subject:  pkix.Name{CommonName: "analytics"},
+	}
+	legacy := &x509.Certificate{Subject: pkix.Name{CommonName: "legacy-batch"}}
+
+	tests := []struct {
+		name           string
+		identities     map[string]string
+		cert           *x509.Certificate
+		expectedCaller string
+		expectedCode   codes.Code
+	}{
+		{
+			name:           "spiffe id preferred",
+			cert:           workload,
+			expectedCaller: "spiffe://example.org/ns/analytics/sa/api",
+		},
+		{
+			name:           "common name",
+			cert:           legacy,
+			expectedCaller: "legacy-batch",
+		},
+		{
+			name: "no certificate",
+		},
+		{
+			name:           "mapped identity",
+			identities:     map[string]string{"spiffe://example.org/ns/analytics/sa/api": "analytics"},
+			cert:           workload,
+			expectedCaller: "analytics",
+		},
+		{
+			name:           "mapped dns name",
+			identities:     map[string]string{"analytics.internal": "analytics"},
+			cert:           workload,
+			expectedCaller: "analytics",
+		},
+		{
+			name:         "unlisted identity",
+			identities:   map[string]string{"spiffe://example.org/ns/billing/sa/api": "billing"},
+			cert:         legacy,
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "no certificate with identities",
+			identities:   map[string]string{"spiffe://example.org/ns/billing/sa/api": "billing"},
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := grpcHandler.IdentityInterceptor(tt.identities, zap.NewNop())
+
+			var caller string
+			var authenticated bool
+			_, err := interceptor(peerContext(tt.cert), nil, &grpc.UnaryServerInfo{FullMethod: "/test"},
+				func(ctx context.Context, _ any) (any, error) {
+					caller, authenticated = grpcHandler.CallerFromContext(ctx)
+					return nil, nil
+				})
+
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			assert.Equal(t, tt.expectedCaller, caller)
+			assert.Equal(t, tt.expectedCaller != "", authenticated)
+		})
+	}
+}
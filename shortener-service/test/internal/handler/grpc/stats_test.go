@@ -0,0 +1,77 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	urlpb "github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_GetURLStatsDestination(t *testing.T) {
+	const destination = "https://example.com/private"
+
+	protected := &domain.URL{ShortURL: "secret", OriginalURL: destination, Status: domain.StatusActive, Owner: "alice"}
+	require.NoError(t, protected.SetPassword("pass"))
+	repo := newFakeRepo()
+	for _, url := range []*domain.URL{
+		{ShortURL: "public", OriginalURL: destination, Status: domain.StatusActive, Owner: "alice"},
+		protected,
+		{ShortURL: "off", OriginalURL: destination, Status: domain.StatusDisabled, Owner: "alice"},
+		{ShortURL: "gone", OriginalURL: destination, Status: domain.StatusDeleted, Owner: "alice"},
+		{ShortURL: "later", OriginalURL: destination, Status: domain.StatusActive, Owner: "alice", NotBefore: time.Now().Add(time.Hour)},
+		{ShortURL: "ownerless", OriginalURL: destination, Status: domain.StatusDisabled},
+	} {
+		repo.urls[url.ShortURL] = url
+	}
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+	handler := grpcHandler.New(ctrl, zap.NewNop())
+
+	tests := []struct {
+		name     string
+		code     string
+		caller   string
+		expected string
+	}{
+		{name: "active link", code: "public", expected: destination},
+		{name: "password protected", code: "secret"},
+		{name: "disabled", code: "off"},
+		{name: "deleted", code: "gone"},
+		{name: "not yet active", code: "later"},
+		{name: "another caller", code: "secret", caller: "bob"},
+		{name: "owner of a password protected link", code: "secret", caller: "alice", expected: destination},
+		{name: "owner of a disabled link", code: "off", caller: "alice", expected: destination},
+		{name: "owner of a deleted link", code: "gone", caller: "alice", expected: destination},
+		{name: "owner of a link not yet active", code: "later", caller: "alice", expected: destination},
+		{name: "link without an owner", code: "ownerless", caller: "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := func(ctx context.Context) (any, error) {
+				return handler.GetURLStats(ctx, &urlpb.ShortURL{Url: tt.code})
+			}
+			var (
+				resp any
+				err  error
+			)
+			if tt.caller != "" {
+				resp, err = asCaller(t, tt.caller, call)
+			} else {
+				resp, err = call(context.Background())
+			}
+			require.NoError(t, err)
+
+			stats := resp.(*urlpb.URLStats)
+			assert.Equal(t, tt.code, stats.Code)
+			assert.Equal(t, tt.expected, stats.OriginalUrl)
+		})
+	}
+}
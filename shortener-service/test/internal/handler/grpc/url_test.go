@@ -0,0 +1,156 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	urlpb "github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeRepo struct {
+	urls map[string]*domain.URL
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{urls: make(map[string]*domain.URL)}
+}
+
+func (r *fakeRepo) Save(_ context.Context, url *domain.URL, _ time.Duration) error {
+	if _, ok := r.urls[url.ShortURL]; ok {
+		return repository.ErrURLExists
+	}
+	r.urls[url.ShortURL] = url
+	return nil
+}
+
+func (r *fakeRepo) Get(_ context.Context, shortURL string) (*domain.URL, error) {
+	url, ok := r.urls[shortURL]
+	if !ok {
+		return nil, repository.ErrURLNotFound
+	}
+	return url, nil
+}
+
+func (r *fakeRepo) Delete(_ context.Context, shortURL string, _ time.Duration, cond domain.Precondition) error {
+	url, ok := r.urls[shortURL]
+	if !ok {
+		return repository.ErrURLNotFound
+	}
+	if cond.OriginalURL != "" && cond.OriginalURL != url.OriginalURL {
+		return repository.ErrURLChanged
+	}
+	delete(r.urls, shortURL)
+	return nil
+}
+
+func (r *fakeRepo) Restore(_ context.Context, _ string) error {
+	return nil
+}
+
+func (r *fakeRepo) SetStatus(_ context.Context, shortURL string, status domain.Status) error {
+	r.urls[shortURL].Status = status
+	return nil
+}
+
+func (r *fakeRepo) ConsumeClick(_ context.Context, _ string, _ time.Duration) (int64, error) {
+	return -1, nil
+}
+
+// sequenceGenerator hands out codes in order.
+type sequenceGenerator struct {
+	codes []string
+}
+
+func (g *sequenceGenerator) Generate(_ context.Context, _ *domain.URL) (string, error) {
+	code := g.codes[0]
+	g.codes = g.codes[1:]
+	return code, nil
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	response    []byte
+}
+
+type fakeIdempotencyStore struct {
+	entries map[string]*idempotencyEntry
+}
+
+func (s *fakeIdempotencyStore) Reserve(_ context.Context, key, fingerprint string) ([]byte, error) {
+	entry, ok := s.entries[key]
+	if !ok {
+		s.entries[key] = &idempotencyEntry{fingerprint: fingerprint}
+		return nil, nil
+	}
+	if entry.fingerprint != fingerprint {
+		return nil, repository.ErrIdempotencyMismatch
+	}
+	if entry.response == nil {
+		return nil, repository.ErrIdempotencyInProgress
+	}
+	return entry.response, nil
+}
+
+func (s *fakeIdempotencyStore) Complete(_ context.Context, key string, response []byte) error {
+	s.entries[key].response = response
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(_ context.Context, key string) error {
+	delete(s.entries, key)
+	return nil
+}
+
+func TestHandler_GenerateShortURLIdempotency(t *testing.T) {
+	repo := newFakeRepo()
+	gen := &sequenceGenerator{codes: []string{"code1", "code2", "code3"}}
+	ctrl := controller.NewController(repo, events.NopPublisher{}, gen, zap.NewNop())
+	store := &fakeIdempotencyStore{entries: make(map[string]*idempotencyEntry)}
+	handler := grpcHandler.New(ctrl, zap.NewNop(), grpcHandler.WithIdempotency(store))
+
+	withKey := func(key string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("idempotency-key", key))
+	}
+	req := &urlpb.GenerateShortURLRequest{OriginalUrl: "https://example.com"}
+
+	first, err := handler.GenerateShortURL(withKey("req-1"), req)
+	require.NoError(t, err)
+	assert.Equal(t, "code1", first.Code)
+
+	replayed, err := handler.GenerateShortURL(withKey("req-1"), req)
+	require.NoError(t, err)
+	assert.Equal(t, "code1", replayed.Code, "a retry gets the first response")
+
+	fromField, err := handler.GenerateShortURL(context.Background(),
+		&urlpb.GenerateShortURLRequest{OriginalUrl: "https://example.com", IdempotencyKey: "req-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "code1", fromField.Code, "the key can be sent in the request")
+
+	_, err = handler.GenerateShortURL(withKey("req-1"), &urlpb.GenerateShortURLRequest{OriginalUrl: "https://example.org"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = handler.GenerateShortURL(withKey("req-2"), &urlpb.GenerateShortURLRequest{OriginalUrl: "https://example.com", Alias: "code1"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.NotContains(t, store.entries, "req-2", "failed requests release their key")
+
+	unkeyed, err := handler.GenerateShortURL(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "code2", unkeyed.Code)
+	assert.Len(t, repo.urls, 2)
+
+	_, err = handler.GenerateShortURL(withKey("req-3"),
+		&urlpb.GenerateShortURLRequest{OriginalUrl: "https://example.com", IdempotencyKey: "other"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
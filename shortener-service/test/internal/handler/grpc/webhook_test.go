@@ -0,0 +1,228 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	urlpb "github.com/OrtemRepos/ShortURL/shortener-service/gen/url"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	grpcHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/grpc"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeWebhooks struct {
+	webhooks  map[string]*domain.Webhook
+	lastLimit int64
+}
+
+func (s *fakeWebhooks) Register(_ context.Context, owner, endpoint string, events []domain.WebhookEvent) (*domain.Webhook, error) {
+	webhook := &domain.Webhook{
+		ID:        "hook-1",
+		Owner:     owner,
+		URL:       endpoint,
+		Secret:    "s3cret",
+		Events:    events,
+		CreatedAt: time.Now(),
+	}
+	if err := webhook.Validate(); err != nil {
+		return nil, err
+	}
+	s.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+func (s *fakeWebhooks) Webhooks(_ context.Context, owner string) ([]*domain.Webhook, error) {
+	var webhooks []*domain.Webhook
+	for _, webhook := range s.webhooks {
+		if webhook.Owner == owner {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (s *fakeWebhooks) Delete(_ context.Context, owner, id string) error {
+	webhook, ok := s.webhooks[id]
+	if !ok || webhook.Owner != owner {
+		return repository.ErrWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	return nil
+}
+
+func (s *fakeWebhooks) Deliveries(_ context.Context, owner, id string, _ bool, limit int64) ([]*domain.Delivery, error) {
+	s.lastLimit = limit
+	webhook, ok := s.webhooks[id]
+	if !ok || webhook.Owner != owner {
+		return nil, repository.ErrWebhookNotFound
+	}
+	return []*domain.Delivery{{ID: "d-1", WebhookID: id, Event: domain.WebhookExpired, Status: domain.DeliveryDead, Attempts: 8}}, nil
+}
+
+// asCaller runs call as the client with the given certificate common name.
+func asCaller(t *testing.T, name string, call func(ctx context.Context) (any, error)) (any, error) {
+	t.Helper()
+	interceptor := grpcHandler.IdentityInterceptor(nil, zap.NewNop())
+	return interceptor(peerContext(&x509.Certificate{Subject: pkix.Name{CommonName: name}}), nil,
+		&grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) { return call(ctx) })
+}
+
+func TestHandler_Webhooks(t *testing.T) {
+	ctrl := controller.NewController(newFakeRepo(), events.NopPublisher{}, &sequenceGenerator{}, zap.NewNop())
+	webhooks := &fakeWebhooks{webhooks: map[string]*domain.Webhook{}}
+	handler := grpcHandler.New(ctrl, zap.NewNop(), grpcHandler.WithWebhooks(webhooks))
+
+	resp, err := asCaller(t, "alice", func(ctx context.Context) (any, error) {
+		return handler.RegisterWebhook(ctx, &urlpb.RegisterWebhookRequest{
+			Url:    "https://example.com/hook",
+			Events: []urlpb.WebhookEvent{urlpb.WebhookEvent_WEBHOOK_EVENT_CREATED, urlpb.WebhookEvent_WEBHOOK_EVENT_EXPIRED},
+		})
+	})
+	require.NoError(t, err)
+	registered := resp.(*urlpb.Webhook)
+	assert.Equal(t, "s3cret", registered.Secret)
+	assert.Equal(t, []urlpb.WebhookEvent{urlpb.WebhookEvent_WEBHOOK_EVENT_CREATED, urlpb.WebhookEvent_WEBHOOK_EVENT_EXPIRED}, registered.Events)
+	assert.Equal(t, "alice", webhooks.webhooks["hook-1"].Owner, "webhooks belong to the caller")
+
+	resp, err = asCaller(t, "alice", func(ctx context.Context) (any, error) {
+		return handler.ListWebhooks(ctx, &urlpb.ListWebhooksRequest{})
+	})
+	require.NoError(t, err)
+	listed := resp.(*urlpb.ListWebhooksResponse).Webhooks
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret, "the secret is only shown at registration")
+
+	resp, err = asCaller(t, "alice", func(ctx context.Context) (any, error) {
+		return handler.ListWebhookDeliveries(ctx, &urlpb.ListWebhookDeliveriesRequest{WebhookId: "hook-1"})
+	})
+	require.NoError(t, err)
+	deliveries := resp.(*urlpb.ListWebhookDeliveriesResponse).Deliveries
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, urlpb.DeliveryStatus_DELIVERY_STATUS_DEAD, deliveries[0].Status)
+	assert.Equal(t, urlpb.WebhookEvent_WEBHOOK_EVENT_EXPIRED, deliveries[0].Event)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+	assert.Equal(t, int64(100), webhooks.lastLimit, "limit defaults to the maximum")
+
+	_, err = asCaller(t, "bob", func(ctx context.Context) (any, error) {
+		return handler.DeleteWebhook(ctx, &urlpb.DeleteWebhookRequest{Id: "hook-1"})
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err), "other callers cannot delete the webhook")
+}
+
+func TestHandler_WebhooksInvalid(t *testing.T) {
+	ctrl := controller.NewController(newFakeRepo(), events.NopPublisher{}, &sequenceGenerator{}, zap.NewNop())
+	enabled := grpcHandler.New(ctrl, zap.NewNop(), grpcHandler.WithWebhooks(&fakeWebhooks{webhooks: map[string]*domain.Webhook{}}))
+	disabled := grpcHandler.New(ctrl, zap.NewNop())
+
+	tests := []struct {
+		name          string
+		anonymous     bool
+		call          func(ctx context.Context) error
+		expectedCode  codes.Code
+		expectedField string
+	}{
+		{
+			name: "disabled",
+			call: func(ctx context.Context) error {
+				_, err := disabled.ListWebhooks(ctx, &urlpb.ListWebhooksRequest{})
+				return err
+			},
+			expectedCode: codes.Unimplemented,
+		},
+		{
+			name:      "no client certificate",
+			anonymous: true,
+			call: func(ctx context.Context) error {
+				_, err := enabled.ListWebhooks(ctx, &urlpb.ListWebhooksRequest{})
+				return err
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:      "register without client certificate",
+			anonymous: true,
+			call: func(ctx context.Context) error {
+				_, err := enabled.RegisterWebhook(ctx, &urlpb.RegisterWebhookRequest{
+					Url:    "https://example.com/hook",
+					Events: []urlpb.WebhookEvent{urlpb.WebhookEvent_WEBHOOK_EVENT_CREATED},
+				})
+				return err
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name: "unknown event",
+			call: func(ctx context.Context) error {
+				_, err := enabled.RegisterWebhook(ctx, &urlpb.RegisterWebhookRequest{
+					Url:    "https://example.com/hook",
+					Events: []urlpb.WebhookEvent{urlpb.WebhookEvent_WEBHOOK_EVENT_UNSPECIFIED},
+				})
+				return err
+			},
+			expectedCode:  codes.InvalidArgument,
+			expectedField: "events",
+		},
+		{
+			name: "invalid url",
+			call: func(ctx context.Context) error {
+				_, err := enabled.RegisterWebhook(ctx, &urlpb.RegisterWebhookRequest{
+					Url:    "example.com/hook",
+					Events: []urlpb.WebhookEvent{urlpb.WebhookEvent_WEBHOOK_EVENT_CREATED},
+				})
+				return err
+			},
+			expectedCode:  codes.InvalidArgument,
+			expectedField: "url",
+		},
+		{
+			name: "negative limit",
+			call: func(ctx context.Context) error {
+				_, err := enabled.ListWebhookDeliveries(ctx, &urlpb.ListWebhookDeliveriesRequest{WebhookId: "hook-1", Limit: -1})
+				return err
+			},
+			expectedCode:  codes.InvalidArgument,
+			expectedField: "limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.anonymous {
+				err = tt.call(context.Background())
+			} else {
+				_, err = asCaller(t, "alice", func(ctx context.Context) (any, error) {
+					return nil, tt.call(ctx)
+				})
+			}
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedField != "" {
+				assert.Equal(t, tt.expectedField, fieldOf(t, err))
+			}
+		})
+	}
+}
+
+// fieldOf returns the field named in err's BadRequest details.
+func fieldOf(t *testing.T, err error) string {
+	t.Helper()
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*errdetails.BadRequest); ok {
+			require.Len(t, d.FieldViolations, 1)
+			return d.FieldViolations[0].Field
+		}
+	}
+	return ""
+}
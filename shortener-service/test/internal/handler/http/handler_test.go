@@ -0,0 +1,321 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/controller"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	httpHandler "github.com/OrtemRepos/ShortURL/shortener-service/internal/handler/http"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeRepo struct {
+	urls map[string]*domain.URL
+}
+
+func (r *fakeRepo) Save(_ context.Context, url *domain.URL, _ time.Duration) error {
+	r.urls[url.ShortURL] = url
+	return nil
+}
+
+func (r *fakeRepo) Get(_ context.Context, shortURL string) (*domain.URL, error) {
+	url, ok := r.urls[shortURL]
+	if !ok {
+		return nil, repository.ErrURLNotFound
+	}
+	return url, nil
+}
+
+func (r *fakeRepo) Delete(_ context.Context, shortURL string, _ time.Duration, _ domain.Precondition) error {
+	delete(r.urls, shortURL)
+	return nil
+}
+
+func (r *fakeRepo) Restore(_ context.Context, _ string) error {
+	return nil
+}
+
+func (r *fakeRepo) SetStatus(_ context.Context, shortURL string, status domain.Status) error {
+	r.urls[shortURL].Status = status
+	return nil
+}
+
+func (r *fakeRepo) ConsumeClick(_ context.Context, _ string, _ time.Duration) (int64, error) {
+	return -1, nil
+}
+
+func TestHandler_Redirect(t *testing.T) {
+	protected := &domain.URL{ShortURL: "secret", OriginalURL: "https://example.com/secret"}
+	require.NoError(t, protected.SetPassword("pass"))
+
+	repo := &fakeRepo{urls: map[string]*domain.URL{
+		"abc123": {ShortURL: "abc123", OriginalURL: "https://example.com"},
+		"secret": protected,
+		"later":  {ShortURL: "later", OriginalURL: "https://example.com", NotBefore: time.Now().Add(time.Hour)},
+		"off":    {ShortURL: "off", OriginalURL: "https://example.com", Status: domain.StatusDisabled},
+		"gone":   {ShortURL: "gone", OriginalURL: "https://example.com", Status: domain.StatusDeleted},
+		"brand":  {ShortURL: "brand", OriginalURL: "https://example.com/brand", Domain: "go.example.com"},
+		"pass": {
+			ShortURL:    "pass",
+			OriginalURL: "https://example.com/landing?id=1",
+			Query:       domain.QueryOptions{Passthrough: true, Merge: domain.MergeRejectConflicts},
+		},
+	}}
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+	handler := httpHandler.New(ctrl, zaptest.NewLogger(t))
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		password         string
+		expectedCode     int
+		expectedLocation string
+		expectedBody     string
+	}{
+		{
+			name:             "redirect",
+			method:           http.MethodGet,
+			path:             "/abc123",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://example.com",
+		},
+		{
+			name:         "not found",
+			method:       http.MethodGet,
+			path:         "/missing",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid code",
+			method:       http.MethodGet,
+			path:         "/codegen:counter",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "password prompt",
+			method:       http.MethodGet,
+			path:         "/secret",
+			expectedCode: http.StatusOK,
+			expectedBody: `type="password"`,
+		},
+		{
+			name:         "wrong password",
+			method:       http.MethodPost,
+			path:         "/secret",
+			password:     "guess",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "Invalid password.",
+		},
+		{
+			name:             "correct password",
+			method:           http.MethodPost,
+			path:             "/secret",
+			password:         "pass",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://example.com/secret",
+		},
+		{
+			name:         "not active yet",
+			method:       http.MethodGet,
+			path:         "/later",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "not active yet",
+		},
+		{
+			name:             "query passthrough",
+			method:           http.MethodGet,
+			path:             "/pass?utm_source=x",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://example.com/landing?id=1&utm_source=x",
+		},
+		{
+			name:         "conflicting query",
+			method:       http.MethodGet,
+			path:         "/pass?id=2",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "disabled",
+			method:       http.MethodGet,
+			path:         "/off",
+			expectedCode: http.StatusGone,
+		},
+		{
+			name:         "deleted",
+			method:       http.MethodGet,
+			path:         "/gone",
+			expectedCode: http.StatusGone,
+		},
+		{
+			name:             "branded domain",
+			method:           http.MethodGet,
+			path:             "http://go.example.com/brand",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://example.com/brand",
+		},
+		{
+			name:             "branded domain on a custom port",
+			method:           http.MethodGet,
+			path:             "http://Go.Example.com:8081/brand",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://example.com/brand",
+		},
+		{
+			name:         "branded link on another domain",
+			method:       http.MethodGet,
+			path:         "http://sho.rt/brand",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.NewReader(url.Values{"password": {tt.password}}.Encode())
+			req := httptest.NewRequest(tt.method, tt.path, body)
+			if tt.method == http.MethodPost {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, rec.Header().Get("Location"))
+			}
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestHandler_StickyVariant(t *testing.T) {
+	repo := &fakeRepo{urls: map[string]*domain.URL{
+		"split": {
+			ShortURL:    "split",
+			OriginalURL: "https://example.com",
+			Split:       domain.SplitSticky,
+			Variants: []domain.Variant{
+				{Name: "a", Destination: "https://example.com/a", Weight: 1},
+				{Name: "b", Destination: "https://example.com/b", Weight: 1},
+			},
+		},
+	}}
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop())
+	handler := httpHandler.New(ctrl, zaptest.NewLogger(t))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/split", nil))
+	require.Equal(t, http.StatusFound, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	location := rec.Header().Get("Location")
+
+	for range 10 {
+		req := httptest.NewRequest(http.MethodGet, "/split", nil)
+		req.AddCookie(cookies[0])
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, location, rec.Header().Get("Location"))
+		assert.Empty(t, rec.Result().Cookies())
+	}
+}
+
+func TestHandler_QRCode(t *testing.T) {
+	repo := &fakeRepo{urls: map[string]*domain.URL{
+		"abc123": {ShortURL: "abc123", OriginalURL: "https://example.com"},
+		"gone":   {ShortURL: "gone", OriginalURL: "https://example.com", Status: domain.StatusDeleted},
+		"brand":  {ShortURL: "brand", OriginalURL: "https://example.com", Domain: "go.example.com"},
+	}}
+	domains, err := domain.NewDomains("https://sho.rt", []string{"go.example.com"})
+	require.NoError(t, err)
+	ctrl := controller.NewController(repo, events.NopPublisher{}, nil, zap.NewNop(), controller.WithDomains(domains))
+	handler := httpHandler.New(ctrl, zaptest.NewLogger(t))
+
+	tests := []struct {
+		name                string
+		path                string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{
+			name:                "png by default",
+			path:                "/v1/abc123/qr",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "image/png",
+		},
+		{
+			name:                "svg",
+			path:                "/v1/abc123/qr?format=svg&size=512&level=high&margin=0",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "image/svg+xml",
+		},
+		{
+			name:         "invalid size",
+			path:         "/v1/abc123/qr?size=big",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown format",
+			path:         "/v1/abc123/qr?format=gif",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "not found",
+			path:         "/v1/missing/qr",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "deleted",
+			path:         "/v1/gone/qr",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:                "branded domain",
+			path:                "http://go.example.com/v1/brand/qr",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "image/png",
+		},
+		{
+			name:                "branded domain on a custom port",
+			path:                "http://go.example.com:8081/v1/brand/qr",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "image/png",
+		},
+		{
+			name:         "branded link on another domain",
+			path:         "http://sho.rt/v1/brand/qr",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, rec.Header().Get("Content-Type"))
+				assert.NotEmpty(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
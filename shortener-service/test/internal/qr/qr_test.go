@@ -0,0 +1,107 @@
+package qr_test
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const link = "https://sho.rt/abc123"
+
+func TestRender_PNG(t *testing.T) {
+	// link encodes as a 25x25 version 2 code at medium error correction.
+	tests := []struct {
+		name         string
+		size         int
+		margin       int
+		expectedSide int
+	}{
+		{name: "default", size: 256, margin: 4, expectedSide: 33 * 7},
+		{name: "no margin", size: 300, margin: 0, expectedSide: 25 * 12},
+		{name: "one pixel per module at least", size: 10, margin: 2, expectedSide: 29},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := qr.DefaultOptions()
+			opts.Size, opts.Margin = tt.size, tt.margin
+
+			data, err := qr.Render(link, opts)
+			require.NoError(t, err)
+
+			img, err := png.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+
+			bounds := img.Bounds()
+			assert.Equal(t, tt.expectedSide, bounds.Dx())
+			assert.Equal(t, tt.expectedSide, bounds.Dy())
+
+			r, _, _, _ := img.At(0, 0).RGBA()
+			if tt.margin > 0 {
+				assert.Equal(t, uint32(0xffff), r, "margin must be white")
+			} else {
+				assert.Equal(t, uint32(0), r, "finder pattern must start at the corner")
+			}
+		})
+	}
+}
+
+func TestRender_SVG(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Format = qr.FormatSVG
+	opts.Size = 512
+
+	data, err := qr.Render(link, opts)
+	require.NoError(t, err)
+
+	svg := string(data)
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Contains(t, svg, `width="512" height="512"`)
+	assert.Contains(t, svg, "M4 4h1v1h-1z", "finder pattern must start after the margin")
+	assert.True(t, strings.HasSuffix(svg, "</svg>"))
+}
+
+func TestRender_Levels(t *testing.T) {
+	low := qr.DefaultOptions()
+	low.Level = qr.LevelLow
+	high := qr.DefaultOptions()
+	high.Level = qr.LevelHigh
+	low.Format, high.Format = qr.FormatSVG, qr.FormatSVG
+
+	lowSVG, err := qr.Render(link, low)
+	require.NoError(t, err)
+	highSVG, err := qr.Render(link, high)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, lowSVG, highSVG)
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*qr.Options)
+	}{
+		{name: "unknown format", modify: func(o *qr.Options) { o.Format = "gif" }},
+		{name: "unknown level", modify: func(o *qr.Options) { o.Level = "extreme" }},
+		{name: "zero size", modify: func(o *qr.Options) { o.Size = 0 }},
+		{name: "size too large", modify: func(o *qr.Options) { o.Size = qr.MaxSize + 1 }},
+		{name: "negative margin", modify: func(o *qr.Options) { o.Margin = -1 }},
+		{name: "margin too large", modify: func(o *qr.Options) { o.Margin = qr.MaxMargin + 1 }},
+	}
+
+	assert.NoError(t, qr.DefaultOptions().Validate())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := qr.DefaultOptions()
+			tt.modify(&opts)
+
+			_, err := qr.Render(link, opts)
+			assert.ErrorIs(t, err, qr.ErrInvalidOptions)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package repository_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeBackend struct {
+	mu    sync.Mutex
+	urls  map[string]string
+	ttl   time.Duration
+	gets  atomic.Int32
+	delay time.Duration
+}
+
+func newFakeBackend(ttl time.Duration) *fakeBackend {
+	return &fakeBackend{urls: make(map[string]string), ttl: ttl}
+}
+
+func (b *fakeBackend) Save(_ context.Context, url *domain.URL, _ time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.urls[url.ShortURL] = url.OriginalURL
+	return nil
+}
+
+func (b *fakeBackend) Get(ctx context.Context, shortURL string) (*domain.URL, error) {
+	b.gets.Add(1)
+	select {
+	case <-time.After(b.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	originalURL, ok := b.urls[shortURL]
+	if !ok {
+		return nil, repository.ErrURLNotFound
+	}
+	return &domain.URL{ShortURL: shortURL, OriginalURL: originalURL}, nil
+}
+
+func (b *fakeBackend) Delete(_ context.Context, shortURL string, _ time.Duration, _ domain.Precondition) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.urls, shortURL)
+	return nil
+}
+
+func (b *fakeBackend) Restore(_ context.Context, _ string) error {
+	return nil
+}
+
+func (b *fakeBackend) SetStatus(_ context.Context, _ string, _ domain.Status) error {
+	return nil
+}
+
+func (b *fakeBackend) TTL(_ context.Context, shortURL string) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.urls[shortURL]; !ok {
+		return 0, repository.ErrURLNotFound
+	}
+	return b.ttl, nil
+}
+
+func (b *fakeBackend) ConsumeClick(_ context.Context, _ string, _ time.Duration) (int64, error) {
+	return -1, nil
+}
+
+func TestCachedURLRepo_Get(t *testing.T) {
+	ctx := context.Background()
+	shortURL := "abc123"
+	originalURL := "https://example.com"
+
+	tests := []struct {
+		name         string
+		backendTTL   time.Duration
+		maxTTL       time.Duration
+		wait         time.Duration
+		expectedGets int32
+	}{
+		{
+			name:         "second read is served from cache",
+			backendTTL:   time.Hour,
+			maxTTL:       time.Minute,
+			expectedGets: 1,
+		},
+		{
+			name:         "entry does not outlive url ttl",
+			backendTTL:   10 * time.Millisecond,
+			maxTTL:       time.Minute,
+			wait:         20 * time.Millisecond,
+			expectedGets: 2,
+		},
+		{
+			name:         "entry does not outlive max ttl",
+			backendTTL:   0,
+			maxTTL:       10 * time.Millisecond,
+			wait:         20 * time.Millisecond,
+			expectedGets: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend(tt.backendTTL)
+			require.NoError(t, backend.Save(ctx, &domain.URL{ShortURL: shortURL, OriginalURL: originalURL}, 0))
+
+			repo, err := repository.NewCachedURLRepo(backend, 10, tt.maxTTL, zaptest.NewLogger(t))
+			require.NoError(t, err)
+
+			_, err = repo.Get(ctx, shortURL)
+			require.NoError(t, err)
+			time.Sleep(tt.wait)
+			result, err := repo.Get(ctx, shortURL)
+			require.NoError(t, err)
+
+			assert.Equal(t, originalURL, result.OriginalURL)
+			assert.Equal(t, tt.expectedGets, backend.gets.Load())
+		})
+	}
+}
+
+func TestCachedURLRepo_ConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	backend := newFakeBackend(time.Hour)
+	backend.delay = 50 * time.Millisecond
+	require.NoError(t, backend.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+
+	repo, err := repository.NewCachedURLRepo(backend, 10, time.Minute, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Get(ctx, "abc123")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), backend.gets.Load())
+}
+
+func TestCachedURLRepo_CanceledCaller(t *testing.T) {
+	backend := newFakeBackend(time.Hour)
+	backend.delay = 50 * time.Millisecond
+	require.NoError(t, backend.Save(context.Background(), &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+
+	repo, err := repository.NewCachedURLRepo(backend, 10, time.Minute, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := repo.Get(ctx, "abc123")
+		first <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := repo.Get(context.Background(), "abc123")
+		second <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-first, context.Canceled)
+	assert.NoError(t, <-second, "waiters do not fail with the caller that started the load")
+	assert.Equal(t, int32(1), backend.gets.Load())
+}
+
+func TestCachedURLRepo_GetUncached(t *testing.T) {
+	ctx := context.Background()
+	backend := newFakeBackend(time.Hour)
+	require.NoError(t, backend.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+
+	repo, err := repository.NewCachedURLRepo(backend, 10, time.Minute, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	_, err = repo.Get(ctx, "abc123")
+	require.NoError(t, err)
+	require.NoError(t, backend.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.org"}, 0))
+
+	url, err := repo.GetUncached(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", url.OriginalURL)
+	assert.Equal(t, int32(2), backend.gets.Load())
+}
+
+func TestCachedURLRepo_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		invalidate func(repo *repository.CachedURLRepo, backend *fakeBackend) error
+	}{
+		{
+			name: "local delete",
+			invalidate: func(repo *repository.CachedURLRepo, _ *fakeBackend) error {
+				return repo.Delete(ctx, "abc123", 0, domain.Precondition{})
+			},
+		},
+		{
+			name: "remote delete",
+			invalidate: func(repo *repository.CachedURLRepo, backend *fakeBackend) error {
+				if err := backend.Delete(ctx, "abc123", 0, domain.Precondition{}); err != nil {
+					return err
+				}
+				repo.Invalidate("abc123")
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend(time.Hour)
+			require.NoError(t, backend.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+
+			repo, err := repository.NewCachedURLRepo(backend, 10, time.Minute, zaptest.NewLogger(t))
+			require.NoError(t, err)
+
+			_, err = repo.Get(ctx, "abc123")
+			require.NoError(t, err)
+
+			require.NoError(t, tt.invalidate(repo, backend))
+
+			_, err = repo.Get(ctx, "abc123")
+			assert.ErrorIs(t, err, repository.ErrURLNotFound)
+		})
+	}
+}
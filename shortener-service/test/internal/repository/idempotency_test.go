@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRedisIdempotencyStore_Reserve(t *testing.T) {
+	ctx := context.Background()
+	key := "idempotency:req-1"
+
+	tests := []struct {
+		name          string
+		claimed       bool
+		stored        []any
+		execErr       error
+		expected      []byte
+		expectedError error
+	}{
+		{
+			name:    "new key",
+			claimed: true,
+			stored:  []any{"fp", nil},
+		},
+		{
+			name:     "finished request",
+			stored:   []any{"fp", "response"},
+			expected: []byte("response"),
+		},
+		{
+			name:          "request in progress",
+			stored:        []any{"fp", nil},
+			expectedError: repository.ErrIdempotencyInProgress,
+		},
+		{
+			name:          "different request",
+			stored:        []any{"other", "response"},
+			expectedError: repository.ErrIdempotencyMismatch,
+		},
+		{
+			name:          "Redis error",
+			execErr:       redis.ErrClosed,
+			expectedError: redis.ErrClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			store := repository.NewRedisIdempotencyStore(db, time.Hour, zaptest.NewLogger(t))
+
+			mock.ExpectTxPipeline()
+			mock.ExpectHSetNX(key, "fingerprint", "fp").SetVal(tt.claimed)
+			mock.ExpectExpireNX(key, time.Hour).SetVal(tt.claimed)
+			if tt.execErr != nil {
+				mock.ExpectHMGet(key, "fingerprint", "response").SetErr(tt.execErr)
+			} else {
+				mock.ExpectHMGet(key, "fingerprint", "response").SetVal(tt.stored)
+				mock.ExpectTxPipelineExec()
+			}
+
+			response, err := store.Reserve(ctx, "req-1", "fp")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, response)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedisIdempotencyStore_CompleteAndRelease(t *testing.T) {
+	ctx := context.Background()
+	key := "idempotency:req-1"
+	db, mock := redismock.NewClientMock()
+	store := repository.NewRedisIdempotencyStore(db, time.Hour, zaptest.NewLogger(t))
+
+	mock.ExpectTxPipeline()
+	mock.ExpectHSet(key, "response", []byte("response")).SetVal(1)
+	mock.ExpectExpire(key, time.Hour).SetVal(true)
+	mock.ExpectTxPipelineExec()
+	mock.ExpectDel(key).SetVal(1)
+
+	assert.NoError(t, store.Complete(ctx, "req-1", []byte("response")))
+	assert.NoError(t, store.Release(ctx, "req-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
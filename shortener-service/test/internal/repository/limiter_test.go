@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRedisFailureLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	key := "{abc123}:password_attempts:203.0.113.7"
+	window := time.Minute.Milliseconds()
+
+	tests := []struct {
+		name          string
+		mockSetup     func(mock redismock.ClientMock)
+		expected      bool
+		expectedError error
+	}{
+		{
+			name: "first attempt",
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, window).SetVal(int64(1))
+			},
+			expected: true,
+		},
+		{
+			name: "last allowed attempt",
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, window).SetVal(int64(3))
+			},
+			expected: true,
+		},
+		{
+			name: "limit reached",
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, window).SetVal(int64(4))
+			},
+			expected: false,
+		},
+		{
+			name: "Redis error",
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, window).SetErr(redis.ErrClosed)
+			},
+			expectedError: redis.ErrClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			limiter := repository.NewRedisFailureLimiter(db, 3, time.Minute, zaptest.NewLogger(t))
+
+			tt.mockSetup(mock)
+
+			allowed, err := limiter.Allow(ctx, "abc123", "203.0.113.7")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, allowed)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedisFailureLimiter_Reset(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	limiter := repository.NewRedisFailureLimiter(db, 3, time.Minute, zaptest.NewLogger(t))
+
+	mock.ExpectDel("{abc123}:password_attempts:203.0.113.7").SetVal(1)
+
+	assert.NoError(t, limiter.Reset(ctx, "abc123", "203.0.113.7"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -13,10 +13,14 @@ import (
 	"go.uber.org/zap/zaptest"
 )
 
+// scriptSHA matches the SHA1 of any Lua script run through EVALSHA.
+const scriptSHA = `^[0-9a-f]{40}$`
+
 func TestRedisURLRepo_Save(t *testing.T) {
 	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	shortURL := "abc123"
+	key := "url:{abc123}"
 	originalURL := "https://example.com"
 	expTime := 10 * time.Minute
 
@@ -37,7 +41,49 @@ func TestRedisURLRepo_Save(t *testing.T) {
 			expTime:   expTime,
 			setupMock: true,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectSet(shortURL, originalURL, expTime).SetVal("OK")
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL},
+					expTime.Milliseconds(), "original_url", originalURL).SetVal(int64(1))
+			},
+		},
+		{
+			name: "short URL taken",
+			input: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+			},
+			expTime:   expTime,
+			setupMock: true,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL},
+					expTime.Milliseconds(), "original_url", originalURL).SetVal(int64(0))
+			},
+			expectedErr: repository.ErrURLExists,
+		},
+		{
+			name: "successful save with password",
+			input: &domain.URL{
+				ShortURL:     shortURL,
+				OriginalURL:  originalURL,
+				PasswordHash: "hash",
+			},
+			expTime:   expTime,
+			setupMock: true,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL},
+					expTime.Milliseconds(), "original_url", originalURL, "password_hash", "hash").SetVal(int64(1))
+			},
+		},
+		{
+			name: "successful save without expiration",
+			input: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+			},
+			expTime:   0,
+			setupMock: true,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL},
+					int64(0), "original_url", originalURL).SetVal(int64(1))
 			},
 		},
 		{
@@ -48,6 +94,20 @@ func TestRedisURLRepo_Save(t *testing.T) {
 			mockSetup:   func(mock redismock.ClientMock) {},
 			expectedErr: repository.ErrURLNil,
 		},
+		{
+			name: "successful save with click limit",
+			input: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+				MaxClicks:   1,
+			},
+			expTime:   expTime,
+			setupMock: true,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL},
+					expTime.Milliseconds(), "original_url", originalURL, "max_clicks", int64(1), "clicks_left", int64(1)).SetVal(int64(1))
+			},
+		},
 		{
 			name: "Redis error",
 			input: &domain.URL{
@@ -57,7 +117,8 @@ func TestRedisURLRepo_Save(t *testing.T) {
 			expTime:   expTime,
 			setupMock: true,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectSet(shortURL, originalURL, expTime).SetErr(redis.ErrClosed)
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL},
+					expTime.Milliseconds(), "original_url", originalURL).SetErr(redis.ErrClosed)
 			},
 			expectedErr: redis.ErrClosed,
 		},
@@ -102,6 +163,7 @@ func TestRedisURLRepo_Get(t *testing.T) {
 	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	shortURL := "abc123"
+	key := "url:{abc123}"
 	originalURL := "https://example.com"
 
 	tests := []struct {
@@ -115,18 +177,134 @@ func TestRedisURLRepo_Get(t *testing.T) {
 			name:  "successful receipt",
 			input: shortURL,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectGet(shortURL).SetVal(originalURL)
+				mock.ExpectHGetAll(key).SetVal(map[string]string{
+					"original_url": originalURL,
+				})
+			},
+			expectedURL: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+				Status:      domain.StatusActive,
+				Version:     1,
+			},
+		},
+		{
+			name:  "password protected URL",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectHGetAll(key).SetVal(map[string]string{
+					"original_url":  originalURL,
+					"password_hash": "hash",
+				})
+			},
+			expectedURL: &domain.URL{
+				ShortURL:     shortURL,
+				OriginalURL:  originalURL,
+				PasswordHash: "hash",
+				Status:       domain.StatusActive,
+				Version:      1,
+			},
+		},
+		{
+			name:  "URL with activation window",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectHGetAll(key).SetVal(map[string]string{
+					"original_url": originalURL,
+					"not_before":   "1700000000000",
+					"expires_at":   "1700003600000",
+				})
+			},
+			expectedURL: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+				NotBefore:   time.UnixMilli(1700000000000),
+				ExpiresAt:   time.UnixMilli(1700003600000),
+				Status:      domain.StatusActive,
+				Version:     1,
+			},
+		},
+		{
+			name:  "URL with rules",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectHGetAll(key).SetVal(map[string]string{
+					"original_url": originalURL,
+					"rules":        `[{"platform":"ios","destination":"https://apps.apple.com/app"}]`,
+				})
+			},
+			expectedURL: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+				Status:      domain.StatusActive,
+				Version:     1,
+				Rules: []domain.Rule{
+					{Platform: domain.PlatformIOS, Destination: "https://apps.apple.com/app"},
+				},
+			},
+		},
+		{
+			name:  "URL on a branded domain",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectHGetAll(key).SetVal(map[string]string{
+					"original_url": originalURL,
+					"domain":       "go.example.com",
+				})
+			},
+			expectedURL: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+				Status:      domain.StatusActive,
+				Version:     1,
+				Domain:      "go.example.com",
+			},
+		},
+		{
+			name:  "disabled URL",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectHGetAll(key).SetVal(map[string]string{
+					"original_url": originalURL,
+					"status":       "disabled",
+					"version":      "2",
+				})
 			},
 			expectedURL: &domain.URL{
 				ShortURL:    shortURL,
 				OriginalURL: originalURL,
+				Status:      domain.StatusDisabled,
+				Version:     2,
+			},
+		},
+		{
+			name:  "legacy string URL",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectHGetAll(key).SetVal(map[string]string{})
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{shortURL}).SetVal([]any{"original_url", originalURL})
+			},
+			expectedURL: &domain.URL{
+				ShortURL:    shortURL,
+				OriginalURL: originalURL,
+				Status:      domain.StatusActive,
+				Version:     1,
 			},
 		},
 		{
 			name:  "URL not found",
 			input: shortURL,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectGet(shortURL).RedisNil()
+				mock.ExpectHGetAll(key).SetVal(map[string]string{})
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{shortURL}).SetVal([]any{})
+			},
+			expectedError: repository.ErrURLNotFound,
+		},
+		{
+			name:  "internal key is not read as a legacy URL",
+			input: "codegen:counter",
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectHGetAll("url:{codegen:counter}").SetVal(map[string]string{})
 			},
 			expectedError: repository.ErrURLNotFound,
 		},
@@ -134,7 +312,7 @@ func TestRedisURLRepo_Get(t *testing.T) {
 			name:  "Redis error",
 			input: shortURL,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectGet(shortURL).SetErr(redis.ErrClosed)
+				mock.ExpectHGetAll(key).SetErr(redis.ErrClosed)
 			},
 			expectedError: redis.ErrClosed,
 		},
@@ -172,10 +350,13 @@ func TestRedisURLRepo_Delete(t *testing.T) {
 	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	shortURL := "abc123"
+	key := "url:{abc123}"
+	retention := time.Hour
 
 	tests := []struct {
 		name          string
 		input         string
+		cond          domain.Precondition
 		mockSetup     func(mock redismock.ClientMock)
 		expectedError error
 	}{
@@ -183,21 +364,75 @@ func TestRedisURLRepo_Delete(t *testing.T) {
 			name:  "successful removal",
 			input: shortURL,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectDel(shortURL).SetVal(1)
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(0)).SetVal(int64(1))
 			},
 		},
 		{
 			name:  "URL not found",
 			input: shortURL,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectDel(shortURL).SetVal(0)
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(0)).SetVal(int64(0))
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL}, `^\d+$`).SetVal(int64(0))
+			},
+			expectedError: repository.ErrURLNotFound,
+		},
+		{
+			name:  "legacy URL is migrated first",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(0)).SetVal(int64(0))
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL}, `^\d+$`).SetVal(int64(1))
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(0)).SetVal(int64(1))
+			},
+		},
+		{
+			name:  "legacy URL migration fails",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(0)).SetVal(int64(0))
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL}, `^\d+$`).SetErr(redis.ErrClosed)
+			},
+			expectedError: redis.ErrClosed,
+		},
+		{
+			name:  "internal key is not migrated",
+			input: "codegen:counter",
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{`^url:\{codegen:counter\}$`},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(0)).SetVal(int64(0))
+			},
+			expectedError: repository.ErrURLNotFound,
+		},
+		{
+			name:  "precondition met",
+			input: shortURL,
+			cond:  domain.Precondition{OriginalURL: "https://example.com", Version: 3},
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^https://example.com$", int64(3)).SetVal(int64(1))
+			},
+		},
+		{
+			name:  "precondition failed",
+			input: shortURL,
+			cond:  domain.Precondition{Version: 2},
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(2)).SetVal(int64(-1))
 			},
+			expectedError: repository.ErrURLChanged,
 		},
 		{
 			name:  "Redis error",
 			input: shortURL,
 			mockSetup: func(mock redismock.ClientMock) {
-				mock.ExpectDel(shortURL).SetErr(redis.ErrClosed)
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key},
+					`^\d+$`, retention.Milliseconds(), "^$", int64(0)).SetErr(redis.ErrClosed)
 			},
 			expectedError: redis.ErrClosed,
 		},
@@ -216,7 +451,7 @@ func TestRedisURLRepo_Delete(t *testing.T) {
 
 			tt.mockSetup(mock)
 
-			err := repo.Delete(ctx, tt.input)
+			err := repo.Delete(ctx, tt.input, retention, tt.cond)
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
@@ -229,3 +464,253 @@ func TestRedisURLRepo_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestRedisURLRepo_Restore(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	shortURL := "abc123"
+	key := "url:{abc123}"
+
+	tests := []struct {
+		name          string
+		result        int64
+		expectedError error
+	}{
+		{name: "successful restore", result: 1},
+		{name: "URL not found", result: -1, expectedError: repository.ErrURLNotFound},
+		{name: "URL not deleted", result: -2, expectedError: repository.ErrURLNotDeleted},
+		{name: "URL expired", result: -3, expectedError: repository.ErrURLExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			repo := repository.NewRedisURLRepo(db, logger)
+
+			mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, `^\d+$`).SetVal(tt.result)
+			if tt.result == -1 {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL}, `^\d+$`).SetVal(int64(0))
+			}
+
+			err := repo.Restore(ctx, shortURL)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedisURLRepo_SetStatus(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	shortURL := "abc123"
+	key := "url:{abc123}"
+
+	tests := []struct {
+		name          string
+		status        domain.Status
+		legacy        bool
+		result        int64
+		expectedError error
+	}{
+		{name: "disable", status: domain.StatusDisabled, result: 1},
+		{name: "enable", status: domain.StatusActive, result: 1},
+		{name: "disable legacy URL", status: domain.StatusDisabled, legacy: true, result: 0},
+		{name: "URL not found", status: domain.StatusDisabled, result: 0, expectedError: repository.ErrURLNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			repo := repository.NewRedisURLRepo(db, logger)
+
+			mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, string(tt.status)).SetVal(tt.result)
+			if tt.result == 0 {
+				migrated := int64(0)
+				if tt.legacy {
+					migrated = 1
+				}
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL}, `^\d+$`).SetVal(migrated)
+			}
+			if tt.legacy {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, string(tt.status)).SetVal(int64(1))
+			}
+
+			err := repo.SetStatus(ctx, shortURL, tt.status)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedisURLRepo_TTL(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	shortURL := "abc123"
+	key := "url:{abc123}"
+
+	tests := []struct {
+		name          string
+		input         string
+		mockSetup     func(mock redismock.ClientMock)
+		expectedTTL   time.Duration
+		expectedError error
+	}{
+		{
+			name:  "expiring url",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectPTTL(key).SetVal(10 * time.Minute)
+			},
+			expectedTTL: 10 * time.Minute,
+		},
+		{
+			name:  "url without expiration",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectPTTL(key).SetVal(-1)
+			},
+			expectedTTL: 0,
+		},
+		{
+			name:  "URL not found",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectPTTL(key).SetVal(-2)
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{shortURL}).SetVal([]any{})
+			},
+			expectedError: repository.ErrURLNotFound,
+		},
+		{
+			name:  "legacy URL",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.ExpectPTTL(key).SetVal(-2)
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{shortURL}).SetVal([]any{"original_url", "https://example.com"})
+				mock.ExpectPTTL(shortURL).SetVal(5 * time.Minute)
+			},
+			expectedTTL: 5 * time.Minute,
+		},
+		{
+			name:          "empty shortURL",
+			input:         "",
+			mockSetup:     func(mock redismock.ClientMock) {},
+			expectedError: repository.ErrShortURLEmpty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			repo := repository.NewRedisURLRepo(db, logger)
+
+			tt.mockSetup(mock)
+
+			ttl, err := repo.TTL(ctx, tt.input)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedTTL, ttl)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedisURLRepo_ConsumeClick(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	shortURL := "abc123"
+	key := "url:{abc123}"
+	retention := time.Hour
+
+	tests := []struct {
+		name          string
+		input         string
+		mockSetup     func(mock redismock.ClientMock)
+		expectedLeft  int64
+		expectedError error
+	}{
+		{
+			name:  "clicks left",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, `^\d+$`, retention.Milliseconds()).SetVal(int64(2))
+			},
+			expectedLeft: 2,
+		},
+		{
+			name:  "last click",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, `^\d+$`, retention.Milliseconds()).SetVal(int64(0))
+			},
+			expectedLeft: 0,
+		},
+		{
+			name:  "no click limit",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, `^\d+$`, retention.Milliseconds()).SetVal(int64(-2))
+			},
+			expectedLeft: -1,
+		},
+		{
+			name:  "URL not found",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, `^\d+$`, retention.Milliseconds()).SetVal(int64(-1))
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key, shortURL}, `^\d+$`).SetVal(int64(0))
+			},
+			expectedError: repository.ErrURLNotFound,
+		},
+		{
+			name:  "used up",
+			input: shortURL,
+			mockSetup: func(mock redismock.ClientMock) {
+				mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, `^\d+$`, retention.Milliseconds()).SetVal(int64(-3))
+			},
+			expectedError: repository.ErrURLExhausted,
+		},
+		{
+			name:          "empty shortURL",
+			input:         "",
+			mockSetup:     func(mock redismock.ClientMock) {},
+			expectedError: repository.ErrShortURLEmpty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			repo := repository.NewRedisURLRepo(db, logger)
+
+			tt.mockSetup(mock)
+
+			left, err := repo.ConsumeClick(ctx, tt.input, retention)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedLeft, left)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
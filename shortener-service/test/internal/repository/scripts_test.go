@@ -0,0 +1,312 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// The tests below run the Lua scripts on miniredis rather than checking the
+// commands sent, so that what the scripts do to the data is covered too.
+
+func newMiniredisRepo(t *testing.T) (*miniredis.Miniredis, *repository.RedisURLRepo) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return server, repository.NewRedisURLRepo(client, zaptest.NewLogger(t))
+}
+
+func TestConsumeClickScript(t *testing.T) {
+	ctx := context.Background()
+	server, repo := newMiniredisRepo(t)
+	retention := time.Hour
+
+	require.NoError(t, repo.Save(ctx, &domain.URL{
+		ShortURL:    "abc123",
+		OriginalURL: "https://example.com",
+		MaxClicks:   2,
+	}, 0))
+
+	left, err := repo.ConsumeClick(ctx, "abc123", retention)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), left)
+
+	left, err = repo.ConsumeClick(ctx, "abc123", retention)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), left)
+
+	assert.Equal(t, "deleted", server.HGet("url:{abc123}", "status"))
+	assert.Equal(t, retention, server.TTL("url:{abc123}"))
+
+	_, err = repo.ConsumeClick(ctx, "abc123", retention)
+	assert.ErrorIs(t, err, repository.ErrURLExhausted)
+}
+
+func TestConsumeClickScript_NoRetention(t *testing.T) {
+	ctx := context.Background()
+	server, repo := newMiniredisRepo(t)
+
+	require.NoError(t, repo.Save(ctx, &domain.URL{
+		ShortURL:    "abc123",
+		OriginalURL: "https://example.com",
+		MaxClicks:   1,
+	}, 0))
+
+	left, err := repo.ConsumeClick(ctx, "abc123", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), left)
+	assert.False(t, server.Exists("url:{abc123}"))
+}
+
+func TestConsumeClickScript_NotLimited(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newMiniredisRepo(t)
+
+	require.NoError(t, repo.Save(ctx, &domain.URL{ShortURL: "free", OriginalURL: "https://example.com"}, 0))
+	require.NoError(t, repo.Save(ctx, &domain.URL{
+		ShortURL:    "off",
+		OriginalURL: "https://example.com",
+		MaxClicks:   1,
+	}, 0))
+	require.NoError(t, repo.SetStatus(ctx, "off", domain.StatusDisabled))
+
+	left, err := repo.ConsumeClick(ctx, "free", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), left)
+
+	_, err = repo.ConsumeClick(ctx, "off", time.Hour)
+	assert.ErrorIs(t, err, repository.ErrURLNotFound)
+
+	_, err = repo.ConsumeClick(ctx, "missing", time.Hour)
+	assert.ErrorIs(t, err, repository.ErrURLNotFound)
+}
+
+func TestConsumeClickScript_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	server, repo := newMiniredisRepo(t)
+	const maxClicks, visitors = 10, 50
+
+	require.NoError(t, repo.Save(ctx, &domain.URL{
+		ShortURL:    "abc123",
+		OriginalURL: "https://example.com",
+		MaxClicks:   maxClicks,
+	}, 0))
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		unknown   []error
+	)
+	for range visitors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.ConsumeClick(ctx, "abc123", time.Hour)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, repository.ErrURLExhausted):
+				unknown = append(unknown, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, unknown)
+	assert.Equal(t, maxClicks, succeeded)
+	assert.Equal(t, "deleted", server.HGet("url:{abc123}", "status"))
+	assert.Equal(t, "0", server.HGet("url:{abc123}", "clicks_left"))
+}
+
+func TestDeleteScript_Retention(t *testing.T) {
+	ctx := context.Background()
+	server, repo := newMiniredisRepo(t)
+	retention := time.Hour
+
+	require.NoError(t, repo.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+	require.NoError(t, repo.Delete(ctx, "abc123", retention, domain.Precondition{}))
+
+	assert.Equal(t, "deleted", server.HGet("url:{abc123}", "status"))
+	assert.NotEmpty(t, server.HGet("url:{abc123}", "deleted_at"))
+	assert.Equal(t, retention, server.TTL("url:{abc123}"))
+
+	// The tombstone keeps the short URL from being reissued.
+	err := repo.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.org"}, 0)
+	assert.ErrorIs(t, err, repository.ErrURLExists)
+	assert.ErrorIs(t, repo.Delete(ctx, "abc123", retention, domain.Precondition{}), repository.ErrURLNotFound)
+
+	server.FastForward(retention)
+	assert.False(t, server.Exists("url:{abc123}"))
+	assert.NoError(t, repo.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.org"}, 0))
+}
+
+func TestRestoreScript(t *testing.T) {
+	ctx := context.Background()
+	retention := time.Hour
+
+	t.Run("within retention", func(t *testing.T) {
+		server, repo := newMiniredisRepo(t)
+		expiresAt := time.Now().Add(24 * time.Hour)
+		require.NoError(t, repo.Save(ctx, &domain.URL{
+			ShortURL:    "abc123",
+			OriginalURL: "https://example.com",
+			ExpiresAt:   expiresAt,
+		}, time.Until(expiresAt)))
+		require.NoError(t, repo.Delete(ctx, "abc123", retention, domain.Precondition{}))
+
+		require.NoError(t, repo.Restore(ctx, "abc123"))
+
+		url, err := repo.Get(ctx, "abc123")
+		require.NoError(t, err)
+		assert.Equal(t, domain.StatusActive, url.Status)
+		assert.Equal(t, int64(2), url.Version)
+		assert.Empty(t, server.HGet("url:{abc123}", "deleted_at"))
+		assert.InDelta(t, 24*time.Hour, server.TTL("url:{abc123}"), float64(time.Minute))
+	})
+
+	t.Run("without expiration", func(t *testing.T) {
+		server, repo := newMiniredisRepo(t)
+		require.NoError(t, repo.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+		require.NoError(t, repo.Delete(ctx, "abc123", retention, domain.Precondition{}))
+
+		require.NoError(t, repo.Restore(ctx, "abc123"))
+		assert.Zero(t, server.TTL("url:{abc123}"))
+	})
+
+	t.Run("after retention", func(t *testing.T) {
+		server, repo := newMiniredisRepo(t)
+		require.NoError(t, repo.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+		require.NoError(t, repo.Delete(ctx, "abc123", retention, domain.Precondition{}))
+
+		server.FastForward(retention)
+		assert.ErrorIs(t, repo.Restore(ctx, "abc123"), repository.ErrURLNotFound)
+	})
+
+	t.Run("past its expiration", func(t *testing.T) {
+		_, repo := newMiniredisRepo(t)
+		require.NoError(t, repo.Save(ctx, &domain.URL{
+			ShortURL:    "abc123",
+			OriginalURL: "https://example.com",
+			ExpiresAt:   time.Now().Add(-time.Minute),
+		}, 0))
+		require.NoError(t, repo.Delete(ctx, "abc123", retention, domain.Precondition{}))
+
+		assert.ErrorIs(t, repo.Restore(ctx, "abc123"), repository.ErrURLExpired)
+	})
+
+	t.Run("not deleted", func(t *testing.T) {
+		_, repo := newMiniredisRepo(t)
+		require.NoError(t, repo.Save(ctx, &domain.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}, 0))
+
+		assert.ErrorIs(t, repo.Restore(ctx, "abc123"), repository.ErrURLNotDeleted)
+	})
+}
+
+func TestMigrateLegacyScript(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("plain string", func(t *testing.T) {
+		server, repo := newMiniredisRepo(t)
+		require.NoError(t, server.Set("old", "https://example.com/old"))
+		server.SetTTL("old", 10*time.Minute)
+
+		url, err := repo.Get(ctx, "old")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/old", url.OriginalURL)
+		assert.False(t, server.Exists("url:{old}"), "reads do not migrate")
+
+		require.NoError(t, repo.SetStatus(ctx, "old", domain.StatusDisabled))
+
+		assert.False(t, server.Exists("old"))
+		assert.Equal(t, "https://example.com/old", server.HGet("url:{old}", "original_url"))
+		assert.Equal(t, "disabled", server.HGet("url:{old}", "status"))
+		assert.NotEmpty(t, server.HGet("url:{old}", "expires_at"))
+		assert.Equal(t, 10*time.Minute, server.TTL("url:{old}"))
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		server, repo := newMiniredisRepo(t)
+		server.HSet("old", "original_url", "https://example.com/old", "version", "3")
+
+		require.NoError(t, repo.Delete(ctx, "old", time.Hour, domain.Precondition{Version: 3}))
+
+		assert.False(t, server.Exists("old"))
+		assert.Equal(t, "deleted", server.HGet("url:{old}", "status"))
+		require.NoError(t, repo.Restore(ctx, "old"))
+		assert.Equal(t, "4", server.HGet("url:{old}", "version"))
+	})
+
+	t.Run("taken code is not reissued", func(t *testing.T) {
+		server, repo := newMiniredisRepo(t)
+		require.NoError(t, server.Set("old", "https://example.com/old"))
+
+		err := repo.Save(ctx, &domain.URL{ShortURL: "old", OriginalURL: "https://example.org"}, 0)
+		assert.ErrorIs(t, err, repository.ErrURLExists)
+	})
+}
+
+func TestURLScripts_LeaveInternalKeysAlone(t *testing.T) {
+	ctx := context.Background()
+	server, repo := newMiniredisRepo(t)
+
+	require.NoError(t, server.Set("codegen:counter", "41"))
+	require.NoError(t, server.Set("idempotency:req-1", "https://example.com"))
+	server.HSet("webhook:{w1}", "url", "https://hooks.example.com", "owner", "alice")
+	server.HSet("{abc123}:password_attempts:10.0.0.1", "original_url", "https://example.com")
+	// A bare key with a valid code that does not hold a URL.
+	require.NoError(t, server.Set("counter", "41"))
+
+	for _, key := range []string{
+		"codegen:counter",
+		"idempotency:req-1",
+		"webhook:{w1}",
+		"{abc123}:password_attempts:10.0.0.1",
+		"counter",
+	} {
+		t.Run(key, func(t *testing.T) {
+			_, err := repo.Get(ctx, key)
+			assert.ErrorIs(t, err, repository.ErrURLNotFound)
+			assert.ErrorIs(t, repo.SetStatus(ctx, key, domain.StatusDisabled), repository.ErrURLNotFound)
+			assert.ErrorIs(t, repo.Delete(ctx, key, time.Hour, domain.Precondition{}), repository.ErrURLNotFound)
+			assert.ErrorIs(t, repo.Restore(ctx, key), repository.ErrURLNotFound)
+			_, err = repo.ConsumeClick(ctx, key, time.Hour)
+			assert.ErrorIs(t, err, repository.ErrURLNotFound)
+			_, err = repo.TTL(ctx, key)
+			assert.ErrorIs(t, err, repository.ErrURLNotFound)
+		})
+	}
+
+	assert.Equal(t, "41", mustGet(t, server, "codegen:counter"))
+	assert.Equal(t, "https://example.com", mustGet(t, server, "idempotency:req-1"))
+	assert.Equal(t, "alice", server.HGet("webhook:{w1}", "owner"))
+	assert.Equal(t, "https://example.com", server.HGet("{abc123}:password_attempts:10.0.0.1", "original_url"))
+	assert.Equal(t, "41", mustGet(t, server, "counter"))
+	assert.Equal(t, []string{
+		"codegen:counter",
+		"counter",
+		"idempotency:req-1",
+		"webhook:{w1}",
+		"{abc123}:password_attempts:10.0.0.1",
+	}, server.Keys())
+}
+
+func mustGet(t *testing.T, server *miniredis.Miniredis, key string) string {
+	t.Helper()
+	value, err := server.Get(key)
+	require.NoError(t, err)
+	return value
+}
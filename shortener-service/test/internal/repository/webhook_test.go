@@ -0,0 +1,119 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRedisWebhookStore_GetWebhook(t *testing.T) {
+	createdAt := time.UnixMilli(1700000000000)
+
+	tests := []struct {
+		name        string
+		fields      map[string]string
+		expected    *domain.Webhook
+		expectedErr error
+	}{
+		{
+			name: "found",
+			fields: map[string]string{
+				"owner":      "alice",
+				"url":        "https://example.com/hook",
+				"secret":     "s3cret",
+				"events":     "created,expired",
+				"created_at": "1700000000000",
+			},
+			expected: &domain.Webhook{
+				ID:        "id",
+				Owner:     "alice",
+				URL:       "https://example.com/hook",
+				Secret:    "s3cret",
+				Events:    []domain.WebhookEvent{domain.WebhookCreated, domain.WebhookExpired},
+				CreatedAt: createdAt,
+			},
+		},
+		{name: "missing", fields: map[string]string{}, expectedErr: repository.ErrWebhookNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			store := repository.NewRedisWebhookStore(db, 100, time.Hour, zaptest.NewLogger(t))
+
+			mock.ExpectHGetAll("webhook:{id}").SetVal(tt.fields)
+
+			webhook, err := store.GetWebhook(context.Background(), "id")
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expected, webhook)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedisWebhookStore_DeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		deliveries  []any
+		notOwned    bool
+		expectedErr error
+	}{
+		{
+			name:       "owned with deliveries",
+			deliveries: []any{"d-2", "d-1", "d-1"},
+		},
+		{name: "owned without deliveries"},
+		{name: "not owned", notOwned: true, expectedErr: repository.ErrWebhookNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			store := repository.NewRedisWebhookStore(db, 100, time.Hour, zaptest.NewLogger(t))
+
+			eval := mock.Regexp().ExpectEvalSha(scriptSHA,
+				[]string{"webhook:{id}", "webhook:{id}:deliveries", "webhook:{id}:dead"}, "alice")
+			if tt.notOwned {
+				eval.RedisNil()
+			} else {
+				eval.SetVal(tt.deliveries)
+			}
+			if len(tt.deliveries) > 0 {
+				var keys []string
+				var members []any
+				for _, id := range tt.deliveries {
+					keys = append(keys, "webhook:{id}:delivery:"+id.(string))
+					members = append(members, "id:"+id.(string))
+				}
+				mock.ExpectDel(keys...).SetVal(2)
+				mock.ExpectZRem("webhooks:queue", members...).SetVal(2)
+			}
+			if !tt.notOwned {
+				mock.ExpectSRem("webhooks:owner:alice", "id").SetVal(1)
+			}
+
+			err := store.DeleteWebhook(context.Background(), "alice", "id")
+			require.ErrorIs(t, err, tt.expectedErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedisWebhookStore_RemoveDelivery(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	store := repository.NewRedisWebhookStore(db, 100, time.Hour, zaptest.NewLogger(t))
+
+	mock.ExpectDel("webhook:{id}:delivery:d-1").SetVal(1)
+	mock.ExpectZRem("webhooks:queue", "id:d-1").SetVal(1)
+
+	err := store.RemoveDelivery(context.Background(), &domain.Delivery{ID: "d-1", WebhookID: "id"})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
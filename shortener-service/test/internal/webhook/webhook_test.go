@@ -0,0 +1,417 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/domain"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/events"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/repository"
+	"github.com/OrtemRepos/ShortURL/shortener-service/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeStore struct {
+	mu         sync.Mutex
+	webhooks   map[string]*domain.Webhook
+	deliveries map[string]*domain.Delivery
+	expiries   map[domain.ExpiringURL]time.Time
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		webhooks:   map[string]*domain.Webhook{},
+		deliveries: map[string]*domain.Delivery{},
+		expiries:   map[domain.ExpiringURL]time.Time{},
+	}
+}
+
+func (s *fakeStore) SaveWebhook(_ context.Context, webhook *domain.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (s *fakeStore) GetWebhook(_ context.Context, id string) (*domain.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return nil, repository.ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+func (s *fakeStore) Webhooks(_ context.Context, owner string) ([]*domain.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var webhooks []*domain.Webhook
+	for _, webhook := range s.webhooks {
+		if webhook.Owner == owner {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (s *fakeStore) DeleteWebhook(_ context.Context, owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook, ok := s.webhooks[id]
+	if !ok || webhook.Owner != owner {
+		return repository.ErrWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	return nil
+}
+
+func (s *fakeStore) AddDelivery(_ context.Context, delivery *domain.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *delivery
+	s.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (s *fakeStore) UpdateDelivery(ctx context.Context, delivery *domain.Delivery) error {
+	return s.AddDelivery(ctx, delivery)
+}
+
+func (s *fakeStore) RemoveDelivery(_ context.Context, delivery *domain.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deliveries, delivery.ID)
+	return nil
+}
+
+func (s *fakeStore) ClaimDeliveries(_ context.Context, now time.Time, _ time.Duration, limit int64) ([]*domain.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*domain.Delivery
+	for _, delivery := range s.deliveries {
+		if int64(len(due)) < limit && delivery.Status == domain.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			copied := *delivery
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeStore) Deliveries(_ context.Context, webhookID string, deadOnly bool, _ int64) ([]*domain.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []*domain.Delivery
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID && (!deadOnly || delivery.Status == domain.DeliveryDead) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *fakeStore) ScheduleExpiry(_ context.Context, url domain.ExpiringURL, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiries[url] = at
+	return nil
+}
+
+func (s *fakeStore) CancelExpiry(_ context.Context, url domain.ExpiringURL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expiries, url)
+	return nil
+}
+
+func (s *fakeStore) ClaimExpiries(_ context.Context, now time.Time, _ time.Duration, _ int64) ([]domain.ExpiringURL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []domain.ExpiringURL
+	for url, at := range s.expiries {
+		if !at.After(now) {
+			due = append(due, url)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeStore) only(t *testing.T) *domain.Delivery {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.Len(t, s.deliveries, 1)
+	for _, delivery := range s.deliveries {
+		return delivery
+	}
+	return nil
+}
+
+// endpoint records the requests it gets and answers with the given status
+// codes in turn, then 200.
+type endpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, r)
+	e.bodies = append(e.bodies, body)
+	code := http.StatusOK
+	if len(e.statuses) > 0 {
+		code, e.statuses = e.statuses[0], e.statuses[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func (e *endpoint) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.requests)
+}
+
+func setup(t *testing.T, statuses []int, opts ...webhook.Option) (*webhook.Service, *fakeStore, *endpoint, *httptest.Server) {
+	t.Helper()
+	store := newFakeStore()
+	ep := &endpoint{statuses: statuses}
+	srv := httptest.NewServer(ep)
+	t.Cleanup(srv.Close)
+	// The test server listens on loopback.
+	opts = append([]webhook.Option{webhook.WithAllowPrivateNetworks()}, opts...)
+	return webhook.NewService(store, zap.NewNop(), opts...), store, ep, srv
+}
+
+func TestService_DeliversSignedEvents(t *testing.T) {
+	svc, store, ep, srv := setup(t, nil)
+	ctx := context.Background()
+
+	hook, err := svc.Register(ctx, "alice", srv.URL, []domain.WebhookEvent{domain.WebhookCreated})
+	require.NoError(t, err)
+	assert.NotEmpty(t, hook.Secret)
+
+	require.NoError(t, svc.Publish(ctx, events.Event{
+		Name:     "url_created",
+		ShortURL: "abc",
+		Owner:    "alice",
+		Payload:  []byte(`{"original_url":"https://example.com"}`),
+	}))
+	require.NoError(t, svc.Poll(ctx))
+
+	require.Equal(t, 1, ep.count())
+	req, body := ep.requests[0], ep.bodies[0]
+	assert.Equal(t, "created", req.Header.Get(webhook.EventHeader))
+
+	var timestamp int64
+	var signature string
+	_, err = fmt.Sscanf(strings.Replace(req.Header.Get(webhook.SignatureHeader), ",v1=", " ", 1), "t=%d %s", &timestamp, &signature)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Sign(hook.Secret, timestamp, body), signature)
+
+	var payload struct {
+		ID       string          `json:"id"`
+		Event    string          `json:"event"`
+		ShortURL string          `json:"short_url"`
+		Data     json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, req.Header.Get(webhook.DeliveryHeader), payload.ID)
+	assert.Equal(t, "abc", payload.ShortURL)
+	assert.JSONEq(t, `{"original_url":"https://example.com"}`, string(payload.Data))
+
+	delivery := store.only(t)
+	assert.Equal(t, domain.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, http.StatusOK, delivery.LastStatusCode)
+}
+
+func TestService_OnlySubscribedEventsOfOwner(t *testing.T) {
+	svc, store, _, srv := setup(t, nil)
+	ctx := context.Background()
+
+	_, err := svc.Register(ctx, "alice", srv.URL, []domain.WebhookEvent{domain.WebhookDeleted})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_created", ShortURL: "abc", Owner: "alice"}))
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_deleted", ShortURL: "abc", Owner: "bob"}))
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_status_changed", ShortURL: "abc", Owner: "alice"}))
+	assert.Empty(t, store.deliveries)
+
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_deleted", ShortURL: "abc", Owner: "alice"}))
+	assert.Equal(t, domain.WebhookDeleted, store.only(t).Event)
+}
+
+func TestService_RetriesThenDeadLetters(t *testing.T) {
+	tests := []struct {
+		name           string
+		statuses       []int
+		expectedStatus domain.DeliveryStatus
+		expectedCalls  int
+	}{
+		{name: "recovers", statuses: []int{500, 503}, expectedStatus: domain.DeliveryDelivered, expectedCalls: 3},
+		{name: "gives up", statuses: []int{500, 500, 500}, expectedStatus: domain.DeliveryDead, expectedCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store, ep, srv := setup(t, tt.statuses, webhook.WithRetries(3, time.Millisecond, 2*time.Millisecond))
+			ctx := context.Background()
+
+			_, err := svc.Register(ctx, "alice", srv.URL, []domain.WebhookEvent{domain.WebhookVisited})
+			require.NoError(t, err)
+			require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_visited", ShortURL: "abc", Owner: "alice"}))
+
+			for range 5 {
+				require.NoError(t, svc.Poll(ctx))
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			assert.Equal(t, tt.expectedCalls, ep.count())
+			delivery := store.only(t)
+			assert.Equal(t, tt.expectedStatus, delivery.Status)
+			assert.Equal(t, 3, delivery.Attempts)
+			if tt.expectedStatus == domain.DeliveryDead {
+				assert.Equal(t, "endpoint returned 500 Internal Server Error", delivery.LastError)
+			}
+		})
+	}
+}
+
+func TestService_DeletedWebhookDropsDeliveries(t *testing.T) {
+	svc, store, ep, srv := setup(t, nil)
+	ctx := context.Background()
+
+	// The fake store keeps deliveries of deleted webhooks, like ones the
+	// Redis store trimmed from the history before the webhook was deleted.
+	hook, err := svc.Register(ctx, "alice", srv.URL, []domain.WebhookEvent{domain.WebhookVisited})
+	require.NoError(t, err)
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_visited", ShortURL: "abc", Owner: "alice"}))
+	require.NoError(t, svc.Delete(ctx, "alice", hook.ID))
+	require.NoError(t, svc.Poll(ctx))
+
+	assert.Zero(t, ep.count())
+	assert.Empty(t, store.deliveries)
+}
+
+func TestService_AnnouncesExpiry(t *testing.T) {
+	svc, store, ep, srv := setup(t, nil)
+	ctx := context.Background()
+
+	_, err := svc.Register(ctx, "alice", srv.URL, []domain.WebhookEvent{domain.WebhookExpired})
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(-time.Second).UTC()
+	require.NoError(t, svc.Publish(ctx, events.Event{
+		Name:     "url_created",
+		ShortURL: "abc",
+		Owner:    "alice",
+		Payload:  fmt.Appendf(nil, `{"expires_at":%q}`, expiresAt.Format(time.RFC3339Nano)),
+	}))
+	require.NoError(t, svc.Publish(ctx, events.Event{
+		Name:     "url_created",
+		ShortURL: "def",
+		Owner:    "alice",
+		Payload:  fmt.Appendf(nil, `{"expires_at":%q}`, expiresAt.Format(time.RFC3339Nano)),
+	}))
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_deleted", ShortURL: "def", Owner: "alice"}))
+	require.Len(t, store.expiries, 1, "deleting a URL cancels its expiry")
+
+	require.NoError(t, svc.Poll(ctx))
+	assert.Empty(t, store.expiries)
+	delivery := store.only(t)
+	assert.Equal(t, domain.WebhookExpired, delivery.Event)
+	assert.Equal(t, "abc", delivery.ShortURL)
+
+	require.NoError(t, svc.Poll(ctx))
+	assert.Equal(t, 1, ep.count())
+}
+
+func TestService_Deliveries(t *testing.T) {
+	svc, _, _, srv := setup(t, nil)
+	ctx := context.Background()
+
+	hook, err := svc.Register(ctx, "alice", srv.URL, []domain.WebhookEvent{domain.WebhookVisited})
+	require.NoError(t, err)
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_visited", ShortURL: "abc", Owner: "alice"}))
+
+	deliveries, err := svc.Deliveries(ctx, "alice", hook.ID, false, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	_, err = svc.Deliveries(ctx, "bob", hook.ID, false, 10)
+	assert.ErrorIs(t, err, repository.ErrWebhookNotFound, "other owners cannot see deliveries")
+}
+
+type denyAll struct{}
+
+func (denyAll) Check(string) error { return assert.AnError }
+
+func TestService_Register(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		events   []domain.WebhookEvent
+		opts     []webhook.Option
+		expected error
+	}{
+		{name: "not http", endpoint: "ftp://example.com/hook", events: []domain.WebhookEvent{domain.WebhookCreated}, expected: domain.ErrInvalidWebhookURL},
+		{name: "no events", endpoint: "https://example.com/hook", expected: domain.ErrInvalidWebhookEvents},
+		{name: "loopback", endpoint: "http://127.0.0.1:8080/hook", events: []domain.WebhookEvent{domain.WebhookCreated}, expected: domain.ErrPrivateWebhookURL},
+		{name: "localhost", endpoint: "http://LocalHost./hook", events: []domain.WebhookEvent{domain.WebhookCreated}, expected: domain.ErrPrivateWebhookURL},
+		{name: "private", endpoint: "https://10.1.2.3/hook", events: []domain.WebhookEvent{domain.WebhookCreated}, expected: domain.ErrPrivateWebhookURL},
+		{name: "link-local", endpoint: "http://169.254.169.254/latest/meta-data", events: []domain.WebhookEvent{domain.WebhookCreated}, expected: domain.ErrPrivateWebhookURL},
+		{name: "unspecified ipv6", endpoint: "http://[::]/hook", events: []domain.WebhookEvent{domain.WebhookCreated}, expected: domain.ErrPrivateWebhookURL},
+		{name: "mapped ipv4", endpoint: "http://[::ffff:192.168.0.1]/hook", events: []domain.WebhookEvent{domain.WebhookCreated}, expected: domain.ErrPrivateWebhookURL},
+		{name: "checker refuses", endpoint: "https://example.com/hook", events: []domain.WebhookEvent{domain.WebhookCreated}, opts: []webhook.Option{webhook.WithURLChecker(denyAll{})}, expected: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			svc := webhook.NewService(store, zap.NewNop(), tt.opts...)
+
+			_, err := svc.Register(context.Background(), "alice", tt.endpoint, tt.events)
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Empty(t, store.webhooks)
+		})
+	}
+}
+
+func TestService_RefusesPrivateAddressesWhenDialling(t *testing.T) {
+	store := newFakeStore()
+	ep := &endpoint{}
+	srv := httptest.NewServer(ep)
+	t.Cleanup(srv.Close)
+	svc := webhook.NewService(store, zap.NewNop(), webhook.WithRetries(1, time.Millisecond, time.Millisecond))
+	ctx := context.Background()
+
+	// A host name vetted at registration may resolve to a private address
+	// later, so the address is checked again when it is dialled.
+	endpoint := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	require.NoError(t, store.SaveWebhook(ctx, &domain.Webhook{
+		ID:     "hook",
+		Owner:  "alice",
+		URL:    endpoint,
+		Events: []domain.WebhookEvent{domain.WebhookVisited},
+	}))
+	require.NoError(t, svc.Publish(ctx, events.Event{Name: "url_visited", ShortURL: "abc", Owner: "alice"}))
+	require.NoError(t, svc.Poll(ctx))
+
+	assert.Zero(t, ep.count())
+	delivery := store.only(t)
+	assert.Equal(t, domain.DeliveryDead, delivery.Status)
+	assert.Equal(t, "endpoint resolves to a private address", delivery.LastError)
+}